@@ -3,50 +3,261 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
+	"fmt"
+	"homework/internal/config"
+	"homework/internal/logging"
+	"homework/internal/metrics"
+	"homework/internal/migrator"
+	"homework/internal/tracing"
 	"homework/internal/usecase"
+	"homework/internal/worker"
 	"log"
-	"net/http"
+	"log/slog"
 	"os"
 	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
 
 	httpGateway "homework/internal/gateways/http"
-	eventRepository "homework/internal/repository/event/postgres"
-	sensorRepository "homework/internal/repository/sensor/postgres"
-	userRepository "homework/internal/repository/user/postgres"
+	repositoryMetrics "homework/internal/repository/metrics"
+	sensorCache "homework/internal/repository/sensor/cache"
+	repositoryTracing "homework/internal/repository/tracing"
 )
 
+// tracingShutdownTimeout - время на отправку накопленных трасс при остановке
+const tracingShutdownTimeout = 5 * time.Second
+
 func main() {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[0]+" migrate", os.Args[2:]); err != nil && !errors.Is(err, flag.ErrHelp) {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+
+	// процесс завершается только после того, как run выполнит все отложенные закрытия и сбросит трассы
+	if err := run(os.Args[0], os.Args[1:]); err != nil && !errors.Is(err, flag.ErrHelp) {
+		slog.Error("can't run server", "err", err)
+		os.Exit(1)
+	}
+}
+
+// run - запускает сервер и возвращает управление после его остановки
+func run(name string, args []string) error {
+	cfg, err := config.Load(name, args, os.Getenv, os.Stderr)
+	if err != nil {
+		return fmt.Errorf("can't load config: %w", err)
+	}
+
+	logger, err := logging.New(cfg.Logging.Config(), os.Stderr)
+	if err != nil {
+		return fmt.Errorf("can't create logger: %w", err)
+	}
+	// записи через log тоже попадают в logger
+	slog.SetDefault(logger)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	config, err := pgxpool.ParseConfig(os.Getenv("DATABASE_URL"))
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing.Config(), os.Stdout)
 	if err != nil {
-		log.Fatalf("can't parse pgxpool config")
+		return fmt.Errorf("can't setup tracing: %w", err)
 	}
+	defer func() {
+		// трассы, накопленные к остановке, отправляются до выхода
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			slog.Error("can't flush traces", "err", err)
+		}
+	}()
 
-	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	store, err := openStorage(ctx, cfg.Storage)
 	if err != nil {
-		log.Fatalf("can't create new pool")
+		return fmt.Errorf("can't open storage: %w", err)
+	}
+	defer store.mustClose()
+
+	if store.migrator != nil {
+		if err := store.migrator.Run(ctx, cfg.Storage.Postgres.Migrate); err != nil {
+			return fmt.Errorf("can't migrate database: %w", err)
+		}
+	}
+
+	// span операций открываются на уровне хранилища, под метриками и кэшем
+	var (
+		er  usecase.EventRepository       = repositoryTracing.NewEventRepository(store.event)
+		sr  usecase.SensorRepository      = repositoryTracing.NewSensorRepository(store.sensor)
+		ur  usecase.UserRepository        = repositoryTracing.NewUserRepository(store.user)
+		sor usecase.SensorOwnerRepository = repositoryTracing.NewSensorOwnerRepository(store.sensorOwner)
+		qr  usecase.QuarantineRepository  = repositoryTracing.NewQuarantineRepository(store.quarantine)
+	)
+
+	var (
+		eventOptions  []func(*usecase.Event)
+		sensorOptions []func(*usecase.Sensor)
+		serverOptions []func(*httpGateway.Server)
+	)
+
+	// без реестра метрики не собираются, а /metrics не регистрируется
+	var reg *prometheus.Registry
+	if cfg.Metrics.Enabled {
+		reg = metrics.NewRegistry()
+		if store.pool != nil {
+			reg.MustRegister(metrics.NewPoolCollector(store.pool))
+		}
+
+		// длительность операций учитывается на уровне хранилища, под кэшем
+		o := repositoryMetrics.NewObserver(reg)
+		er = o.EventRepository(er)
+		sr = o.SensorRepository(sr)
+		ur = o.UserRepository(ur)
+		sor = o.SensorOwnerRepository(sor)
+		qr = o.QuarantineRepository(qr)
+
+		eventOptions = append(eventOptions, usecase.WithObserver(metrics.NewEvents(reg)))
+		serverOptions = append(serverOptions, httpGateway.WithMetrics(reg))
+	}
+
+	if cfg.SensorCache.Enabled {
+		cache := sensorCache.NewSensorRepository(sr, cfg.SensorCache.Size, cfg.SensorCache.TTL)
+		if reg != nil {
+			metrics.RegisterSensorCache(reg, cache)
+		}
+		sr = cache
+	}
+	if cfg.RateLimit.Events.Enabled {
+		eventOptions = append(eventOptions, usecase.WithRateLimiter(cfg.RateLimit.Events.SensorLimiter()))
+		serverOptions = append(serverOptions, httpGateway.WithClientRateLimit(cfg.RateLimit.Events.Client.Limit()))
+	}
+	if cfg.Quarantine.Enabled {
+		eventOptions = append(eventOptions, usecase.WithQuarantine(qr))
+		sensorOptions = append(sensorOptions, usecase.WithAdoption(qr, er))
 	}
-	defer pool.Close()
 
-	er := eventRepository.NewEventRepository(pool)
-	sr := sensorRepository.NewSensorRepository(pool)
-	ur := userRepository.NewUserRepository(pool)
-	sor := userRepository.NewSensorOwnerRepository(pool)
+	// фоновые задачи останавливаются только после того, как сервер завершит начатые запросы,
+	// иначе принятые в них события могут не попасть в очередь
+	workersCtx, stopWorkers := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopWorkers()
+	var workers sync.WaitGroup
+	runWorker := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workersCtx)
+		}()
+	}
+
+	var checks []httpGateway.ReadinessCheck
+	if store.ping != nil {
+		checks = append(checks, httpGateway.ReadinessCheck{Name: "postgres", Check: store.ping})
+	}
+	if store.migrator != nil && cfg.Storage.Postgres.Migrate != migrator.ModeNone {
+		checks = append(checks, httpGateway.ReadinessCheck{Name: "migrations", Check: store.migrator.Check})
+	}
+	runPeriodic := func(name string, interval time.Duration, run func(ctx context.Context) error) {
+		p := worker.NewPeriodic(name, interval, run)
+		checks = append(checks, httpGateway.ReadinessCheck{Name: name, Check: func(context.Context) error {
+			return p.Check()
+		}})
+		runWorker(p.Run)
+	}
+
+	// без очереди события сохраняются сразу
+	if cfg.Ingestion.Enabled {
+		ingestion := usecase.NewIngestion(er, sr, cfg.Ingestion.IngestionConfig())
+		eventOptions = append(eventOptions, usecase.WithIngestion(ingestion))
+		if reg != nil {
+			metrics.RegisterIngestion(reg, ingestion)
+		}
+		checks = append(checks, httpGateway.ReadinessCheck{Name: "ingestion", Check: func(context.Context) error {
+			return ingestion.Check()
+		}})
+		runWorker(ingestion.Run)
+	}
 
 	useCases := httpGateway.UseCases{
-		Event:  usecase.NewEvent(er, sr),
-		Sensor: usecase.NewSensor(sr),
+		Event:  usecase.NewEvent(er, sr, eventOptions...),
+		Sensor: usecase.NewSensor(sr, sensorOptions...),
 		User:   usecase.NewUser(ur, sor, sr),
 	}
 
-	// TODO реализовать веб-сервис
+	policy := cfg.Retention.Policy()
+	if cfg.Retention.Enabled() {
+		pruner := usecase.NewRetention(er, sr, policy, cfg.Retention.BatchSize)
+		runPeriodic("events pruning", cfg.Retention.Interval, func(ctx context.Context) error {
+			report, err := pruner.Prune(ctx)
+			slog.InfoContext(ctx, "events pruned", "removed", report.Removed, "duration", report.Duration)
+			return err
+		})
+	}
+
+	if cfg.Quarantine.TTL > 0 {
+		runPeriodic("quarantine pruning", cfg.Quarantine.Interval, func(ctx context.Context) error {
+			removed, err := qr.DeleteQuarantinedEventsBefore(ctx, time.Now().Add(-cfg.Quarantine.TTL))
+			slog.InfoContext(ctx, "quarantined events pruned", "removed", removed)
+			return err
+		})
+	}
+
+	if store.snapshot != nil {
+		runPeriodic("storage snapshot", cfg.Storage.InMemory.SnapshotInterval, store.snapshot)
+	}
+
+	if partitions := store.partitions; partitions != nil {
+		runPeriodic("events partitioning", cfg.Partitions.Interval, func(ctx context.Context) error {
+			now := time.Now()
+			created, err := partitions.EnsurePartitions(ctx, now, now.AddDate(0, cfg.Partitions.Ahead, 0))
+			if len(created) > 0 {
+				slog.InfoContext(ctx, "events partitions created", "count", len(created))
+			}
+			if err != nil {
+				return err
+			}
+
+			// секция удаляется целиком, только когда её события устарели для всех датчиков
+			longest, ok := policy.Longest()
+			if !ok {
+				return nil
+			}
+			dropped, err := partitions.DropPartitionsBefore(ctx, now.Add(-longest))
+			if len(dropped) > 0 {
+				slog.InfoContext(ctx, "events partitions dropped", "count", len(dropped))
+			}
+			return err
+		})
+	}
 
-	r := httpGateway.NewServer(useCases)
-	if err := r.Run(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		log.Printf("error during server shutdown: %v", err)
+	if cfg.Metrics.Sensors.Enabled {
+		sensors := metrics.NewSensorCollector(useCases.Sensor, useCases.User, cfg.Metrics.Sensors.TTL)
+		serverOptions = append(serverOptions, httpGateway.WithSensorMetrics(sensors))
 	}
+
+	serverOptions = append(serverOptions,
+		httpGateway.WithHost(cfg.HTTP.Host),
+		httpGateway.WithPort(cfg.HTTP.Port),
+		httpGateway.WithTimeouts(cfg.HTTP.Timeouts()),
+		httpGateway.WithShutdownTimeout(cfg.HTTP.ShutdownTimeout),
+		httpGateway.WithShutdownDelay(cfg.HTTP.ShutdownDelay),
+		httpGateway.WithTrustedProxies(cfg.HTTP.TrustedProxies),
+		httpGateway.WithReadinessChecks(checks...),
+		httpGateway.WithMaxConcurrentRequests(cfg.RateLimit.MaxConcurrentRequests),
+	)
+	if cfg.Logging.AccessLog.Enabled {
+		serverOptions = append(serverOptions, httpGateway.WithAccessLog(logger, cfg.Logging.AccessLog.SampleRatio))
+	}
+	r := httpGateway.NewServer(useCases, serverOptions...)
+	if err := r.Run(ctx); err != nil {
+		slog.Error("error during server shutdown", "err", err)
+	}
+
+	// события, принятые в очередь, сохраняются до закрытия хранилища
+	stopWorkers()
+	workers.Wait()
+
+	return nil
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/config"
+	"homework/internal/migrator"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+)
+
+var errMigrateUsage = errors.New("usage: migrate up|down [N]|version [flags]")
+
+// runMigrate - подкоманда migrate: команда и число шагов для down указываются до флагов конфигурации
+func runMigrate(name string, args []string) error {
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+	command, args := args[0], args[1:]
+
+	steps := 1
+	if command == "down" && len(args) > 0 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
+			steps, args = n, args[1:]
+		}
+	}
+
+	cfg, err := config.Load(name+" "+command, args, os.Getenv, os.Stderr)
+	if err != nil {
+		return err
+	}
+	if cfg.Storage.Backend != config.BackendPostgres {
+		return fmt.Errorf("migrations are supported only by %s backend, got %s", config.BackendPostgres, cfg.Storage.Backend)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	pool, err := openPool(ctx, cfg.Storage.Postgres)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	m := migrator.New(pool)
+	switch command {
+	case "up":
+		if err := m.Up(ctx); err != nil {
+			return err
+		}
+	case "down":
+		if err := m.Down(ctx, steps); err != nil {
+			return err
+		}
+	case "version":
+	default:
+		return errMigrateUsage
+	}
+
+	v, dirty, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	latest, err := m.Latest()
+	if err != nil {
+		return err
+	}
+	log.Printf("schema version %d, dirty %t, latest migration %d", v, dirty, latest)
+
+	return nil
+}
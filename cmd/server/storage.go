@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/config"
+	"homework/internal/migrator"
+	"homework/internal/tracing"
+	"homework/internal/usecase"
+	"homework/pkg/journal"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	eventFile "homework/internal/repository/event/file"
+	eventMemory "homework/internal/repository/event/inmemory"
+	eventRepository "homework/internal/repository/event/postgres"
+	quarantineMemory "homework/internal/repository/quarantine/inmemory"
+	quarantineRepository "homework/internal/repository/quarantine/postgres"
+	sensorFile "homework/internal/repository/sensor/file"
+	sensorMemory "homework/internal/repository/sensor/inmemory"
+	sensorRepository "homework/internal/repository/sensor/postgres"
+	"homework/internal/repository/snapshot"
+	userFile "homework/internal/repository/user/file"
+	userMemory "homework/internal/repository/user/inmemory"
+	userRepository "homework/internal/repository/user/postgres"
+)
+
+// storage - репозитории выбранного хранилища
+type storage struct {
+	event       usecase.EventRepository
+	sensor      usecase.SensorRepository
+	user        usecase.UserRepository
+	sensorOwner usecase.SensorOwnerRepository
+	quarantine  usecase.QuarantineRepository
+	// partitions - обслуживание секций events, есть только у postgres
+	partitions *eventRepository.PartitionManager
+	// migrator - миграции схемы, есть только у postgres
+	migrator *migrator.Migrator
+	// ping - проверка соединения с базой, есть только у postgres
+	ping func(ctx context.Context) error
+	// pool - пул соединений для метрик, есть только у postgres
+	pool *pgxpool.Pool
+	// snapshot - сохранение состояния на диск, есть только у inmemory со снимком
+	snapshot func(ctx context.Context) error
+
+	closers []func() error
+}
+
+// openStorage - открывает хранилище, выбранное в конфигурации
+func openStorage(ctx context.Context, c config.Storage) (*storage, error) {
+	switch c.Backend {
+	case config.BackendFile:
+		return openFileStorage(c.File)
+	case config.BackendInMemory:
+		return openMemoryStorage(c.InMemory.SnapshotPath)
+	default:
+		return openPostgresStorage(ctx, c.Postgres)
+	}
+}
+
+func openPostgresStorage(ctx context.Context, c config.Postgres) (*storage, error) {
+	pool, err := openPool(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage{
+		event:       eventRepository.NewEventRepository(pool),
+		sensor:      sensorRepository.NewSensorRepository(pool),
+		user:        userRepository.NewUserRepository(pool),
+		sensorOwner: userRepository.NewSensorOwnerRepository(pool),
+		quarantine:  quarantineRepository.NewQuarantineRepository(pool),
+		partitions:  eventRepository.NewPartitionManager(pool),
+		migrator:    migrator.New(pool),
+		ping:        pool.Ping,
+		pool:        pool,
+		closers: []func() error{func() error {
+			pool.Close()
+			return nil
+		}},
+	}, nil
+}
+
+func openPool(ctx context.Context, c config.Postgres) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(c.URL)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse pgxpool config: %w", err)
+	}
+	poolConfig.MaxConns = c.MaxConns
+	poolConfig.MinConns = c.MinConns
+	poolConfig.MaxConnLifetime = c.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = c.MaxConnIdleTime
+	poolConfig.ConnConfig.ConnectTimeout = c.ConnectTimeout
+	poolConfig.ConnConfig.Tracer = tracing.NewQueryTracer()
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("can't create new pool: %w", err)
+	}
+
+	return pool, nil
+}
+
+// openFileStorage - хранилище в локальных файлах каталога c.Dir. Карантин хранится только в памяти.
+func openFileStorage(c config.File) (*storage, error) {
+	s := &storage{quarantine: quarantineMemory.NewQuarantineRepository()}
+	dir, snapshotEvery := c.Dir, journal.WithSnapshotEvery(c.SnapshotEvery)
+
+	er, err := eventFile.NewEventRepository(dir, snapshotEvery)
+	if err != nil {
+		return nil, err
+	}
+	s.event = er
+	s.closers = append(s.closers, er.Close)
+
+	sr, err := sensorFile.NewSensorRepository(dir, snapshotEvery)
+	if err != nil {
+		return nil, errors.Join(err, s.close())
+	}
+	s.sensor = sr
+	s.closers = append(s.closers, sr.Close)
+
+	ur, err := userFile.NewUserRepository(dir, snapshotEvery)
+	if err != nil {
+		return nil, errors.Join(err, s.close())
+	}
+	s.user = ur
+	s.closers = append(s.closers, ur.Close)
+
+	sor, err := userFile.NewSensorOwnerRepository(dir, snapshotEvery)
+	if err != nil {
+		return nil, errors.Join(err, s.close())
+	}
+	s.sensorOwner = sor
+	s.closers = append(s.closers, sor.Close)
+
+	return s, nil
+}
+
+// openMemoryStorage - хранилище в памяти. Если задан snapshotPath, состояние загружается из снимка
+// при запуске и сохраняется в него при закрытии. Карантин в снимок не входит.
+func openMemoryStorage(snapshotPath string) (*storage, error) {
+	repos := snapshot.Repositories{
+		Event:       eventMemory.NewEventRepository(),
+		Sensor:      sensorMemory.NewSensorRepository(),
+		User:        userMemory.NewUserRepository(),
+		SensorOwner: userMemory.NewSensorOwnerRepository(),
+	}
+	s := &storage{
+		event:       repos.Event,
+		sensor:      repos.Sensor,
+		user:        repos.User,
+		sensorOwner: repos.SensorOwner,
+		quarantine:  quarantineMemory.NewQuarantineRepository(),
+	}
+	if snapshotPath == "" {
+		return s, nil
+	}
+
+	state, err := snapshot.Load(snapshotPath, repos)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		slog.Info("snapshot not found, starting with empty storage", "path", snapshotPath)
+	case err != nil:
+		return nil, fmt.Errorf("can't load snapshot: %w", err)
+	default:
+		slog.Info("snapshot loaded", "path", snapshotPath, "created_at", state.CreatedAt.Format(time.RFC3339))
+	}
+
+	s.snapshot = func(ctx context.Context) error {
+		return snapshot.Save(ctx, snapshotPath, repos)
+	}
+	s.closers = append(s.closers, func() error {
+		return s.snapshot(context.Background())
+	})
+
+	return s, nil
+}
+
+func (s *storage) close() error {
+	var errs []error
+	for _, closer := range s.closers {
+		errs = append(errs, closer())
+	}
+
+	return errors.Join(errs...)
+}
+
+// mustClose - закрывает хранилище при завершении, ошибки только логируются
+func (s *storage) mustClose() {
+	if err := s.close(); err != nil {
+		slog.Error("can't close storage", "err", err)
+	}
+}
@@ -0,0 +1,53 @@
+// Package buildinfo - сведения о сборке, которые задаются при линковке:
+//
+//	go build -ldflags "-X homework/internal/buildinfo.version=v1.2.3 -X homework/internal/buildinfo.commit=$(git rev-parse HEAD) -X homework/internal/buildinfo.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Если коммит не задан, он берётся из сведений о VCS, которые go build встраивает сам.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	version   = "dev"
+	commit    = ""
+	buildTime = ""
+)
+
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+	// Modified - сборка из рабочего каталога с незафиксированными изменениями
+	Modified bool `json:"modified"`
+}
+
+// Get - сведения о текущей сборке
+func Get() Info {
+	info := Info{
+		Version:   version,
+		Commit:    commit,
+		BuildTime: buildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = s.Value
+			}
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+
+	return info
+}
@@ -0,0 +1,614 @@
+// Package config - конфигурация сервера: значения по умолчанию, файл YAML, переменные окружения и флаги.
+// Каждый следующий источник переопределяет предыдущий.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/migrator"
+	"homework/internal/ratelimit"
+	"homework/internal/tracing"
+	"homework/internal/usecase"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	httpGateway "homework/internal/gateways/http"
+)
+
+const (
+	BackendPostgres = "postgres"
+	BackendInMemory = "inmemory"
+	BackendFile     = "file"
+)
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+type Config struct {
+	HTTP        HTTP        `yaml:"http"`
+	Storage     Storage     `yaml:"storage"`
+	SensorCache SensorCache `yaml:"sensor_cache"`
+	Ingestion   Ingestion   `yaml:"ingestion"`
+	Quarantine  Quarantine  `yaml:"quarantine"`
+	Retention   Retention   `yaml:"retention"`
+	Partitions  Partitions  `yaml:"partitions"`
+	Metrics     Metrics     `yaml:"metrics"`
+	Tracing     Tracing     `yaml:"tracing"`
+	Logging     Logging     `yaml:"logging"`
+	RateLimit   RateLimit   `yaml:"rate_limit"`
+}
+
+type HTTP struct {
+	Host string `yaml:"host"`
+	Port uint16 `yaml:"port"`
+	// таймауты http.Server, 0 - без ограничения
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout - время на завершение начатых запросов при остановке
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// ShutdownDelay - сколько принимать запросы после начала остановки, пока балансировщик исключает сервер по /readyz
+	ShutdownDelay time.Duration `yaml:"shutdown_delay"`
+	// TrustedProxies - адреса и подсети обратных прокси, от которых принимаются X-Forwarded-For и X-Real-IP.
+	// Без них адрес клиента берётся из соединения.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type Storage struct {
+	// Backend - хранилище: postgres, inmemory или file
+	Backend  string   `yaml:"backend"`
+	Postgres Postgres `yaml:"postgres"`
+	InMemory InMemory `yaml:"inmemory"`
+	File     File     `yaml:"file"`
+}
+
+type Postgres struct {
+	URL string `yaml:"url"`
+	// Migrate - действие с миграциями при запуске: up, check или none
+	Migrate         string        `yaml:"migrate"`
+	MaxConns        int32         `yaml:"max_conns"`
+	MinConns        int32         `yaml:"min_conns"`
+	ConnectTimeout  time.Duration `yaml:"connect_timeout"`
+	MaxConnLifetime time.Duration `yaml:"max_conn_lifetime"`
+	MaxConnIdleTime time.Duration `yaml:"max_conn_idle_time"`
+}
+
+type InMemory struct {
+	// SnapshotPath - файл снимка, пустой путь - данные не сохраняются
+	SnapshotPath     string        `yaml:"snapshot_path"`
+	SnapshotInterval time.Duration `yaml:"snapshot_interval"`
+}
+
+type File struct {
+	Dir string `yaml:"dir"`
+	// SnapshotEvery - количество записей журнала между снимками
+	SnapshotEvery int `yaml:"snapshot_every"`
+}
+
+// Metrics - выдача метрик в формате Prometheus на /metrics
+type Metrics struct {
+	Enabled bool           `yaml:"enabled"`
+	Sensors SensorsMetrics `yaml:"sensors"`
+}
+
+// SensorsMetrics - выдача состояния активных датчиков на /metrics/sensors
+type SensorsMetrics struct {
+	Enabled bool `yaml:"enabled"`
+	// TTL - время, в течение которого собранные метрики отдаются без обращения к хранилищу
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// Tracing - экспорт трасс OpenTelemetry
+type Tracing struct {
+	// Exporter - куда отправляются трассы: none, stdout или otlp
+	Exporter string `yaml:"exporter"`
+	// Endpoint - URL OTLP/HTTP коллектора, пустой URL берётся из переменных OTEL_EXPORTER_OTLP_*
+	Endpoint string `yaml:"endpoint"`
+	// SampleRatio - доля записываемых трасс, начатых сервером
+	SampleRatio float64 `yaml:"sample_ratio"`
+	ServiceName string  `yaml:"service_name"`
+}
+
+// Logging - журнал сервера
+type Logging struct {
+	// Level - минимальный уровень записей: debug, info, warn или error
+	Level string `yaml:"level"`
+	// Format - формат записей: text или json
+	Format    string    `yaml:"format"`
+	AccessLog AccessLog `yaml:"access_log"`
+}
+
+// AccessLog - журнал запросов
+type AccessLog struct {
+	Enabled bool `yaml:"enabled"`
+	// SampleRatio - доля записываемых запросов, запросы с ошибкой сервера записываются всегда
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// RateLimit - ограничение частоты событий и одновременных запросов
+type RateLimit struct {
+	Events EventsRateLimit `yaml:"events"`
+	// MaxConcurrentRequests - сколько запросов API обрабатывается одновременно, 0 - без ограничения
+	MaxConcurrentRequests int `yaml:"max_concurrent_requests"`
+}
+
+// EventsRateLimit - ограничение частоты POST /events
+type EventsRateLimit struct {
+	Enabled bool `yaml:"enabled"`
+	// Sensor - ограничение событий одного датчика для типов без своего ограничения и незарегистрированных датчиков
+	Sensor Limit `yaml:"sensor"`
+	// ByType - ограничения событий одного датчика по типу
+	ByType map[domain.SensorType]Limit `yaml:"by_type"`
+	// Client - ограничение событий с одного адреса клиента
+	Client Limit `yaml:"client"`
+}
+
+// Limit - в среднем rate событий в секунду и до burst подряд, rate 0 - без ограничения
+type Limit struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+type SensorCache struct {
+	Enabled bool          `yaml:"enabled"`
+	Size    int           `yaml:"size"`
+	TTL     time.Duration `yaml:"ttl"`
+}
+
+type Ingestion struct {
+	// Enabled - отложенная запись событий через очередь
+	Enabled       bool          `yaml:"enabled"`
+	QueueSize     int           `yaml:"queue_size"`
+	BatchSize     int           `yaml:"batch_size"`
+	Workers       int           `yaml:"workers"`
+	FlushInterval time.Duration `yaml:"flush_interval"`
+}
+
+type Quarantine struct {
+	Enabled bool `yaml:"enabled"`
+	// TTL - срок хранения событий карантина, 0 - бессрочно
+	TTL      time.Duration `yaml:"ttl"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+type Retention struct {
+	// Default - срок хранения событий, 0 - бессрочно
+	Default   time.Duration                       `yaml:"default"`
+	ByType    map[domain.SensorType]time.Duration `yaml:"by_type"`
+	BySensor  map[int64]time.Duration             `yaml:"by_sensor"`
+	Interval  time.Duration                       `yaml:"interval"`
+	BatchSize int                                 `yaml:"batch_size"`
+}
+
+type Partitions struct {
+	Interval time.Duration `yaml:"interval"`
+	// Ahead - на сколько месяцев вперёд создаются секции events
+	Ahead int `yaml:"ahead"`
+}
+
+// Default - конфигурация по умолчанию
+func Default() *Config {
+	return &Config{
+		HTTP: HTTP{
+			Host:              "localhost",
+			Port:              8080,
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   15 * time.Second,
+		},
+		Storage: Storage{
+			Backend: BackendPostgres,
+			Postgres: Postgres{
+				Migrate:         migrator.ModeCheck,
+				MaxConns:        10,
+				ConnectTimeout:  5 * time.Second,
+				MaxConnLifetime: time.Hour,
+				MaxConnIdleTime: 30 * time.Minute,
+			},
+			InMemory: InMemory{SnapshotInterval: 5 * time.Minute},
+			File:     File{Dir: "data", SnapshotEvery: 10000},
+		},
+		SensorCache: SensorCache{Size: 10000, TTL: time.Minute},
+		Ingestion:   Ingestion{QueueSize: 10000, BatchSize: 500, Workers: 4, FlushInterval: 100 * time.Millisecond},
+		Quarantine:  Quarantine{TTL: 30 * 24 * time.Hour, Interval: time.Hour},
+		Retention:   Retention{Interval: time.Hour, BatchSize: 1000},
+		Partitions:  Partitions{Interval: 24 * time.Hour, Ahead: 3},
+		Metrics:     Metrics{Sensors: SensorsMetrics{TTL: 15 * time.Second}},
+		Tracing:     Tracing{Exporter: tracing.ExporterNone, SampleRatio: 1, ServiceName: "smarthome"},
+		Logging:     Logging{Level: "info", Format: logging.FormatText, AccessLog: AccessLog{SampleRatio: 1}},
+		RateLimit: RateLimit{
+			Events: EventsRateLimit{
+				Sensor: Limit{Rate: 10, Burst: 20},
+				Client: Limit{Rate: 1000, Burst: 2000},
+			},
+		},
+	}
+}
+
+// Load - функция чтения конфигурации. Файл задаётся флагом -config или переменной CONFIG_FILE.
+// Ошибка flag.ErrHelp означает, что была запрошена справка и она уже выведена в output.
+func Load(name string, args []string, getenv func(string) string, output io.Writer) (*Config, error) {
+	c := Default()
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	path := fs.String("config", getenv("CONFIG_FILE"), "путь к файлу конфигурации в формате YAML (CONFIG_FILE)")
+	vars := c.bind(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("%w: unexpected arguments %v", ErrInvalidConfig, fs.Args())
+	}
+
+	if *path != "" {
+		if err := c.readFile(*path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, v := range vars {
+		value := getenv(v.env)
+		if value == "" {
+			continue
+		}
+		if err := fs.Set(v.flag, value); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidConfig, v.env, err)
+		}
+	}
+	// флаги разбираются повторно, чтобы переопределить значения из файла и окружения
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// variable - параметр, который задаётся флагом и переменной окружения
+type variable struct {
+	flag string
+	env  string
+}
+
+// bind - регистрирует флаги параметров, значения по умолчанию берутся из c
+func (c *Config) bind(fs *flag.FlagSet) []variable {
+	var vars []variable
+	add := func(name, env string) {
+		vars = append(vars, variable{flag: name, env: env})
+	}
+	usage := func(text, env string) string {
+		return fmt.Sprintf("%s (%s)", text, env)
+	}
+
+	fs.StringVar(&c.HTTP.Host, "http.host", c.HTTP.Host, usage("адрес для входящих соединений", "HTTP_HOST"))
+	add("http.host", "HTTP_HOST")
+	fs.Func("http.port", usage("порт для входящих соединений", "HTTP_PORT"), portSetter(&c.HTTP.Port))
+	add("http.port", "HTTP_PORT")
+	fs.DurationVar(&c.HTTP.ShutdownTimeout, "http.shutdown_timeout", c.HTTP.ShutdownTimeout, usage("время на завершение начатых запросов при остановке", "HTTP_SHUTDOWN_TIMEOUT"))
+	add("http.shutdown_timeout", "HTTP_SHUTDOWN_TIMEOUT")
+	fs.DurationVar(&c.HTTP.ShutdownDelay, "http.shutdown_delay", c.HTTP.ShutdownDelay, usage("сколько принимать запросы после начала остановки", "HTTP_SHUTDOWN_DELAY"))
+	add("http.shutdown_delay", "HTTP_SHUTDOWN_DELAY")
+	fs.Func("http.trusted_proxies", usage("адреса и подсети обратных прокси через запятую", "HTTP_TRUSTED_PROXIES"), listSetter(&c.HTTP.TrustedProxies))
+	add("http.trusted_proxies", "HTTP_TRUSTED_PROXIES")
+
+	fs.StringVar(&c.Storage.Backend, "storage.backend", c.Storage.Backend, usage("хранилище: postgres, inmemory или file", "STORAGE_BACKEND"))
+	add("storage.backend", "STORAGE_BACKEND")
+	fs.StringVar(&c.Storage.Postgres.URL, "storage.postgres.url", c.Storage.Postgres.URL, usage("URL подключения к postgres", "DATABASE_URL"))
+	add("storage.postgres.url", "DATABASE_URL")
+	fs.StringVar(&c.Storage.Postgres.Migrate, "migrate", c.Storage.Postgres.Migrate, usage("действие с миграциями при запуске: up, check или none", "DATABASE_MIGRATE"))
+	add("migrate", "DATABASE_MIGRATE")
+	fs.Func("storage.postgres.max_conns", usage("максимальный размер пула соединений", "DATABASE_MAX_CONNS"), int32Setter(&c.Storage.Postgres.MaxConns))
+	add("storage.postgres.max_conns", "DATABASE_MAX_CONNS")
+	fs.Func("storage.postgres.min_conns", usage("минимальный размер пула соединений", "DATABASE_MIN_CONNS"), int32Setter(&c.Storage.Postgres.MinConns))
+	add("storage.postgres.min_conns", "DATABASE_MIN_CONNS")
+	fs.DurationVar(&c.Storage.Postgres.ConnectTimeout, "storage.postgres.connect_timeout", c.Storage.Postgres.ConnectTimeout, usage("таймаут подключения к postgres", "DATABASE_CONNECT_TIMEOUT"))
+	add("storage.postgres.connect_timeout", "DATABASE_CONNECT_TIMEOUT")
+	fs.StringVar(&c.Storage.InMemory.SnapshotPath, "storage.inmemory.snapshot_path", c.Storage.InMemory.SnapshotPath, usage("файл снимка хранилища в памяти", "SNAPSHOT_PATH"))
+	add("storage.inmemory.snapshot_path", "SNAPSHOT_PATH")
+	fs.StringVar(&c.Storage.File.Dir, "storage.file.dir", c.Storage.File.Dir, usage("каталог файлового хранилища", "DATA_DIR"))
+	add("storage.file.dir", "DATA_DIR")
+
+	fs.BoolVar(&c.SensorCache.Enabled, "sensor_cache.enabled", c.SensorCache.Enabled, usage("кэширование датчиков", "SENSOR_CACHE_ENABLED"))
+	add("sensor_cache.enabled", "SENSOR_CACHE_ENABLED")
+	fs.BoolVar(&c.Ingestion.Enabled, "ingestion.enabled", c.Ingestion.Enabled, usage("отложенная запись событий через очередь", "INGESTION_ENABLED"))
+	add("ingestion.enabled", "INGESTION_ENABLED")
+	fs.IntVar(&c.Ingestion.QueueSize, "ingestion.queue_size", c.Ingestion.QueueSize, usage("размер очереди событий", "INGESTION_QUEUE_SIZE"))
+	add("ingestion.queue_size", "INGESTION_QUEUE_SIZE")
+	fs.BoolVar(&c.Quarantine.Enabled, "quarantine.enabled", c.Quarantine.Enabled, usage("карантин отклонённых событий", "QUARANTINE_ENABLED"))
+	add("quarantine.enabled", "QUARANTINE_ENABLED")
+	fs.DurationVar(&c.Quarantine.TTL, "quarantine.ttl", c.Quarantine.TTL, usage("срок хранения событий карантина, 0 - бессрочно", "QUARANTINE_TTL"))
+	add("quarantine.ttl", "QUARANTINE_TTL")
+	fs.DurationVar(&c.Retention.Default, "retention.default", c.Retention.Default, usage("срок хранения событий, 0 - бессрочно", "EVENTS_RETENTION"))
+	add("retention.default", "EVENTS_RETENTION")
+	fs.BoolVar(&c.Metrics.Enabled, "metrics.enabled", c.Metrics.Enabled, usage("метрики Prometheus на /metrics", "METRICS_ENABLED"))
+	add("metrics.enabled", "METRICS_ENABLED")
+	fs.BoolVar(&c.Metrics.Sensors.Enabled, "metrics.sensors.enabled", c.Metrics.Sensors.Enabled, usage("метрики состояния датчиков на /metrics/sensors", "METRICS_SENSORS_ENABLED"))
+	add("metrics.sensors.enabled", "METRICS_SENSORS_ENABLED")
+	fs.StringVar(&c.Tracing.Exporter, "tracing.exporter", c.Tracing.Exporter, usage("экспорт трасс: none, stdout или otlp", "TRACING_EXPORTER"))
+	add("tracing.exporter", "TRACING_EXPORTER")
+	fs.StringVar(&c.Tracing.Endpoint, "tracing.endpoint", c.Tracing.Endpoint, usage("URL OTLP/HTTP коллектора трасс", "TRACING_ENDPOINT"))
+	add("tracing.endpoint", "TRACING_ENDPOINT")
+	fs.Float64Var(&c.Tracing.SampleRatio, "tracing.sample_ratio", c.Tracing.SampleRatio, usage("доля записываемых трасс от 0 до 1", "TRACING_SAMPLE_RATIO"))
+	add("tracing.sample_ratio", "TRACING_SAMPLE_RATIO")
+	fs.StringVar(&c.Logging.Level, "logging.level", c.Logging.Level, usage("минимальный уровень записей: debug, info, warn или error", "LOG_LEVEL"))
+	add("logging.level", "LOG_LEVEL")
+	fs.StringVar(&c.Logging.Format, "logging.format", c.Logging.Format, usage("формат записей: text или json", "LOG_FORMAT"))
+	add("logging.format", "LOG_FORMAT")
+	fs.BoolVar(&c.Logging.AccessLog.Enabled, "logging.access_log.enabled", c.Logging.AccessLog.Enabled, usage("журнал запросов", "ACCESS_LOG_ENABLED"))
+	add("logging.access_log.enabled", "ACCESS_LOG_ENABLED")
+	fs.Float64Var(&c.Logging.AccessLog.SampleRatio, "logging.access_log.sample_ratio", c.Logging.AccessLog.SampleRatio, usage("доля записываемых запросов от 0 до 1", "ACCESS_LOG_SAMPLE_RATIO"))
+	add("logging.access_log.sample_ratio", "ACCESS_LOG_SAMPLE_RATIO")
+	fs.BoolVar(&c.RateLimit.Events.Enabled, "rate_limit.events.enabled", c.RateLimit.Events.Enabled, usage("ограничение частоты событий датчиков и клиентов", "RATE_LIMIT_ENABLED"))
+	add("rate_limit.events.enabled", "RATE_LIMIT_ENABLED")
+	fs.IntVar(&c.RateLimit.MaxConcurrentRequests, "rate_limit.max_concurrent_requests", c.RateLimit.MaxConcurrentRequests, usage("сколько запросов обрабатывается одновременно, 0 - без ограничения", "MAX_CONCURRENT_REQUESTS"))
+	add("rate_limit.max_concurrent_requests", "MAX_CONCURRENT_REQUESTS")
+
+	return vars
+}
+
+func (c *Config) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("can't open config file: %w", err)
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: %s: %w", ErrInvalidConfig, path, err)
+	}
+
+	return nil
+}
+
+// Validate - проверка конфигурации, возвращает все найденные ошибки с именами параметров
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, param, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: "+format, append([]any{param}, args...)...))
+		}
+	}
+
+	check(c.HTTP.Port != 0, "http.port", "should be between 1 and 65535")
+	check(c.HTTP.ReadHeaderTimeout >= 0, "http.read_header_timeout", "should not be negative, got %s", c.HTTP.ReadHeaderTimeout)
+	check(c.HTTP.ReadTimeout >= 0, "http.read_timeout", "should not be negative, got %s", c.HTTP.ReadTimeout)
+	check(c.HTTP.WriteTimeout >= 0, "http.write_timeout", "should not be negative, got %s", c.HTTP.WriteTimeout)
+	check(c.HTTP.IdleTimeout >= 0, "http.idle_timeout", "should not be negative, got %s", c.HTTP.IdleTimeout)
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdown_timeout", "should be positive, got %s", c.HTTP.ShutdownTimeout)
+	check(c.HTTP.ShutdownDelay >= 0, "http.shutdown_delay", "should not be negative, got %s", c.HTTP.ShutdownDelay)
+	for _, proxy := range c.HTTP.TrustedProxies {
+		check(validProxy(proxy), "http.trusted_proxies", "should be ip or cidr, got %q", proxy)
+	}
+
+	switch c.Storage.Backend {
+	case BackendPostgres:
+		p := c.Storage.Postgres
+		check(p.URL != "", "storage.postgres.url", "required for postgres backend")
+		check(p.Migrate == migrator.ModeUp || p.Migrate == migrator.ModeCheck || p.Migrate == migrator.ModeNone,
+			"storage.postgres.migrate", "should be one of %s, %s, %s, got %q", migrator.ModeUp, migrator.ModeCheck, migrator.ModeNone, p.Migrate)
+		check(p.MaxConns > 0, "storage.postgres.max_conns", "should be positive, got %d", p.MaxConns)
+		check(p.MinConns >= 0 && p.MinConns <= p.MaxConns, "storage.postgres.min_conns", "should be between 0 and max_conns %d, got %d", p.MaxConns, p.MinConns)
+		check(p.ConnectTimeout > 0, "storage.postgres.connect_timeout", "should be positive, got %s", p.ConnectTimeout)
+		check(p.MaxConnLifetime > 0, "storage.postgres.max_conn_lifetime", "should be positive, got %s", p.MaxConnLifetime)
+		check(p.MaxConnIdleTime > 0, "storage.postgres.max_conn_idle_time", "should be positive, got %s", p.MaxConnIdleTime)
+	case BackendInMemory:
+		check(c.Storage.InMemory.SnapshotPath == "" || c.Storage.InMemory.SnapshotInterval > 0,
+			"storage.inmemory.snapshot_interval", "should be positive, got %s", c.Storage.InMemory.SnapshotInterval)
+	case BackendFile:
+		check(c.Storage.File.Dir != "", "storage.file.dir", "required for file backend")
+		check(c.Storage.File.SnapshotEvery > 0, "storage.file.snapshot_every", "should be positive, got %d", c.Storage.File.SnapshotEvery)
+		// события файлового хранилища держатся в памяти и целиком переписываются в каждый снимок
+		_, bounded := c.Retention.Policy().Longest()
+		check(bounded, "retention.default", "file backend requires retention for all events, set default and non-zero by_type and by_sensor")
+	default:
+		check(false, "storage.backend", "should be one of %s, %s, %s, got %q", BackendPostgres, BackendInMemory, BackendFile, c.Storage.Backend)
+	}
+
+	if c.SensorCache.Enabled {
+		check(c.SensorCache.Size > 0, "sensor_cache.size", "should be positive, got %d", c.SensorCache.Size)
+		check(c.SensorCache.TTL > 0, "sensor_cache.ttl", "should be positive, got %s", c.SensorCache.TTL)
+	}
+
+	if c.Ingestion.Enabled {
+		check(c.Ingestion.QueueSize > 0, "ingestion.queue_size", "should be positive, got %d", c.Ingestion.QueueSize)
+		check(c.Ingestion.BatchSize > 0, "ingestion.batch_size", "should be positive, got %d", c.Ingestion.BatchSize)
+		check(c.Ingestion.Workers > 0 && c.Ingestion.Workers <= c.Ingestion.QueueSize,
+			"ingestion.workers", "should be between 1 and queue_size %d, got %d", c.Ingestion.QueueSize, c.Ingestion.Workers)
+		check(c.Ingestion.FlushInterval > 0, "ingestion.flush_interval", "should be positive, got %s", c.Ingestion.FlushInterval)
+	}
+
+	check(c.Quarantine.TTL >= 0, "quarantine.ttl", "should not be negative, got %s", c.Quarantine.TTL)
+	if c.Quarantine.TTL > 0 {
+		check(c.Quarantine.Interval > 0, "quarantine.interval", "should be positive, got %s", c.Quarantine.Interval)
+	}
+
+	check(c.Retention.Default >= 0, "retention.default", "should not be negative, got %s", c.Retention.Default)
+	for t, d := range c.Retention.ByType {
+		_, ok := usecase.LookupSensorType(t)
+		check(ok, "retention.by_type", "unknown sensor type %q", t)
+		check(d >= 0, "retention.by_type."+string(t), "should not be negative, got %s", d)
+	}
+	for id, d := range c.Retention.BySensor {
+		check(id > 0, "retention.by_sensor", "sensor id should be positive, got %d", id)
+		check(d >= 0, fmt.Sprintf("retention.by_sensor.%d", id), "should not be negative, got %s", d)
+	}
+	if c.Retention.Enabled() {
+		check(c.Retention.Interval > 0, "retention.interval", "should be positive, got %s", c.Retention.Interval)
+		check(c.Retention.BatchSize > 0, "retention.batch_size", "should be positive, got %d", c.Retention.BatchSize)
+	}
+
+	if c.Storage.Backend == BackendPostgres {
+		check(c.Partitions.Interval > 0, "partitions.interval", "should be positive, got %s", c.Partitions.Interval)
+		check(c.Partitions.Ahead > 0, "partitions.ahead", "should be positive, got %d", c.Partitions.Ahead)
+	}
+
+	if c.Metrics.Sensors.Enabled {
+		check(c.Metrics.Sensors.TTL >= 0, "metrics.sensors.ttl", "should not be negative, got %s", c.Metrics.Sensors.TTL)
+	}
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone:
+	case tracing.ExporterStdout, tracing.ExporterOTLP:
+		check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "should be between 0 and 1, got %g", c.Tracing.SampleRatio)
+		check(c.Tracing.ServiceName != "", "tracing.service_name", "required for %s exporter", c.Tracing.Exporter)
+	default:
+		check(false, "tracing.exporter", "should be one of %s, %s, %s, got %q", tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP, c.Tracing.Exporter)
+	}
+
+	_, err := logging.ParseLevel(c.Logging.Level)
+	check(err == nil, "logging.level", "should be one of debug, info, warn, error, got %q", c.Logging.Level)
+	check(c.Logging.Format == logging.FormatText || c.Logging.Format == logging.FormatJSON,
+		"logging.format", "should be one of %s, %s, got %q", logging.FormatText, logging.FormatJSON, c.Logging.Format)
+	if c.Logging.AccessLog.Enabled {
+		ratio := c.Logging.AccessLog.SampleRatio
+		check(ratio >= 0 && ratio <= 1, "logging.access_log.sample_ratio", "should be between 0 and 1, got %g", ratio)
+	}
+
+	checkLimit := func(param string, l Limit) {
+		check(l.Rate >= 0, param+".rate", "should not be negative, got %g", l.Rate)
+		check(l.Rate == 0 || l.Burst > 0, param+".burst", "should be positive, got %d", l.Burst)
+	}
+	if c.RateLimit.Events.Enabled {
+		checkLimit("rate_limit.events.sensor", c.RateLimit.Events.Sensor)
+		checkLimit("rate_limit.events.client", c.RateLimit.Events.Client)
+		for t, l := range c.RateLimit.Events.ByType {
+			_, ok := usecase.LookupSensorType(t)
+			check(ok, "rate_limit.events.by_type", "unknown sensor type %q", t)
+			checkLimit("rate_limit.events.by_type."+string(t), l)
+		}
+	}
+	check(c.RateLimit.MaxConcurrentRequests >= 0, "rate_limit.max_concurrent_requests", "should not be negative, got %d", c.RateLimit.MaxConcurrentRequests)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w:\n%w", ErrInvalidConfig, errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// Timeouts - таймауты http сервера
+func (h HTTP) Timeouts() httpGateway.Timeouts {
+	return httpGateway.Timeouts{
+		ReadHeader: h.ReadHeaderTimeout,
+		Read:       h.ReadTimeout,
+		Write:      h.WriteTimeout,
+		Idle:       h.IdleTimeout,
+	}
+}
+
+// Enabled - задан ли срок хранения хотя бы для части событий
+func (r Retention) Enabled() bool {
+	return r.Default > 0 || len(r.ByType) > 0 || len(r.BySensor) > 0
+}
+
+// Policy - политика хранения событий
+func (r Retention) Policy() domain.RetentionPolicy {
+	return domain.RetentionPolicy{
+		Default:  r.Default,
+		ByType:   r.ByType,
+		BySensor: r.BySensor,
+	}
+}
+
+// Config - параметры экспорта трасс
+func (t Tracing) Config() tracing.Config {
+	return tracing.Config{
+		Exporter:    t.Exporter,
+		Endpoint:    t.Endpoint,
+		SampleRatio: t.SampleRatio,
+		ServiceName: t.ServiceName,
+	}
+}
+
+// Config - параметры журнала
+func (l Logging) Config() logging.Config {
+	return logging.Config{
+		Level:  l.Level,
+		Format: l.Format,
+	}
+}
+
+// Limit - ограничение частоты
+func (l Limit) Limit() ratelimit.Limit {
+	return ratelimit.Limit{Rate: l.Rate, Burst: l.Burst}
+}
+
+// SensorLimiter - ограничение частоты событий каждого датчика
+func (e EventsRateLimit) SensorLimiter() *ratelimit.SensorLimiter {
+	byType := make(map[domain.SensorType]ratelimit.Limit, len(e.ByType))
+	for t, l := range e.ByType {
+		byType[t] = l.Limit()
+	}
+
+	return ratelimit.NewSensorLimiter(e.Sensor.Limit(), byType)
+}
+
+// IngestionConfig - параметры очереди приёма событий
+func (i Ingestion) IngestionConfig() usecase.IngestionConfig {
+	return usecase.IngestionConfig{
+		QueueSize:     i.QueueSize,
+		BatchSize:     i.BatchSize,
+		Workers:       i.Workers,
+		FlushInterval: i.FlushInterval,
+	}
+}
+
+func portSetter(port *uint16) func(string) error {
+	return func(s string) error {
+		var v uint16
+		if _, err := fmt.Sscan(strings.TrimSpace(s), &v); err != nil {
+			return fmt.Errorf("invalid port %q", s)
+		}
+		*port = v
+		return nil
+	}
+}
+
+func int32Setter(p *int32) func(string) error {
+	return func(s string) error {
+		var v int32
+		if _, err := fmt.Sscan(strings.TrimSpace(s), &v); err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		*p = v
+		return nil
+	}
+}
+
+// listSetter - значение списка через запятую, пустая строка - пустой список
+func listSetter(p *[]string) func(string) error {
+	return func(s string) error {
+		*p = nil
+		for _, v := range strings.Split(s, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				*p = append(*p, v)
+			}
+		}
+		return nil
+	}
+}
+
+func validProxy(proxy string) bool {
+	if net.ParseIP(proxy) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(proxy)
+	return err == nil
+}
@@ -0,0 +1,325 @@
+package config
+
+import (
+	"flag"
+	"homework/internal/domain"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(key string) string {
+		return vars[key]
+	}
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "server.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("ok, defaults", func(t *testing.T) {
+		c, err := Load("server", nil, env(map[string]string{"DATABASE_URL": "postgres://localhost/db"}), io.Discard)
+		require.NoError(t, err)
+
+		want := Default()
+		want.Storage.Postgres.URL = "postgres://localhost/db"
+		assert.Equal(t, want, c)
+	})
+
+	t.Run("ok, file", func(t *testing.T) {
+		path := writeConfig(t, `
+http:
+  host: 0.0.0.0
+  port: 9000
+storage:
+  backend: inmemory
+  inmemory:
+    snapshot_path: /tmp/state.json
+    snapshot_interval: 1m
+ingestion:
+  enabled: true
+  workers: 2
+retention:
+  default: 720h
+  by_type:
+    adc: 24h
+  by_sensor:
+    5: 1h
+`)
+
+		c, err := Load("server", []string{"-config", path}, env(nil), io.Discard)
+		require.NoError(t, err)
+
+		assert.Equal(t, "0.0.0.0", c.HTTP.Host)
+		assert.Equal(t, uint16(9000), c.HTTP.Port)
+		assert.Equal(t, BackendInMemory, c.Storage.Backend)
+		assert.Equal(t, InMemory{SnapshotPath: "/tmp/state.json", SnapshotInterval: time.Minute}, c.Storage.InMemory)
+		assert.True(t, c.Ingestion.Enabled)
+		assert.Equal(t, 2, c.Ingestion.Workers)
+		assert.Equal(t, Default().Ingestion.QueueSize, c.Ingestion.QueueSize)
+		assert.Equal(t, domain.RetentionPolicy{
+			Default:  720 * time.Hour,
+			ByType:   map[domain.SensorType]time.Duration{domain.SensorTypeADC: 24 * time.Hour},
+			BySensor: map[int64]time.Duration{5: time.Hour},
+		}, c.Retention.Policy())
+	})
+
+	t.Run("ok, env overrides file and flags override env", func(t *testing.T) {
+		path := writeConfig(t, `
+http:
+  host: file
+  port: 9000
+storage:
+  backend: file
+  file:
+    dir: /var/lib/file
+retention:
+  default: 720h
+`)
+
+		c, err := Load("server", []string{"-http.port=9002", "-storage.file.dir", "/var/lib/flag", "-migrate=up"}, env(map[string]string{
+			"CONFIG_FILE":          path,
+			"HTTP_HOST":            "env",
+			"HTTP_PORT":            "9001",
+			"DATA_DIR":             "/var/lib/env",
+			"HTTP_TRUSTED_PROXIES": "10.0.0.0/8, 192.0.2.1",
+		}), io.Discard)
+		require.NoError(t, err)
+
+		assert.Equal(t, "env", c.HTTP.Host)
+		assert.Equal(t, []string{"10.0.0.0/8", "192.0.2.1"}, c.HTTP.TrustedProxies)
+		assert.Equal(t, uint16(9002), c.HTTP.Port)
+		assert.Equal(t, BackendFile, c.Storage.Backend)
+		assert.Equal(t, "/var/lib/flag", c.Storage.File.Dir)
+		assert.Equal(t, "up", c.Storage.Postgres.Migrate)
+	})
+
+	t.Run("ok, help", func(t *testing.T) {
+		_, err := Load("server", []string{"-h"}, env(nil), io.Discard)
+		assert.ErrorIs(t, err, flag.ErrHelp)
+	})
+
+	t.Run("fail, unknown field in file", func(t *testing.T) {
+		path := writeConfig(t, "http:\n  hots: localhost\n")
+
+		_, err := Load("server", []string{"-config", path}, env(nil), io.Discard)
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "field hots not found")
+	})
+
+	t.Run("fail, no file", func(t *testing.T) {
+		_, err := Load("server", []string{"-config", filepath.Join(t.TempDir(), "server.yaml")}, env(nil), io.Discard)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("fail, invalid env", func(t *testing.T) {
+		_, err := Load("server", nil, env(map[string]string{"HTTP_PORT": "http"}), io.Discard)
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "HTTP_PORT")
+	})
+
+	t.Run("fail, invalid flag", func(t *testing.T) {
+		_, err := Load("server", []string{"-retention.default", "month"}, env(nil), io.Discard)
+		assert.Error(t, err)
+	})
+
+	t.Run("fail, postgres without url", func(t *testing.T) {
+		_, err := Load("server", nil, env(nil), io.Discard)
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "storage.postgres.url: required for postgres backend")
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   []string
+	}{
+		{
+			name:   "ok, defaults",
+			modify: func(c *Config) {},
+		},
+		{
+			name: "ok, disabled features are not checked",
+			modify: func(c *Config) {
+				c.SensorCache = SensorCache{}
+				c.Ingestion = Ingestion{}
+				c.Quarantine = Quarantine{}
+				c.Retention = Retention{}
+			},
+		},
+		{
+			name: "fail, unknown backend",
+			modify: func(c *Config) {
+				c.Storage.Backend = "mysql"
+			},
+			want: []string{`storage.backend: should be one of postgres, inmemory, file, got "mysql"`},
+		},
+		{
+			name: "fail, pool sizes",
+			modify: func(c *Config) {
+				c.Storage.Postgres.MaxConns = 2
+				c.Storage.Postgres.MinConns = 3
+			},
+			want: []string{"storage.postgres.min_conns: should be between 0 and max_conns 2, got 3"},
+		},
+		{
+			name: "fail, all errors are reported",
+			modify: func(c *Config) {
+				c.HTTP.Port = 0
+				c.SensorCache.Enabled = true
+				c.SensorCache.TTL = 0
+				c.Ingestion.Enabled = true
+				c.Ingestion.FlushInterval = -time.Second
+			},
+			want: []string{
+				"http.port: should be between 1 and 65535",
+				"sensor_cache.ttl: should be positive, got 0s",
+				"ingestion.flush_interval: should be positive, got -1s",
+			},
+		},
+		{
+			name: "fail, quarantine",
+			modify: func(c *Config) {
+				c.Quarantine.Interval = 0
+			},
+			want: []string{"quarantine.interval: should be positive, got 0s"},
+		},
+		{
+			name: "fail, retention",
+			modify: func(c *Config) {
+				c.Retention.ByType = map[domain.SensorType]time.Duration{"Thermometer": time.Hour}
+				c.Retention.BySensor = map[int64]time.Duration{1: -time.Hour}
+				c.Retention.Interval = 0
+			},
+			want: []string{
+				`retention.by_type: unknown sensor type "Thermometer"`,
+				"retention.by_sensor.1: should not be negative, got -1h0m0s",
+				"retention.interval: should be positive, got 0s",
+			},
+		},
+		{
+			name: "fail, unknown migrate mode",
+			modify: func(c *Config) {
+				c.Storage.Postgres.Migrate = "down"
+			},
+			want: []string{`storage.postgres.migrate: should be one of up, check, none, got "down"`},
+		},
+		{
+			name: "fail, file backend without dir",
+			modify: func(c *Config) {
+				c.Storage.Backend = BackendFile
+				c.Storage.File.Dir = ""
+			},
+			want: []string{"storage.file.dir: required for file backend"},
+		},
+		{
+			name: "ok, file backend with retention",
+			modify: func(c *Config) {
+				c.Storage.Backend = BackendFile
+				c.Retention.Default = 30 * 24 * time.Hour
+			},
+		},
+		{
+			name: "fail, file backend keeps events forever",
+			modify: func(c *Config) {
+				c.Storage.Backend = BackendFile
+				c.Retention.Default = 30 * 24 * time.Hour
+				c.Retention.BySensor = map[int64]time.Duration{1: 0}
+			},
+			want: []string{"retention.default: file backend requires retention for all events"},
+		},
+		{
+			name: "fail, negative sensor metrics ttl",
+			modify: func(c *Config) {
+				c.Metrics.Sensors.Enabled = true
+				c.Metrics.Sensors.TTL = -time.Second
+			},
+			want: []string{"metrics.sensors.ttl: should not be negative, got -1s"},
+		},
+		{
+			name: "fail, tracing",
+			modify: func(c *Config) {
+				c.Tracing.Exporter = "jaeger"
+			},
+			want: []string{`tracing.exporter: should be one of none, stdout, otlp, got "jaeger"`},
+		},
+		{
+			name: "fail, rate limit",
+			modify: func(c *Config) {
+				c.RateLimit.Events.Enabled = true
+				c.RateLimit.Events.Sensor = Limit{Rate: -1}
+				c.RateLimit.Events.ByType = map[domain.SensorType]Limit{
+					domain.SensorTypeADC: {Rate: 100},
+					"Thermometer":        {Rate: 1, Burst: 1},
+				}
+				c.RateLimit.MaxConcurrentRequests = -1
+			},
+			want: []string{
+				"rate_limit.events.sensor.rate: should not be negative, got -1",
+				"rate_limit.events.by_type.adc.burst: should be positive, got 0",
+				`rate_limit.events.by_type: unknown sensor type "Thermometer"`,
+				"rate_limit.max_concurrent_requests: should not be negative, got -1",
+			},
+		},
+		{
+			name: "fail, trusted proxies",
+			modify: func(c *Config) {
+				c.HTTP.TrustedProxies = []string{"10.0.0.0/8", "proxy.local"}
+			},
+			want: []string{`http.trusted_proxies: should be ip or cidr, got "proxy.local"`},
+		},
+		{
+			name: "fail, logging",
+			modify: func(c *Config) {
+				c.Logging.Level = "verbose"
+				c.Logging.Format = "logfmt"
+				c.Logging.AccessLog.Enabled = true
+				c.Logging.AccessLog.SampleRatio = -0.5
+			},
+			want: []string{
+				`logging.level: should be one of debug, info, warn, error, got "verbose"`,
+				`logging.format: should be one of text, json, got "logfmt"`,
+				"logging.access_log.sample_ratio: should be between 0 and 1, got -0.5",
+			},
+		},
+		{
+			name: "fail, tracing sample ratio",
+			modify: func(c *Config) {
+				c.Tracing.Exporter = "otlp"
+				c.Tracing.SampleRatio = 1.5
+			},
+			want: []string{"tracing.sample_ratio: should be between 0 and 1, got 1.5"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			c.Storage.Postgres.URL = "postgres://localhost/db"
+			tt.modify(c)
+
+			err := c.Validate()
+			if len(tt.want) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidConfig)
+			for _, want := range tt.want {
+				assert.ErrorContains(t, err, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"math"
+	"sort"
+)
+
+type CalibrationKind string
+
+const (
+	CalibrationKindLinear CalibrationKind = "linear"
+	CalibrationKindTable  CalibrationKind = "table"
+)
+
+// CalibrationPoint - точка таблицы калибровки: сырое значение датчика и соответствующее ему значение в единицах измерения
+type CalibrationPoint struct {
+	Raw   int64
+	Value float64
+}
+
+// Calibration - структура для перевода сырых показаний датчика в единицы измерения
+// Kind - способ перевода: линейный (Value = Raw*Scale + Offset) или кусочно-линейный по таблице Points
+// Unit - единица измерения, Precision - количество знаков после запятой при отображении
+type Calibration struct {
+	Kind      CalibrationKind
+	Scale     float64
+	Offset    float64
+	Points    []CalibrationPoint
+	Unit      string
+	Precision int
+}
+
+// Convert - функция перевода сырого значения в единицы измерения с округлением до Precision знаков.
+// Точки таблицы должны быть отсортированы по Raw, за пределами таблицы значение экстраполируется по крайнему отрезку.
+func (c *Calibration) Convert(raw int64) float64 {
+	var value float64
+
+	switch c.Kind {
+	case CalibrationKindLinear:
+		value = float64(raw)*c.Scale + c.Offset
+	case CalibrationKindTable:
+		value = c.interpolate(raw)
+	default:
+		value = float64(raw)
+	}
+
+	return round(value, c.Precision)
+}
+
+func (c *Calibration) interpolate(raw int64) float64 {
+	switch len(c.Points) {
+	case 0:
+		return float64(raw)
+	case 1:
+		return c.Points[0].Value
+	}
+
+	i := sort.Search(len(c.Points), func(i int) bool {
+		return c.Points[i].Raw >= raw
+	})
+	if i == 0 {
+		i = 1
+	}
+	if i == len(c.Points) {
+		i = len(c.Points) - 1
+	}
+
+	left, right := c.Points[i-1], c.Points[i]
+	// калибровка из хранилища не проверяется, совпадающие raw не должны давать NaN
+	if right.Raw == left.Raw {
+		return left.Value
+	}
+	k := (right.Value - left.Value) / float64(right.Raw-left.Raw)
+
+	return left.Value + k*float64(raw-left.Raw)
+}
+
+func round(value float64, precision int) float64 {
+	p := math.Pow10(precision)
+
+	return math.Round(value*p) / p
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalibration_Convert(t *testing.T) {
+	tests := []struct {
+		name        string
+		calibration Calibration
+		raw         int64
+		want        float64
+	}{
+		{
+			name:        "linear",
+			calibration: Calibration{Kind: CalibrationKindLinear, Scale: 0.1, Offset: -40, Precision: 1},
+			raw:         625,
+			want:        22.5,
+		},
+		{
+			name:        "linear, rounded to precision",
+			calibration: Calibration{Kind: CalibrationKindLinear, Scale: 1.0 / 3, Precision: 2},
+			raw:         1,
+			want:        0.33,
+		},
+		{
+			name: "table, between points",
+			calibration: Calibration{Kind: CalibrationKindTable, Points: []CalibrationPoint{
+				{Raw: 0, Value: 0},
+				{Raw: 100, Value: 10},
+				{Raw: 200, Value: 50},
+			}, Precision: 1},
+			raw:  150,
+			want: 30,
+		},
+		{
+			name: "table, exact point",
+			calibration: Calibration{Kind: CalibrationKindTable, Points: []CalibrationPoint{
+				{Raw: 0, Value: 0},
+				{Raw: 100, Value: 10},
+			}},
+			raw:  100,
+			want: 10,
+		},
+		{
+			name: "table, below first point",
+			calibration: Calibration{Kind: CalibrationKindTable, Points: []CalibrationPoint{
+				{Raw: 0, Value: 0},
+				{Raw: 100, Value: 10},
+			}},
+			raw:  -100,
+			want: -10,
+		},
+		{
+			name: "table, above last point",
+			calibration: Calibration{Kind: CalibrationKindTable, Points: []CalibrationPoint{
+				{Raw: 0, Value: 0},
+				{Raw: 100, Value: 10},
+				{Raw: 200, Value: 50},
+			}},
+			raw:  300,
+			want: 90,
+		},
+		{
+			name: "table, duplicate raw points",
+			calibration: Calibration{Kind: CalibrationKindTable, Points: []CalibrationPoint{
+				{Raw: 100, Value: 10},
+				{Raw: 100, Value: 20},
+			}},
+			raw:  150,
+			want: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, tt.calibration.Convert(tt.raw), 1e-9)
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+type QuarantineReason string
+
+const (
+	// QuarantineReasonInvalidPayload - значение события не прошло проверку для датчика
+	QuarantineReasonInvalidPayload QuarantineReason = "invalid_payload"
+	// QuarantineReasonUnknownSensor - датчик с серийным номером события не зарегистрирован
+	QuarantineReasonUnknownSensor QuarantineReason = "unknown_sensor"
+)
+
+// QuarantinedEvent - структура для хранения события, не принятого в историю датчика
+// Reason - причина отклонения, Details - текст ошибки проверки
+type QuarantinedEvent struct {
+	Event
+	Reason  QuarantineReason
+	Details string
+}
+
+// UnknownDevice - сводка по событиям незарегистрированного датчика
+// SamplePayloads - значения последних событий, от новых к старым
+type UnknownDevice struct {
+	SerialNumber   string
+	FirstSeen      time.Time
+	LastSeen       time.Time
+	EventCount     int64
+	SamplePayloads []int64
+}
@@ -0,0 +1,56 @@
+package domain
+
+import "time"
+
+// RetentionPolicy - срок хранения событий датчиков, 0 - хранить бессрочно
+// Default - срок по умолчанию, ByType и BySensor переопределяют его для типа датчика и для датчика с заданным ID
+type RetentionPolicy struct {
+	Default  time.Duration
+	ByType   map[SensorType]time.Duration
+	BySensor map[int64]time.Duration
+}
+
+// For - функция получения срока хранения событий датчика, настройка датчика важнее настройки типа
+func (p RetentionPolicy) For(sensor *Sensor) time.Duration {
+	if d, ok := p.BySensor[sensor.ID]; ok {
+		return d
+	}
+	if d, ok := p.ByType[sensor.Type]; ok {
+		return d
+	}
+
+	return p.Default
+}
+
+// Longest - функция получения наибольшего срока хранения среди всех настроек,
+// false если хотя бы для части датчиков события хранятся бессрочно
+func (p RetentionPolicy) Longest() (time.Duration, bool) {
+	durations := []time.Duration{p.Default}
+	for _, d := range p.ByType {
+		durations = append(durations, d)
+	}
+	for _, d := range p.BySensor {
+		durations = append(durations, d)
+	}
+
+	var longest time.Duration
+	for _, d := range durations {
+		if d <= 0 {
+			return 0, false
+		}
+		if d > longest {
+			longest = d
+		}
+	}
+
+	return longest, true
+}
+
+// PruneReport - результат удаления устаревших событий
+// Removed - общее количество удалённых событий, BySensor - количество по ID датчика
+type PruneReport struct {
+	StartedAt time.Time
+	Duration  time.Duration
+	Removed   int64
+	BySensor  map[int64]int64
+}
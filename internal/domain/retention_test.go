@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetentionPolicy(t *testing.T) {
+	policy := RetentionPolicy{
+		Default:  30 * 24 * time.Hour,
+		ByType:   map[SensorType]time.Duration{SensorTypeADC: 7 * 24 * time.Hour},
+		BySensor: map[int64]time.Duration{1: 365 * 24 * time.Hour},
+	}
+
+	assert.Equal(t, 365*24*time.Hour, policy.For(&Sensor{ID: 1, Type: SensorTypeADC}))
+	assert.Equal(t, 7*24*time.Hour, policy.For(&Sensor{ID: 2, Type: SensorTypeADC}))
+	assert.Equal(t, 30*24*time.Hour, policy.For(&Sensor{ID: 3, Type: SensorTypeContactClosure}))
+
+	longest, ok := policy.Longest()
+	assert.True(t, ok)
+	assert.Equal(t, 365*24*time.Hour, longest)
+
+	policy.ByType[SensorTypeCounter] = 0
+	_, ok = policy.Longest()
+	assert.False(t, ok)
+
+	_, ok = RetentionPolicy{}.Longest()
+	assert.False(t, ok)
+}
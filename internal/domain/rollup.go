@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+type RollupResolution string
+
+const (
+	RollupResolutionHour RollupResolution = "hour"
+	RollupResolutionDay  RollupResolution = "day"
+)
+
+// Duration - длина интервала свёртки
+func (r RollupResolution) Duration() time.Duration {
+	switch r {
+	case RollupResolutionHour:
+		return time.Hour
+	case RollupResolutionDay:
+		return 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// Rollup - свёртка значений событий датчика за интервал, начинающийся в Bucket
+type Rollup struct {
+	SensorID int64
+	Bucket   time.Time
+	Min      int64
+	Max      int64
+	Sum      int64
+	Count    int64
+	// Last - значение последнего события интервала, LastAt - время этого события
+	Last   int64
+	LastAt time.Time
+}
+
+// Average - среднее значение за интервал
+func (r Rollup) Average() float64 {
+	if r.Count == 0 {
+		return 0
+	}
+
+	return float64(r.Sum) / float64(r.Count)
+}
+
+// Value - значение за интервал по способу свёртки: среднее, прирост накопительного счётчика
+// (сумма приращений) или последнее значение
+func (r Rollup) Value(aggregation Aggregation) float64 {
+	switch aggregation {
+	case AggregationAverage:
+		return r.Average()
+	case AggregationDelta:
+		return float64(r.Sum)
+	default:
+		return float64(r.Last)
+	}
+}
+
+// Add - функция добавления значения события в свёртку
+func (r *Rollup) Add(timestamp time.Time, payload int64) {
+	r.Merge(Rollup{Min: payload, Max: payload, Sum: payload, Count: 1, Last: payload, LastAt: timestamp})
+}
+
+// Merge - функция объединения свёрток
+func (r *Rollup) Merge(other Rollup) {
+	if other.Count == 0 {
+		return
+	}
+	if r.Count == 0 || other.Min < r.Min {
+		r.Min = other.Min
+	}
+	if r.Count == 0 || other.Max > r.Max {
+		r.Max = other.Max
+	}
+	if r.Count == 0 || !other.LastAt.Before(r.LastAt) {
+		r.Last = other.Last
+		r.LastAt = other.LastAt
+	}
+	r.Sum += other.Sum
+	r.Count += other.Count
+}
+
+// History - история значений датчика по интервалам
+type History struct {
+	// Aggregation - способ свёртки значений датчика, по которому считается Rollup.Value
+	Aggregation Aggregation
+	Points      []Rollup
+}
+
+// Downsample - функция объединения свёрток в интервалы длиной step, интервалы выравниваются по времени UTC.
+// Результат отсортирован по Bucket.
+func Downsample(rollups []Rollup, step time.Duration) []Rollup {
+	buckets := make(map[time.Time]*Rollup)
+	for _, rollup := range rollups {
+		bucket := rollup.Bucket.UTC().Truncate(step)
+		merged, ok := buckets[bucket]
+		if !ok {
+			merged = &Rollup{SensorID: rollup.SensorID, Bucket: bucket}
+			buckets[bucket] = merged
+		}
+		merged.Merge(rollup)
+	}
+
+	result := make([]Rollup, 0, len(buckets))
+	for _, rollup := range buckets {
+		result = append(result, *rollup)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Bucket.Before(result[j].Bucket)
+	})
+
+	return result
+}
+
+// RollupEvents - функция свёртки событий в интервалы длиной step, см. Downsample
+func RollupEvents(events []Event, step time.Duration) []Rollup {
+	rollups := make([]Rollup, 0, len(events))
+	for _, event := range events {
+		rollup := Rollup{SensorID: event.SensorID, Bucket: event.Timestamp}
+		rollup.Add(event.Timestamp, event.Payload)
+		rollups = append(rollups, rollup)
+	}
+
+	return Downsample(rollups, step)
+}
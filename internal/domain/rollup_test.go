@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollupEvents(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	events := []Event{
+		{SensorID: 1, Timestamp: day.Add(10 * time.Minute), Payload: 10},
+		{SensorID: 1, Timestamp: day.Add(20 * time.Minute), Payload: 30},
+		{SensorID: 1, Timestamp: day.Add(90 * time.Minute), Payload: -5},
+		{SensorID: 1, Timestamp: day.Add(25 * time.Hour), Payload: 7},
+	}
+
+	hourly := RollupEvents(events, time.Hour)
+	assert.Equal(t, []Rollup{
+		{SensorID: 1, Bucket: day, Min: 10, Max: 30, Sum: 40, Count: 2, Last: 30, LastAt: day.Add(20 * time.Minute)},
+		{SensorID: 1, Bucket: day.Add(time.Hour), Min: -5, Max: -5, Sum: -5, Count: 1, Last: -5, LastAt: day.Add(90 * time.Minute)},
+		{SensorID: 1, Bucket: day.Add(25 * time.Hour), Min: 7, Max: 7, Sum: 7, Count: 1, Last: 7, LastAt: day.Add(25 * time.Hour)},
+	}, hourly)
+	assert.Equal(t, float64(20), hourly[0].Average())
+	assert.Equal(t, float64(20), hourly[0].Value(AggregationAverage))
+	assert.Equal(t, float64(40), hourly[0].Value(AggregationDelta))
+	assert.Equal(t, float64(30), hourly[0].Value(AggregationLast))
+
+	daily := Downsample(hourly, RollupResolutionDay.Duration())
+	assert.Equal(t, []Rollup{
+		{SensorID: 1, Bucket: day, Min: -5, Max: 30, Sum: 35, Count: 3, Last: -5, LastAt: day.Add(90 * time.Minute)},
+		{SensorID: 1, Bucket: day.Add(24 * time.Hour), Min: 7, Max: 7, Sum: 7, Count: 1, Last: 7, LastAt: day.Add(25 * time.Hour)},
+	}, daily)
+}
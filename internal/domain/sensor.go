@@ -7,9 +7,37 @@ type SensorType string
 const (
 	SensorTypeContactClosure SensorType = "cc"
 	SensorTypeADC            SensorType = "adc"
+	SensorTypeCounter        SensorType = "counter"
+	SensorTypeSelector       SensorType = "selector"
 )
 
+// Aggregation - способ свёртки значений датчика за период
+type Aggregation string
+
+const (
+	// AggregationAverage - среднее значение, для аналоговых датчиков
+	AggregationAverage Aggregation = "avg"
+	// AggregationLast - последнее значение, для датчиков состояния
+	AggregationLast Aggregation = "last"
+	// AggregationDelta - прирост за период, для накопительных счётчиков
+	AggregationDelta Aggregation = "delta"
+)
+
+// PayloadRange - допустимый диапазон значений событий датчика, границы включаются
+type PayloadRange struct {
+	Min int64
+	Max int64
+}
+
+// Contains - функция проверки, что значение попадает в диапазон
+func (r *PayloadRange) Contains(payload int64) bool {
+	return payload >= r.Min && payload <= r.Max
+}
+
 // Sensor - структура для хранения данных датчика
+// Calibration - калибровка датчика, nil если показания отдаются только в сырых значениях
+// PayloadRange - допустимый диапазон значений событий, nil если ограничений нет
+// Version - версия метаданных датчика, растёт с каждым их изменением; события её не меняют
 type Sensor struct {
 	ID           int64
 	SerialNumber string
@@ -19,4 +47,31 @@ type Sensor struct {
 	IsActive     bool
 	RegisteredAt time.Time
 	LastActivity time.Time
+	Calibration  *Calibration
+	PayloadRange *PayloadRange
+	Version      int64
+}
+
+// SensorStateUpdate - изменение состояния датчика событиями
+type SensorStateUpdate struct {
+	// State - значение последнего по времени события или, если Accumulate, сумма значений событий
+	State int64
+	// Accumulate - State прибавляется к текущему состоянию, иначе заменяет его,
+	// если события не старше последней активности датчика
+	Accumulate bool
+	// LastActivity - время последнего события
+	LastActivity time.Time
+}
+
+// ApplyStateUpdate - функция изменения состояния и времени последней активности датчика
+func (s *Sensor) ApplyStateUpdate(update SensorStateUpdate) {
+	switch {
+	case update.Accumulate:
+		s.CurrentState += update.State
+	case !update.LastActivity.Before(s.LastActivity):
+		s.CurrentState = update.State
+	}
+	if update.LastActivity.After(s.LastActivity) {
+		s.LastActivity = update.LastActivity
+	}
 }
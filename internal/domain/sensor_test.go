@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSensor_ApplyStateUpdate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		update SensorStateUpdate
+		want   Sensor
+	}{
+		{
+			name:   "ok, newer event replaces state",
+			update: SensorStateUpdate{State: 7, LastActivity: now.Add(time.Minute)},
+			want:   Sensor{CurrentState: 7, LastActivity: now.Add(time.Minute)},
+		},
+		{
+			name:   "ok, event at the same time replaces state",
+			update: SensorStateUpdate{State: 7, LastActivity: now},
+			want:   Sensor{CurrentState: 7, LastActivity: now},
+		},
+		{
+			name:   "ok, older event keeps state",
+			update: SensorStateUpdate{State: 7, LastActivity: now.Add(-time.Minute)},
+			want:   Sensor{CurrentState: 5, LastActivity: now},
+		},
+		{
+			name:   "ok, older events are accumulated",
+			update: SensorStateUpdate{State: 7, Accumulate: true, LastActivity: now.Add(-time.Minute)},
+			want:   Sensor{CurrentState: 12, LastActivity: now},
+		},
+		{
+			name:   "ok, newer events are accumulated",
+			update: SensorStateUpdate{State: 7, Accumulate: true, LastActivity: now.Add(time.Minute)},
+			want:   Sensor{CurrentState: 12, LastActivity: now.Add(time.Minute)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sensor := Sensor{CurrentState: 5, LastActivity: now}
+			sensor.ApplyStateUpdate(tt.update)
+			assert.Equal(t, tt.want, sensor)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// StateInterval - интервал, в течение которого датчик находился в одном состоянии
+type StateInterval struct {
+	State int64
+	From  time.Time
+	To    time.Time
+}
+
+// Duration - длительность интервала
+func (i StateInterval) Duration() time.Duration {
+	return i.To.Sub(i.From)
+}
+
+// StateDuration - суммарное время нахождения датчика в состоянии State
+type StateDuration struct {
+	State    int64
+	Duration time.Duration
+}
+
+// StateReport - отчёт о состояниях датчика за период [From, To)
+// Durations - время в каждом состоянии по возрастанию State, время до первого известного события не учитывается
+// Transitions - количество смен состояния, Longest - самый длинный интервал, nil если событий нет
+type StateReport struct {
+	SensorID    int64
+	From        time.Time
+	To          time.Time
+	Durations   []StateDuration
+	Transitions int64
+	Longest     *StateInterval
+}
+
+// NewStateIntervals - функция построения интервалов состояний за период [from, to) по событиям,
+// отсортированным по времени. Первое событие может быть раньше from: оно задаёт начальное состояние.
+// Подряд идущие события с одинаковым значением объединяются в один интервал.
+func NewStateIntervals(events []Event, from, to time.Time) []StateInterval {
+	intervals := make([]StateInterval, 0)
+	for _, event := range events {
+		if !event.Timestamp.Before(to) {
+			break
+		}
+
+		start := event.Timestamp
+		if start.Before(from) {
+			start = from
+		}
+
+		if n := len(intervals); n > 0 {
+			if intervals[n-1].State == event.Payload {
+				continue
+			}
+			intervals[n-1].To = start
+		}
+		intervals = append(intervals, StateInterval{State: event.Payload, From: start, To: to})
+	}
+
+	return intervals
+}
+
+// NewStateReport - функция построения отчёта по интервалам состояний, см. NewStateIntervals
+func NewStateReport(sensorID int64, from, to time.Time, intervals []StateInterval) *StateReport {
+	report := &StateReport{
+		SensorID:  sensorID,
+		From:      from,
+		To:        to,
+		Durations: make([]StateDuration, 0),
+	}
+
+	durations := make(map[int64]time.Duration)
+	for i, interval := range intervals {
+		durations[interval.State] += interval.Duration()
+		if report.Longest == nil || interval.Duration() > report.Longest.Duration() {
+			report.Longest = &intervals[i]
+		}
+	}
+	if len(intervals) > 0 {
+		report.Transitions = int64(len(intervals) - 1)
+	}
+
+	for state, duration := range durations {
+		report.Durations = append(report.Durations, StateDuration{State: state, Duration: duration})
+	}
+	sort.Slice(report.Durations, func(i, j int) bool {
+		return report.Durations[i].State < report.Durations[j].State
+	})
+
+	return report
+}
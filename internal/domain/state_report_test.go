@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStateReport(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	at := func(d time.Duration) time.Time {
+		return from.Add(d)
+	}
+
+	tests := []struct {
+		name        string
+		events      []Event
+		intervals   []StateInterval
+		durations   []StateDuration
+		transitions int64
+		longest     *StateInterval
+	}{
+		{
+			name:      "no events",
+			intervals: []StateInterval{},
+			durations: []StateDuration{},
+		},
+		{
+			name: "state before range, repeated payloads merged",
+			events: []Event{
+				{Timestamp: at(-time.Hour), Payload: 0},
+				{Timestamp: at(8 * time.Hour), Payload: 1},
+				{Timestamp: at(9 * time.Hour), Payload: 1},
+				{Timestamp: at(10 * time.Hour), Payload: 0},
+				{Timestamp: at(25 * time.Hour), Payload: 1},
+			},
+			intervals: []StateInterval{
+				{State: 0, From: at(0), To: at(8 * time.Hour)},
+				{State: 1, From: at(8 * time.Hour), To: at(10 * time.Hour)},
+				{State: 0, From: at(10 * time.Hour), To: to},
+			},
+			durations: []StateDuration{
+				{State: 0, Duration: 22 * time.Hour},
+				{State: 1, Duration: 2 * time.Hour},
+			},
+			transitions: 2,
+			longest:     &StateInterval{State: 0, From: at(10 * time.Hour), To: to},
+		},
+		{
+			name: "first event inside range",
+			events: []Event{
+				{Timestamp: at(12 * time.Hour), Payload: 1},
+			},
+			intervals: []StateInterval{
+				{State: 1, From: at(12 * time.Hour), To: to},
+			},
+			durations: []StateDuration{
+				{State: 1, Duration: 12 * time.Hour},
+			},
+			longest: &StateInterval{State: 1, From: at(12 * time.Hour), To: to},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intervals := NewStateIntervals(tt.events, from, to)
+			assert.Equal(t, tt.intervals, intervals)
+
+			report := NewStateReport(1, from, to, intervals)
+			assert.Equal(t, tt.durations, report.Durations)
+			assert.Equal(t, tt.transitions, report.Transitions)
+			assert.Equal(t, tt.longest, report.Longest)
+		})
+	}
+}
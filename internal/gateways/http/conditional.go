@@ -0,0 +1,133 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"homework/internal/domain"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sensorsETag - сильный ETag представления датчиков в формате mediaType. Вычисляется по всем полям датчиков,
+// поэтому меняется и с новым событием (LastActivity, CurrentState), и при смене калибровки или диапазона.
+func sensorsETag(mediaType string, sensors ...domain.Sensor) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(mediaType))
+	enc := json.NewEncoder(h)
+	for i := range sensors {
+		// поля датчика - простые значения, кодирование не возвращает ошибок
+		_ = enc.Encode(&sensors[i])
+	}
+
+	return fmt.Sprintf(`"%016x"`, h.Sum64())
+}
+
+// sensorETag - сильный ETag представления датчика в формате mediaType: версия метаданных датчика
+// и хэш представления. Хэш меняется с новым событием, версия - только при изменении метаданных,
+// поэтому If-Match сравнивает только версию.
+func sensorETag(mediaType string, sensor *domain.Sensor) string {
+	return fmt.Sprintf(`"%d-%s`, sensor.Version, strings.TrimPrefix(sensorsETag(mediaType, *sensor), `"`))
+}
+
+// sensorETagVersion - версия метаданных датчика из его ETag, false для слабого или чужого ETag
+func sensorETagVersion(etag string) (int64, bool) {
+	tag, ok := strings.CutPrefix(etag, `"`)
+	if !ok {
+		return 0, false
+	}
+	version, _, ok := strings.Cut(tag, "-")
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(version, 10, 64)
+	if err != nil || v < 1 {
+		return 0, false
+	}
+
+	return v, true
+}
+
+// sensorsLastModified - время последнего изменения датчиков: последнее событие или регистрация.
+// Смена калибровки и диапазона его не меняет, такие изменения видны только по ETag.
+func sensorsLastModified(sensors ...domain.Sensor) time.Time {
+	var modified time.Time
+	for i := range sensors {
+		for _, t := range []time.Time{sensors[i].RegisteredAt, sensors[i].LastActivity} {
+			if t.After(modified) {
+				modified = t
+			}
+		}
+	}
+
+	return modified
+}
+
+// notModified - записывает заголовки ETag и Last-Modified и проверяет условия If-None-Match и If-Modified-Since
+// запроса GET или HEAD. Если представление у клиента актуально, отвечает 304 и возвращает true.
+// If-Modified-Since учитывается только без If-None-Match.
+func notModified(c *gin.Context, etag string, modified time.Time) bool {
+	c.Header("ETag", etag)
+	if !modified.IsZero() {
+		c.Header("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if v := c.GetHeader("If-None-Match"); v != "" {
+		if !etagMatches(v, etag, true) {
+			return false
+		}
+	} else {
+		since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+		if err != nil || modified.IsZero() || modified.Truncate(time.Second).After(since) {
+			return false
+		}
+	}
+
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// ifMatch - ожидаемая версия датчика из условия If-Match его изменения, 0 - без условия или для "*".
+// Версия одинакова в ETag любого формата ответа; из списка учитывается первый сильный ETag датчика.
+// Совпадение версии проверяет хранилище в момент записи, поэтому изменения между проверкой и записью не теряются.
+func ifMatch(c *gin.Context) (int64, error) {
+	v := strings.TrimSpace(c.GetHeader("If-Match"))
+	if v == "" || v == "*" {
+		return 0, nil
+	}
+
+	for _, tag := range strings.Split(v, ",") {
+		if version, ok := sensorETagVersion(strings.TrimSpace(tag)); ok {
+			return version, nil
+		}
+	}
+
+	return 0, ErrPreconditionFailed
+}
+
+// etagMatches - проверка, что etag есть в списке header заголовка If-Match или If-None-Match.
+// При слабом сравнении (weak) теги W/ сравниваются без префикса, при сильном не совпадают ни с чем.
+func etagMatches(header, etag string, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
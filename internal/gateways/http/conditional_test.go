@@ -0,0 +1,285 @@
+package http
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		weak   bool
+		want   bool
+	}{
+		{name: "ok, exact", header: `"abc"`, want: true},
+		{name: "ok, list", header: `"def", "abc"`, want: true},
+		{name: "ok, any", header: "*", want: true},
+		{name: "ok, weak comparison", header: `W/"abc"`, weak: true, want: true},
+		{name: "fail, strong comparison of weak tag", header: `W/"abc"`, want: false},
+		{name: "fail, other tag", header: `"def"`, weak: true, want: false},
+		{name: "fail, unquoted", header: `abc`, weak: true, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, etagMatches(tt.header, `"abc"`, tt.weak))
+		})
+	}
+}
+
+func TestSensorsETag(t *testing.T) {
+	sensor := domain.Sensor{ID: 1, SerialNumber: "0123456789", Type: domain.SensorTypeADC, CurrentState: 1}
+	etag := sensorsETag(mimeJSON, sensor)
+
+	assert.Regexp(t, `^"[0-9a-f]{16}"$`, etag)
+	assert.Equal(t, etag, sensorsETag(mimeJSON, sensor))
+	assert.NotEqual(t, etag, sensorsETag(mimeXML, sensor), "representations differ")
+
+	changed := sensor
+	changed.PayloadRange = &domain.PayloadRange{Min: 0, Max: 1}
+	assert.NotEqual(t, etag, sensorsETag(mimeJSON, changed))
+
+	changed = sensor
+	changed.LastActivity = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.NotEqual(t, etag, sensorsETag(mimeJSON, changed))
+}
+
+func TestSensorETag(t *testing.T) {
+	sensor := domain.Sensor{ID: 1, SerialNumber: "0123456789", Type: domain.SensorTypeADC, CurrentState: 1, Version: 3}
+	etag := sensorETag(mimeJSON, &sensor)
+
+	assert.Regexp(t, `^"3-[0-9a-f]{16}"$`, etag)
+	version, ok := sensorETagVersion(etag)
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), version)
+
+	// новое событие меняет ETag, но не версию
+	changed := sensor
+	changed.CurrentState = 2
+	assert.NotEqual(t, etag, sensorETag(mimeJSON, &changed))
+	version, _ = sensorETagVersion(sensorETag(mimeJSON, &changed))
+	assert.Equal(t, int64(3), version)
+
+	for _, tag := range []string{`W/` + etag, `"0000000000000000"`, `"0-0000000000000000"`, `3-0000000000000000`} {
+		_, ok := sensorETagVersion(tag)
+		assert.False(t, ok, tag)
+	}
+}
+
+func TestServer_ConditionalGet(t *testing.T) {
+	lastActivity := time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC)
+	sensor := domain.Sensor{
+		ID:           1,
+		SerialNumber: "0123456789",
+		Type:         domain.SensorTypeADC,
+		RegisteredAt: lastActivity.Add(-time.Hour),
+		LastActivity: lastActivity,
+	}
+	ctrl := gomock.NewController(t)
+	srMock := usecase.NewMockSensorRepository(ctrl)
+	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(1))).DoAndReturn(func(context.Context, int64) (*domain.Sensor, error) {
+		s := sensor
+		return &s, nil
+	}).AnyTimes()
+	srMock.EXPECT().GetSensors(gomock.Any()).Return([]domain.Sensor{sensor}, nil).AnyTimes()
+	s := NewServer(newServerUseCases(t, srMock))
+
+	serve := func(method, target string, headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, target := range []string{"/sensors", "/sensors/1"} {
+		t.Run(target, func(t *testing.T) {
+			w := serve(http.MethodGet, target, nil)
+			require.Equal(t, http.StatusOK, w.Code)
+			etag := w.Header().Get("ETag")
+			require.NotEmpty(t, etag)
+			assert.Equal(t, "Tue, 02 Jan 2024 03:04:05 GMT", w.Header().Get("Last-Modified"))
+
+			t.Run("ok, if-none-match", func(t *testing.T) {
+				w := serve(http.MethodGet, target, map[string]string{"If-None-Match": etag})
+				assert.Equal(t, http.StatusNotModified, w.Code)
+				assert.Equal(t, etag, w.Header().Get("ETag"))
+				assert.Empty(t, w.Body.String())
+
+				w = serve(http.MethodHead, target, map[string]string{"If-None-Match": `"other", W/` + etag})
+				assert.Equal(t, http.StatusNotModified, w.Code)
+			})
+
+			t.Run("ok, etag depends on format", func(t *testing.T) {
+				w := serve(http.MethodGet, target, map[string]string{"If-None-Match": etag, "Accept": "application/xml"})
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.NotEqual(t, etag, w.Header().Get("ETag"))
+			})
+
+			t.Run("ok, if-modified-since", func(t *testing.T) {
+				w := serve(http.MethodGet, target, map[string]string{"If-Modified-Since": "Tue, 02 Jan 2024 03:04:05 GMT"})
+				assert.Equal(t, http.StatusNotModified, w.Code)
+
+				w = serve(http.MethodGet, target, map[string]string{"If-Modified-Since": "Tue, 02 Jan 2024 03:04:04 GMT"})
+				assert.Equal(t, http.StatusOK, w.Code)
+			})
+
+			t.Run("ok, if-none-match takes precedence", func(t *testing.T) {
+				w := serve(http.MethodGet, target, map[string]string{
+					"If-None-Match":     `"other"`,
+					"If-Modified-Since": "Tue, 02 Jan 2024 03:04:05 GMT",
+				})
+				assert.Equal(t, http.StatusOK, w.Code)
+			})
+		})
+	}
+}
+
+func TestServer_IfMatch(t *testing.T) {
+	sensor := domain.Sensor{ID: 1, SerialNumber: "0123456789", Type: domain.SensorTypeADC, Version: 3}
+	etag := sensorETag(mimeJSON, &sensor)
+
+	newServer := func(t *testing.T, saves int, saveErr error) *Server {
+		ctrl := gomock.NewController(t)
+		srMock := usecase.NewMockSensorRepository(ctrl)
+		srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(1))).DoAndReturn(func(context.Context, int64) (*domain.Sensor, error) {
+			s := sensor
+			return &s, nil
+		}).AnyTimes()
+		srMock.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, ss *domain.Sensor) error {
+			if saveErr != nil {
+				return saveErr
+			}
+			assert.Equal(t, int64(3), ss.Version)
+			ss.Version++
+			return nil
+		}).Times(saves)
+
+		return NewServer(newServerUseCases(t, srMock))
+	}
+	serve := func(s *Server, method, target, body, ifMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+	put := func(s *Server, ifMatch string) *httptest.ResponseRecorder {
+		return serve(s, http.MethodPut, "/sensors/1/payload-range", `{"min": 0, "max": 100}`, ifMatch)
+	}
+
+	t.Run("ok, current etag", func(t *testing.T) {
+		w := put(newServer(t, 1, nil), etag)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		changed := sensor
+		changed.PayloadRange = &domain.PayloadRange{Min: 0, Max: 100}
+		changed.Version = 4
+		assert.Equal(t, sensorETag(mimeJSON, &changed), w.Header().Get("ETag"))
+	})
+
+	t.Run("ok, xml etag", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, put(newServer(t, 1, nil), sensorETag(mimeXML, &sensor)).Code)
+	})
+
+	t.Run("ok, xml response", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, "/sensors/1/payload-range", strings.NewReader(`{"min": 0, "max": 100}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/xml")
+		newServer(t, 1, nil).router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), mimeXML)
+		changed := sensor
+		changed.PayloadRange = &domain.PayloadRange{Min: 0, Max: 100}
+		changed.Version = 4
+		assert.Equal(t, sensorETag(mimeXML, &changed), w.Header().Get("ETag"))
+	})
+
+	t.Run("fail, not acceptable", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, "/sensors/1/payload-range", strings.NewReader(`{"min": 0, "max": 100}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "text/csv")
+		newServer(t, 0, nil).router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	})
+
+	t.Run("ok, etag before new events", func(t *testing.T) {
+		before := sensor
+		before.CurrentState = 42
+		assert.Equal(t, http.StatusOK, put(newServer(t, 1, nil), `"other", `+sensorETag(mimeJSON, &before)).Code)
+	})
+
+	t.Run("ok, any", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, put(newServer(t, 1, nil), "*").Code)
+	})
+
+	t.Run("ok, without if-match", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, put(newServer(t, 1, nil), "").Code)
+	})
+
+	t.Run("ok, patch", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		srMock := usecase.NewMockSensorRepository(ctrl)
+		srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(1))).Return(&domain.Sensor{
+			ID:           1,
+			SerialNumber: "0123456789",
+			Type:         domain.SensorTypeADC,
+			Calibration:  &domain.Calibration{Kind: domain.CalibrationKindLinear, Scale: 2},
+			PayloadRange: &domain.PayloadRange{Min: 0, Max: 10},
+			Version:      3,
+		}, nil)
+		srMock.EXPECT().SaveSensor(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, ss *domain.Sensor) error {
+			assert.Equal(t, "kitchen", ss.Description)
+			assert.Equal(t, &domain.Calibration{Kind: domain.CalibrationKindLinear, Scale: 2}, ss.Calibration)
+			assert.Nil(t, ss.PayloadRange)
+			ss.Version++
+			return nil
+		})
+
+		w := serve(NewServer(newServerUseCases(t, srMock)), http.MethodPatch, "/sensors/1", `{"description": "kitchen", "payload_range": null}`, etag)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Regexp(t, `^"4-`, w.Header().Get("ETag"))
+		assert.Contains(t, w.Body.String(), `"description":"kitchen"`)
+	})
+
+	t.Run("fail, stale etag", func(t *testing.T) {
+		w := put(newServer(t, 0, nil), `"2-0000000000000000"`)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"precondition_failed"`)
+	})
+
+	t.Run("fail, changed between read and write", func(t *testing.T) {
+		w := put(newServer(t, 1, usecase.ErrSensorVersionConflict), etag)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	})
+
+	t.Run("fail, weak etag", func(t *testing.T) {
+		assert.Equal(t, http.StatusPreconditionFailed, put(newServer(t, 0, nil), "W/"+etag).Code)
+	})
+
+	t.Run("fail, list etag", func(t *testing.T) {
+		assert.Equal(t, http.StatusPreconditionFailed, put(newServer(t, 0, nil), sensorsETag(mimeJSON, sensor)).Code)
+	})
+}
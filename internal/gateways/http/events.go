@@ -0,0 +1,64 @@
+package http
+
+import (
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var serialNumberRegexp = regexp.MustCompile(`^\d{10}$`)
+
+func receiveEvent(uc UseCases, ws *WebSocketHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body SensorEvent
+		if err := readBody(c, &body, eventFormats...); err != nil {
+			writeError(c, err)
+			return
+		}
+		if !serialNumberRegexp.MatchString(body.SensorSerialNumber) {
+			writeError(c, invalidField("sensor_serial_number", usecase.ErrWrongSensorSerialNumber))
+			return
+		}
+
+		event := &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: body.SensorSerialNumber,
+			Payload:            body.Payload,
+		}
+		if err := uc.Event.ReceiveEvent(c.Request.Context(), event); err != nil {
+			writeError(c, err)
+			return
+		}
+		// событие из очереди сохраняется позже, подписчики уведомляются после сохранения, см. setupRouter
+		if uc.Event.Deferred() {
+			c.Status(http.StatusAccepted)
+			return
+		}
+		ws.Notify(c.Request.Context(), event.SensorID)
+
+		c.Status(http.StatusCreated)
+	}
+}
+
+func getQuarantinedEvents(uc UseCases) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format, err := negotiate(c, responseFormats...)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		reason := domain.QuarantineReason(c.Query("reason"))
+		events, err := uc.Event.GetQuarantinedEvents(c.Request.Context(), reason)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		writeResponse(c, http.StatusOK, format, newQuarantinedEvents(events))
+	}
+}
@@ -0,0 +1,208 @@
+package http
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
+)
+
+const (
+	mimeXML = "application/xml"
+	mimeCSV = "text/csv"
+	// mimeMsgPack - формат MessagePack, mimeXMsgPack - его прежнее название, которое ещё отправляют клиенты
+	mimeMsgPack  = "application/msgpack"
+	mimeXMsgPack = "application/x-msgpack"
+)
+
+var (
+	// responseFormats - форматы ответа по умолчанию, первый используется, если клиент не указал Accept
+	responseFormats = []string{mimeJSON, mimeXML}
+	// listFormats - форматы ответа со списками датчиков и историей
+	listFormats = []string{mimeJSON, mimeXML, mimeCSV}
+	// eventFormats - форматы тела запроса с событием датчика
+	eventFormats = []string{mimeJSON, mimeXML, mimeMsgPack, mimeXMsgPack}
+)
+
+// msgpackHandle - настройки MessagePack, поля называются по тегам json
+var msgpackHandle codec.MsgpackHandle
+
+// csvMarshaler - ответ, который можно отдать в формате csv: первая запись - заголовок
+type csvMarshaler interface {
+	marshalCSV() [][]string
+}
+
+// mediaRange - элемент заголовка Accept
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+// matches - степень совпадения диапазона с форматом: 3 - точное, 2 - type/*, 1 - */*, 0 - не подходит
+func (r mediaRange) matches(mediaType string) int {
+	switch {
+	case r.mediaType == mediaType:
+		return 3
+	case r.mediaType == "*/*":
+		return 1
+	case strings.HasSuffix(r.mediaType, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(r.mediaType, "*")):
+		return 2
+	default:
+		return 0
+	}
+}
+
+// parseAccept - разбор заголовка Accept, элементы с невалидным типом или q пропускаются
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+	}
+
+	return ranges
+}
+
+// negotiate - выбор формата ответа из offers по заголовку Accept с учётом q-значений.
+// Формат получает q самого точного подходящего диапазона, из форматов с равным q выбирается
+// стоящий в offers раньше. Без заголовка Accept выбирается первый формат.
+func negotiate(c *gin.Context, offers ...string) (string, error) {
+	c.Header("Vary", "Accept")
+
+	accept := c.GetHeader("Accept")
+	if accept == "" {
+		return offers[0], nil
+	}
+
+	ranges := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, specificity := 0.0, 0
+		for _, r := range ranges {
+			if m := r.matches(offer); m > specificity {
+				q, specificity = r.q, m
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	if best == "" {
+		return "", ErrNotAcceptable
+	}
+
+	return best, nil
+}
+
+// readBody - функция чтения тела запроса в одном из форматов mediaTypes
+func readBody(c *gin.Context, v any, mediaTypes ...string) error {
+	mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if err != nil || !slices.Contains(mediaTypes, mediaType) {
+		return ErrUnsupportedMediaType
+	}
+
+	switch mediaType {
+	case mimeXML:
+		err = xml.NewDecoder(c.Request.Body).Decode(v)
+	case mimeMsgPack, mimeXMsgPack:
+		err = codec.NewDecoder(c.Request.Body, &msgpackHandle).Decode(v)
+	default:
+		err = json.NewDecoder(c.Request.Body).Decode(v)
+	}
+	if err != nil {
+		return errors.Join(ErrInvalidBody, err)
+	}
+
+	return nil
+}
+
+// writeResponse - функция записи ответа в формате mediaType, для HEAD запроса отдаются только заголовки
+func writeResponse(c *gin.Context, status int, mediaType string, v any) {
+	body, err := marshalBody(mediaType, v)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	contentType := mediaType + "; charset=utf-8"
+	c.Header("Content-Length", strconv.Itoa(len(body)))
+	if c.Request.Method == http.MethodHead {
+		c.Header("Content-Type", contentType)
+		c.Status(status)
+		return
+	}
+
+	c.Data(status, contentType, body)
+}
+
+func marshalBody(mediaType string, v any) ([]byte, error) {
+	switch mediaType {
+	case mimeXML:
+		body, err := xml.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("can't marshal xml: %w", err)
+		}
+		return append([]byte(xml.Header), body...), nil
+	case mimeCSV:
+		m, ok := v.(csvMarshaler)
+		if !ok {
+			return nil, fmt.Errorf("can't marshal %T to csv", v)
+		}
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		if err := w.WriteAll(m.marshalCSV()); err != nil {
+			return nil, fmt.Errorf("can't marshal csv: %w", err)
+		}
+		return buf.Bytes(), nil
+	default:
+		body, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("can't marshal json: %w", err)
+		}
+		return body, nil
+	}
+}
+
+// marshalXMLList - запись списка items элементом name, в котором каждый элемент списка записан элементом itemName
+func marshalXMLList[T any](e *xml.Encoder, name, itemName string, items []T) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for i := range items {
+		if err := e.EncodeElement(items[i], xml.StartElement{Name: xml.Name{Local: itemName}}); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+func formatCSVTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+func formatCSVFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
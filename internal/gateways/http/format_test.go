@@ -0,0 +1,155 @@
+package http
+
+import (
+	"bytes"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name    string
+		accept  string
+		offers  []string
+		want    string
+		wantErr error
+	}{
+		{name: "ok, no accept", offers: listFormats, want: mimeJSON},
+		{name: "ok, exact", accept: "application/xml", offers: responseFormats, want: mimeXML},
+		{name: "ok, any", accept: "*/*", offers: listFormats, want: mimeJSON},
+		{name: "ok, type wildcard", accept: "text/*", offers: listFormats, want: mimeCSV},
+		{name: "ok, higher q wins", accept: "application/json;q=0.5, application/xml", offers: responseFormats, want: mimeXML},
+		{name: "ok, equal q keeps server order", accept: "application/xml;q=0.8, application/json;q=0.8", offers: responseFormats, want: mimeJSON},
+		{name: "ok, exact range overrides wildcard", accept: "application/*;q=0.9, application/json;q=0", offers: responseFormats, want: mimeXML},
+		{name: "ok, invalid range is skipped", accept: "application/xml;q=abc, text/csv;q=0.1", offers: listFormats, want: mimeCSV},
+		{name: "err, unsupported", accept: "text/html", offers: responseFormats, wantErr: ErrNotAcceptable},
+		{name: "err, csv is not offered", accept: "text/csv", offers: responseFormats, wantErr: ErrNotAcceptable},
+		{name: "err, all refused", accept: "application/json;q=0, */*;q=0", offers: responseFormats, wantErr: ErrNotAcceptable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				c.Request.Header.Set("Accept", tt.accept)
+			}
+
+			got, err := negotiate(c, tt.offers...)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, "Accept", w.Header().Get("Vary"))
+		})
+	}
+}
+
+func TestServer_ResponseFormats(t *testing.T) {
+	registeredAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctrl := gomock.NewController(t)
+	srMock := usecase.NewMockSensorRepository(ctrl)
+	srMock.EXPECT().GetSensors(gomock.Any()).Return([]domain.Sensor{{
+		ID:           1,
+		SerialNumber: "0123456789",
+		Type:         domain.SensorTypeADC,
+		CurrentState: 25,
+		Description:  "температура, кухня",
+		IsActive:     true,
+		RegisteredAt: registeredAt,
+		LastActivity: registeredAt,
+		Calibration:  &domain.Calibration{Kind: domain.CalibrationKindLinear, Scale: 0.5, Unit: "°C", Precision: 1},
+		PayloadRange: &domain.PayloadRange{Min: 0, Max: 100},
+	}}, nil).AnyTimes()
+	s := NewServer(newServerUseCases(t, srMock))
+
+	get := func(accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/sensors", nil)
+		req.Header.Set("Accept", accept)
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("ok, csv", func(t *testing.T) {
+		w := get("text/csv")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "id,serial_number,type,current_state,current_value,unit,description,is_active,registered_at,last_activity,payload_range_min,payload_range_max\n"+
+			`1,0123456789,adc,25,12.5,°C,"температура, кухня",true,2024-01-01T00:00:00Z,2024-01-01T00:00:00Z,0,100`+"\n", w.Body.String())
+	})
+
+	t.Run("ok, xml", func(t *testing.T) {
+		w := get("application/json;q=0.5, application/xml")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+		body := w.Body.String()
+		assert.True(t, strings.HasPrefix(body, `<?xml version="1.0" encoding="UTF-8"?>`+"\n<sensors><sensor><id>1</id><serial_number>0123456789</serial_number>"), body)
+		assert.Contains(t, body, "<current_value>12.5</current_value>")
+		assert.Contains(t, body, "<payload_range><min>0</min><max>100</max></payload_range></sensor></sensors>")
+	})
+
+	t.Run("ok, head", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodHead, "/sensors", nil)
+		req.Header.Set("Accept", "text/csv")
+		s.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, get("text/csv").Header().Get("Content-Length"), w.Header().Get("Content-Length"))
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("fail, not acceptable", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotAcceptable, get("text/html").Code)
+	})
+}
+
+func TestServer_EventFormats(t *testing.T) {
+	s := NewServer(newEventsUseCases(t))
+
+	var msgpackBody []byte
+	require.NoError(t, codec.NewEncoderBytes(&msgpackBody, &msgpackHandle).Encode(SensorEvent{
+		SensorSerialNumber: "0123456789",
+		Payload:            1,
+	}))
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        int
+	}{
+		{name: "ok, json", contentType: "application/json", body: []byte(`{"sensor_serial_number": "0123456789", "payload": 1}`), want: http.StatusCreated},
+		{name: "ok, xml", contentType: "application/xml; charset=utf-8", body: []byte(`<event><sensor_serial_number>0123456789</sensor_serial_number><payload>1</payload></event>`), want: http.StatusCreated},
+		{name: "ok, msgpack", contentType: "application/msgpack", body: msgpackBody, want: http.StatusCreated},
+		{name: "ok, legacy msgpack type", contentType: "application/x-msgpack", body: msgpackBody, want: http.StatusCreated},
+		{name: "fail, invalid xml", contentType: "application/xml", body: []byte(`<event>`), want: http.StatusBadRequest},
+		{name: "fail, invalid msgpack", contentType: "application/msgpack", body: []byte{0xc1}, want: http.StatusBadRequest},
+		{name: "fail, unsupported", contentType: "text/csv", body: []byte("0123456789,1"), want: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			s.router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Code, w.Body.String())
+		})
+	}
+}
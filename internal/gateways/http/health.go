@@ -0,0 +1,104 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"homework/internal/buildinfo"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessCheckTimeout - ограничение времени одной проверки готовности
+const readinessCheckTimeout = 2 * time.Second
+
+const (
+	healthStatusOK          = "ok"
+	healthStatusUnavailable = "unavailable"
+)
+
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// ReadinessCheck - проверка готовности компонента, от которого зависит обработка запросов
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// health - состояние сервера для проверок живости и готовности
+type health struct {
+	checks       []ReadinessCheck
+	shuttingDown atomic.Bool
+}
+
+func setupHealthRoutes(r *gin.Engine, h *health) {
+	r.GET("/healthz", h.live)
+	r.HEAD("/healthz", h.live)
+	r.OPTIONS("/healthz", allow(http.MethodGet, http.MethodHead, http.MethodOptions))
+
+	r.GET("/readyz", h.ready)
+	r.HEAD("/readyz", h.ready)
+	r.OPTIONS("/readyz", allow(http.MethodGet, http.MethodHead, http.MethodOptions))
+
+	r.GET("/version", getVersion)
+	r.HEAD("/version", getVersion)
+	r.OPTIONS("/version", allow(http.MethodGet, http.MethodHead, http.MethodOptions))
+}
+
+// live - процесс жив и обрабатывает запросы, внешние зависимости не проверяются
+func (h *health) live(c *gin.Context) {
+	writeJSON(c, http.StatusOK, Health{Status: healthStatusOK})
+}
+
+// ready - сервер готов принимать запросы: все проверки прошли и остановка не началась
+func (h *health) ready(c *gin.Context) {
+	if h.shuttingDown.Load() {
+		writeJSON(c, http.StatusServiceUnavailable, Health{
+			Status: healthStatusUnavailable,
+			Checks: map[string]string{"shutdown": ErrShuttingDown.Error()},
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessCheckTimeout)
+	defer cancel()
+
+	results := make([]error, len(h.checks))
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = check.Check(ctx)
+		}()
+	}
+	wg.Wait()
+
+	resp := Health{Status: healthStatusOK, Checks: make(map[string]string, len(h.checks))}
+	status := http.StatusOK
+	for i, check := range h.checks {
+		if err := results[i]; err != nil {
+			resp.Checks[check.Name] = err.Error()
+			resp.Status = healthStatusUnavailable
+			status = http.StatusServiceUnavailable
+			continue
+		}
+		resp.Checks[check.Name] = healthStatusOK
+	}
+
+	writeJSON(c, status, resp)
+}
+
+func getVersion(c *gin.Context) {
+	info := buildinfo.Get()
+	writeJSON(c, http.StatusOK, Version{
+		Version:   info.Version,
+		Commit:    info.Commit,
+		BuildTime: info.BuildTime,
+		GoVersion: info.GoVersion,
+		Modified:  info.Modified,
+	})
+}
@@ -0,0 +1,125 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"homework/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveHealth(h *health, method, target string) *httptest.ResponseRecorder {
+	r := gin.New()
+	setupHealthRoutes(r, h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+
+	return w
+}
+
+func TestHealth(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	fail := func(context.Context) error { return errors.New("connection refused") }
+
+	t.Run("ok, liveness does not run checks", func(t *testing.T) {
+		w := serveHealth(&health{checks: []ReadinessCheck{{Name: "postgres", Check: fail}}}, http.MethodGet, "/healthz")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+	})
+
+	t.Run("ok, ready", func(t *testing.T) {
+		w := serveHealth(&health{checks: []ReadinessCheck{{Name: "postgres", Check: ok}, {Name: "ingestion", Check: ok}}}, http.MethodGet, "/readyz")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"status":"ok","checks":{"postgres":"ok","ingestion":"ok"}}`, w.Body.String())
+	})
+
+	t.Run("ok, head", func(t *testing.T) {
+		w := serveHealth(&health{checks: []ReadinessCheck{{Name: "postgres", Check: fail}}}, http.MethodHead, "/readyz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("fail, check failed", func(t *testing.T) {
+		w := serveHealth(&health{checks: []ReadinessCheck{{Name: "postgres", Check: fail}, {Name: "ingestion", Check: ok}}}, http.MethodGet, "/readyz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.JSONEq(t, `{"status":"unavailable","checks":{"postgres":"connection refused","ingestion":"ok"}}`, w.Body.String())
+	})
+
+	t.Run("fail, check timed out", func(t *testing.T) {
+		slow := func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		started := time.Now()
+		w := serveHealth(&health{checks: []ReadinessCheck{{Name: "postgres", Check: slow}}}, http.MethodGet, "/readyz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Less(t, time.Since(started), 2*readinessCheckTimeout)
+	})
+
+	t.Run("fail, shutting down", func(t *testing.T) {
+		h := &health{checks: []ReadinessCheck{{Name: "postgres", Check: ok}}}
+		h.shuttingDown.Store(true)
+
+		w := serveHealth(h, http.MethodGet, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.JSONEq(t, `{"status":"unavailable","checks":{"shutdown":"server is shutting down"}}`, w.Body.String())
+
+		w = serveHealth(h, http.MethodGet, "/healthz")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("ok, version", func(t *testing.T) {
+		w := serveHealth(&health{}, http.MethodGet, "/version")
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var v Version
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &v))
+		assert.Equal(t, "dev", v.Version)
+		assert.Equal(t, runtime.Version(), v.GoVersion)
+	})
+}
+
+func TestServer_Serve_NotReadyOnShutdown(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	serverCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	srMock := usecase.NewMockSensorRepository(gomock.NewController(t))
+	addr, done := startServer(t, serverCtx, srMock, WithShutdownDelay(time.Second))
+
+	get := func(path string) int {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, get("/readyz"))
+
+	stop()
+
+	// пока идёт задержка остановки, запросы принимаются, но сервер уже не готов
+	assert.Eventually(t, func() bool {
+		return get("/readyz") == http.StatusServiceUnavailable
+	}, 500*time.Millisecond, 10*time.Millisecond)
+	assert.Equal(t, http.StatusOK, get("/healthz"))
+
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"homework/internal/logging"
+	"log/slog"
+	mathRand "math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestIDHeader - заголовок с идентификатором запроса
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength - максимальная длина принимаемого идентификатора запроса
+const maxRequestIDLength = 128
+
+// requestIDMiddleware - берёт идентификатор запроса из X-Request-ID или создаёт новый,
+// возвращает его в ответе и передаёт обработчикам в контексте, см. logging.WithRequestID
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Header(requestIDHeader, id)
+
+		ctx := logging.WithRequestID(c.Request.Context(), id)
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("http.request_id", id))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// validRequestID - непустой идентификатор из печатных ASCII символов, чтобы его можно было записать в журнал как есть
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// accessLog - журнал запросов. Запросы, завершившиеся ошибкой сервера, записываются всегда,
+// остальные - с вероятностью sampleRatio.
+type accessLog struct {
+	logger      *slog.Logger
+	sampleRatio float64
+	sample      func() float64
+}
+
+func newAccessLog(logger *slog.Logger, sampleRatio float64) *accessLog {
+	return &accessLog{
+		logger:      logger,
+		sampleRatio: sampleRatio,
+		sample:      mathRand.Float64,
+	}
+}
+
+func (l *accessLog) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		if status < http.StatusInternalServerError && l.sample() >= l.sampleRatio {
+			return
+		}
+
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Int("size", max(c.Writer.Size(), 0)),
+			slog.Duration("duration", time.Since(started)),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if err := c.Errors.Last(); err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		l.logger.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
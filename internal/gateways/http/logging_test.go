@@ -0,0 +1,140 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_RequestID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	srMock := usecase.NewMockSensorRepository(ctrl)
+	var ids []string
+	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(1))).DoAndReturn(func(ctx context.Context, _ int64) (*domain.Sensor, error) {
+		// идентификатор запроса передаётся сценариям и хранилищу в контексте
+		ids = append(ids, logging.RequestID(ctx))
+		return &domain.Sensor{ID: 1, SerialNumber: "0123456789", Type: domain.SensorTypeADC}, nil
+	}).Times(3)
+
+	s := NewServer(newServerUseCases(t, srMock))
+	serve := func(id string) string {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/sensors/1", nil)
+		if id != "" {
+			req.Header.Set(requestIDHeader, id)
+		}
+		s.router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Header().Get(requestIDHeader)
+	}
+
+	t.Run("ok, id is accepted", func(t *testing.T) {
+		assert.Equal(t, "client-id-1", serve("client-id-1"))
+	})
+
+	t.Run("ok, id is generated", func(t *testing.T) {
+		id := serve("")
+		assert.Len(t, id, 32)
+	})
+
+	t.Run("ok, invalid id is replaced", func(t *testing.T) {
+		id := serve("bad id\n")
+		assert.Len(t, id, 32)
+		assert.NotContains(t, id, " ")
+	})
+
+	require.Len(t, ids, 3)
+	assert.Equal(t, "client-id-1", ids[0])
+	assert.NotEqual(t, ids[1], ids[2])
+}
+
+func TestServer_AccessLog(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	srMock := usecase.NewMockSensorRepository(ctrl)
+	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(1))).Return(&domain.Sensor{ID: 1, SerialNumber: "0123456789", Type: domain.SensorTypeADC}, nil).AnyTimes()
+	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(2))).Return(nil, errors.New("connection refused")).AnyTimes()
+
+	newServer := func(sampleRatio float64) (*Server, *bytes.Buffer) {
+		var output bytes.Buffer
+		logger, err := logging.New(logging.Config{Level: "info", Format: logging.FormatJSON}, &output)
+		require.NoError(t, err)
+		return NewServer(newServerUseCases(t, srMock), WithAccessLog(logger, sampleRatio)), &output
+	}
+	serve := func(s *Server, target string) {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set(requestIDHeader, "req-1")
+		s.router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	records := func(output *bytes.Buffer) []map[string]any {
+		var result []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var record map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &record))
+			result = append(result, record)
+		}
+		return result
+	}
+
+	t.Run("ok, every request is logged", func(t *testing.T) {
+		s, output := newServer(1)
+		serve(s, "/sensors/1")
+
+		logged := records(output)
+		require.Len(t, logged, 1)
+		assert.Equal(t, "INFO", logged[0]["level"])
+		assert.Equal(t, "request", logged[0]["msg"])
+		assert.Equal(t, "/sensors/:sensor_id", logged[0]["route"])
+		assert.Equal(t, float64(http.StatusOK), logged[0]["status"])
+		assert.Equal(t, "req-1", logged[0][logging.RequestIDKey])
+	})
+
+	t.Run("ok, only server errors are logged without sampling", func(t *testing.T) {
+		s, output := newServer(0)
+		serve(s, "/sensors/1")
+		serve(s, "/sensors/2")
+
+		logged := records(output)
+		require.Len(t, logged, 1)
+		assert.Equal(t, "ERROR", logged[0]["level"])
+		assert.Equal(t, float64(http.StatusInternalServerError), logged[0]["status"])
+		assert.Contains(t, logged[0]["error"], "connection refused")
+	})
+
+	t.Run("ok, requests are sampled", func(t *testing.T) {
+		s, output := newServer(0.5)
+		samples := []float64{0.7, 0.2}
+		s.accessLog.sample = func() float64 {
+			v := samples[0]
+			samples = samples[1:]
+			return v
+		}
+		serve(s, "/sensors/1")
+		serve(s, "/sensors/1")
+
+		assert.Len(t, records(output), 1)
+	})
+}
+
+func TestValidRequestID(t *testing.T) {
+	assert.True(t, validRequestID("0f8fad5b-d9cb-469f-a165-70867728950e"))
+	assert.False(t, validRequestID(""))
+	assert.False(t, validRequestID("with space"))
+	assert.False(t, validRequestID("кириллица"))
+	assert.False(t, validRequestID(strings.Repeat("a", maxRequestIDLength+1)))
+	assert.NotEqual(t, newRequestID(), newRequestID())
+}
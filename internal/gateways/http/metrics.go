@@ -0,0 +1,124 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	appMetrics "homework/internal/metrics"
+)
+
+// unmatchedRoute - значение метки route для запросов, не попавших ни в один маршрут,
+// чтобы произвольные пути не порождали новые серии
+const unmatchedRoute = "unmatched"
+
+// Причины потери ws сообщений
+const (
+	// droppedCoalesced - событие схлопнуто с ещё не отправленным, подписчик получит только последнее
+	droppedCoalesced = "coalesced"
+	// droppedWriteFailed - сообщение не удалось записать в соединение
+	droppedWriteFailed = "write_failed"
+)
+
+// httpMetrics - метрики http запросов и ws подписок. Нулевой указатель ничего не учитывает.
+type httpMetrics struct {
+	registry *prometheus.Registry
+
+	requests      *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	wsConnections prometheus.Gauge
+	wsDropped     *prometheus.CounterVec
+}
+
+func newHTTPMetrics(reg *prometheus.Registry) *httpMetrics {
+	m := &httpMetrics{
+		registry: reg,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: appMetrics.Namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of handled HTTP requests.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: appMetrics.Namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		wsConnections: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: appMetrics.Namespace,
+			Name:      "websocket_connections",
+			Help:      "Number of open WebSocket connections.",
+		}),
+		wsDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: appMetrics.Namespace,
+			Name:      "websocket_dropped_messages_total",
+			Help:      "Number of event notifications not delivered to WebSocket subscribers.",
+		}, []string{"reason"}),
+	}
+	reg.MustRegister(m.requests, m.duration, m.wsConnections, m.wsDropped)
+
+	return m
+}
+
+// middleware - учитывает количество и длительность запросов по шаблону маршрута и коду ответа
+func (m *httpMetrics) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		labels := prometheus.Labels{
+			"method": c.Request.Method,
+			"route":  route,
+			"status": strconv.Itoa(c.Writer.Status()),
+		}
+		m.requests.With(labels).Inc()
+		m.duration.With(labels).Observe(time.Since(started).Seconds())
+	}
+}
+
+// handler - отдаёт метрики реестра в текстовом формате Prometheus
+func (m *httpMetrics) handler() gin.HandlerFunc {
+	return metricsHandler(m.registry)
+}
+
+func metricsHandler(gatherer prometheus.Gatherer) gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+}
+
+func (m *httpMetrics) wsConnected() {
+	if m != nil {
+		m.wsConnections.Inc()
+	}
+}
+
+func (m *httpMetrics) wsDisconnected() {
+	if m != nil {
+		m.wsConnections.Dec()
+	}
+}
+
+func (m *httpMetrics) wsMessageDropped(reason string) {
+	if m != nil {
+		m.wsDropped.WithLabelValues(reason).Inc()
+	}
+}
+
+func setupMetricsRoutes(r *gin.Engine, m *httpMetrics) {
+	r.GET("/metrics", m.handler())
+	r.HEAD("/metrics", m.handler())
+	r.OPTIONS("/metrics", allow(http.MethodGet, http.MethodHead, http.MethodOptions))
+}
+
+func setupSensorMetricsRoutes(r *gin.Engine, gatherer prometheus.Gatherer) {
+	r.GET("/metrics/sensors", metricsHandler(gatherer))
+	r.HEAD("/metrics/sensors", metricsHandler(gatherer))
+	r.OPTIONS("/metrics/sensors", allow(http.MethodGet, http.MethodHead, http.MethodOptions))
+}
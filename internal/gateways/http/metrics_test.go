@@ -0,0 +1,146 @@
+package http
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"nhooyr.io/websocket"
+
+	appMetrics "homework/internal/metrics"
+)
+
+func TestServer_Metrics(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	serverCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	ctrl := gomock.NewController(t)
+	erMock := usecase.NewMockEventRepository(ctrl)
+	erMock.EXPECT().GetLastEventBySensorID(gomock.Any(), gomock.Eq(int64(1))).Return(&domain.Event{SensorID: 1, Payload: 100}, nil).AnyTimes()
+	srMock := usecase.NewMockSensorRepository(ctrl)
+	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(1))).Return(&domain.Sensor{ID: 1, SerialNumber: "0123456789", Type: domain.SensorTypeADC}, nil).Times(2)
+	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(2))).Return(nil, usecase.ErrSensorNotFound).Times(1)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+
+	uc := newServerUseCases(t, srMock)
+	uc.Event = usecase.NewEvent(erMock, srMock)
+	s := NewServer(uc, WithMetrics(prometheus.NewPedanticRegistry()))
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Serve(serverCtx, l)
+	}()
+
+	get := func(path string) (int, string) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	status, _ := get("/sensors/1")
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = get("/sensors/2")
+	assert.Equal(t, http.StatusNotFound, status)
+	status, _ = get("/no/such/path")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	conn, _, err := websocket.Dial(ctx, "ws://"+addr+"/sensors/1/events", nil)
+	require.NoError(t, err)
+	defer conn.CloseNow()
+	// чтение в фоне отвечает на закрытие соединения сервером
+	conn.CloseRead(ctx)
+
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(s.metrics.wsConnections) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// первое сообщение ещё не отправлено, поэтому новые уведомления схлопываются с ним
+	s.ws.Notify(ctx, 1)
+	s.ws.Notify(ctx, 1)
+	assert.Equal(t, float64(2), testutil.ToFloat64(s.metrics.wsDropped.WithLabelValues(droppedCoalesced)))
+
+	status, body := get("/metrics")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `smarthome_http_requests_total{method="GET",route="/sensors/:sensor_id",status="200"} 1`)
+	assert.Contains(t, body, `smarthome_http_requests_total{method="GET",route="/sensors/:sensor_id",status="404"} 1`)
+	assert.Contains(t, body, `smarthome_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `smarthome_http_request_duration_seconds_count{method="GET",route="/sensors/:sensor_id",status="200"} 1`)
+	assert.Contains(t, body, `smarthome_websocket_connections 1`)
+
+	stop()
+	assert.NoError(t, <-done)
+	assert.Equal(t, float64(0), testutil.ToFloat64(s.metrics.wsConnections))
+}
+
+func TestServer_MetricsDisabled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	serverCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	addr, done := startServer(t, serverCtx, usecase.NewMockSensorRepository(gomock.NewController(t)))
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+"/metrics", nil)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	stop()
+	assert.NoError(t, <-done)
+}
+
+func TestServer_SensorMetrics(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	serverCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	ctrl := gomock.NewController(t)
+	srMock := usecase.NewMockSensorRepository(ctrl)
+	srMock.EXPECT().GetSensors(gomock.Any()).Return([]domain.Sensor{
+		{ID: 1, SerialNumber: "0123456789", Type: domain.SensorTypeADC, Description: "kitchen", IsActive: true, CurrentState: 215},
+	}, nil).Times(1)
+	urMock := usecase.NewMockUserRepository(ctrl)
+	urMock.EXPECT().GetUsers(gomock.Any()).Return([]domain.User{{ID: 1, Name: "Homer"}}, nil).Times(1)
+	sorMock := usecase.NewMockSensorOwnerRepository(ctrl)
+	sorMock.EXPECT().GetSensorOwners(gomock.Any()).Return([]domain.SensorOwner{{UserID: 1, SensorID: 1}}, nil).Times(1)
+
+	uc := newServerUseCases(t, srMock)
+	uc.User = usecase.NewUser(urMock, sorMock, srMock)
+	collector := appMetrics.NewSensorCollector(uc.Sensor, uc.User, time.Minute)
+	addr, done := startServer(t, serverCtx, srMock, WithSensorMetrics(collector))
+
+	for range 2 {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+"/metrics/sensors", nil)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, string(body), `smarthome_sensor_current_state{description="kitchen",id="1",owner="Homer",serial="0123456789",type="adc"} 215`)
+	}
+
+	stop()
+	assert.NoError(t, <-done)
+}
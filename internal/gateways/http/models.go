@@ -0,0 +1,436 @@
+package http
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"strconv"
+	"time"
+)
+
+// Модели запросов и ответов API, описаны в api/swagger.yaml
+
+// Problem - ошибка по RFC 7807
+type Problem struct {
+	Type     string           `json:"type"`
+	Title    string           `json:"title"`
+	Status   int              `json:"status"`
+	Detail   string           `json:"detail,omitempty"`
+	Instance string           `json:"instance,omitempty"`
+	Code     string           `json:"code"`
+	Errors   []FieldViolation `json:"errors,omitempty"`
+}
+
+type FieldViolation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type Health struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+type Version struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+	Modified  bool   `json:"modified"`
+}
+
+type User struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserToCreate struct {
+	Name string `json:"name"`
+}
+
+type Sensor struct {
+	XMLName      xml.Name      `json:"-" xml:"sensor"`
+	ID           int64         `json:"id" xml:"id"`
+	SerialNumber string        `json:"serial_number" xml:"serial_number"`
+	Type         string        `json:"type" xml:"type"`
+	CurrentState int64         `json:"current_state" xml:"current_state"`
+	CurrentValue *float64      `json:"current_value,omitempty" xml:"current_value,omitempty"`
+	Unit         string        `json:"unit,omitempty" xml:"unit,omitempty"`
+	Description  string        `json:"description" xml:"description"`
+	IsActive     bool          `json:"is_active" xml:"is_active"`
+	RegisteredAt time.Time     `json:"registered_at" xml:"registered_at"`
+	LastActivity time.Time     `json:"last_activity" xml:"last_activity"`
+	Calibration  *Calibration  `json:"calibration,omitempty" xml:"calibration,omitempty"`
+	PayloadRange *PayloadRange `json:"payload_range,omitempty" xml:"payload_range,omitempty"`
+}
+
+type SensorToCreate struct {
+	SerialNumber string        `json:"serial_number"`
+	Type         string        `json:"type"`
+	Description  string        `json:"description"`
+	IsActive     bool          `json:"is_active"`
+	Calibration  *Calibration  `json:"calibration,omitempty"`
+	PayloadRange *PayloadRange `json:"payload_range,omitempty"`
+}
+
+// SensorPatch - изменение датчика: отсутствующие поля не меняются, null сбрасывает калибровку и диапазон значений
+type SensorPatch struct {
+	Description  *string                `json:"description"`
+	Calibration  nullable[Calibration]  `json:"calibration"`
+	PayloadRange nullable[PayloadRange] `json:"payload_range"`
+}
+
+// nullable - поле тела запроса, для которого отсутствие отличается от null
+type nullable[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (n *nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	return json.Unmarshal(data, &n.Value)
+}
+
+type PayloadRange struct {
+	Min int64 `json:"min" xml:"min"`
+	Max int64 `json:"max" xml:"max"`
+}
+
+type Calibration struct {
+	Kind      string             `json:"kind" xml:"kind"`
+	Scale     float64            `json:"scale,omitempty" xml:"scale,omitempty"`
+	Offset    float64            `json:"offset,omitempty" xml:"offset,omitempty"`
+	Points    []CalibrationPoint `json:"points,omitempty" xml:"points>point,omitempty"`
+	Unit      string             `json:"unit,omitempty" xml:"unit,omitempty"`
+	Precision int                `json:"precision,omitempty" xml:"precision,omitempty"`
+}
+
+type CalibrationPoint struct {
+	Raw   int64   `json:"raw" xml:"raw"`
+	Value float64 `json:"value" xml:"value"`
+}
+
+type SensorToUserBinding struct {
+	SensorID int64 `json:"sensor_id"`
+}
+
+type SensorEvent struct {
+	SensorSerialNumber string `json:"sensor_serial_number" xml:"sensor_serial_number"`
+	Payload            int64  `json:"payload" xml:"payload"`
+}
+
+type QuarantinedEvent struct {
+	Timestamp          time.Time `json:"timestamp" xml:"timestamp"`
+	SensorSerialNumber string    `json:"sensor_serial_number" xml:"sensor_serial_number"`
+	SensorID           int64     `json:"sensor_id" xml:"sensor_id"`
+	Payload            int64     `json:"payload" xml:"payload"`
+	Reason             string    `json:"reason" xml:"reason"`
+	Details            string    `json:"details" xml:"details"`
+}
+
+type StateReport struct {
+	XMLName         xml.Name       `json:"-" xml:"state_report"`
+	SensorID        int64          `json:"sensor_id" xml:"sensor_id"`
+	From            time.Time      `json:"from" xml:"from"`
+	To              time.Time      `json:"to" xml:"to"`
+	States          []StateTotal   `json:"states" xml:"states>state"`
+	Transitions     int64          `json:"transitions" xml:"transitions"`
+	LongestInterval *StateInterval `json:"longest_interval,omitempty" xml:"longest_interval,omitempty"`
+}
+
+type StateTotal struct {
+	State           int64   `json:"state" xml:"state"`
+	DurationSeconds float64 `json:"duration_seconds" xml:"duration_seconds"`
+}
+
+type StateInterval struct {
+	State           int64     `json:"state" xml:"state"`
+	From            time.Time `json:"from" xml:"from"`
+	To              time.Time `json:"to" xml:"to"`
+	DurationSeconds float64   `json:"duration_seconds" xml:"duration_seconds"`
+}
+
+type HistoryPoint struct {
+	Timestamp time.Time `json:"timestamp" xml:"timestamp"`
+	Min       int64     `json:"min" xml:"min"`
+	Max       int64     `json:"max" xml:"max"`
+	Avg       float64   `json:"avg" xml:"avg"`
+	Count     int64     `json:"count" xml:"count"`
+	// Value - значение за интервал по типу датчика: среднее для adc, прирост для counter, иначе последнее
+	Value float64 `json:"value" xml:"value"`
+}
+
+type UnknownDevice struct {
+	SerialNumber   string    `json:"serial_number" xml:"serial_number"`
+	FirstSeen      time.Time `json:"first_seen" xml:"first_seen"`
+	LastSeen       time.Time `json:"last_seen" xml:"last_seen"`
+	EventCount     int64     `json:"event_count" xml:"event_count"`
+	SamplePayloads []int64   `json:"sample_payloads" xml:"sample_payloads>payload"`
+}
+
+// Sensors - список датчиков, в xml элемент sensors с элементами sensor
+type Sensors []Sensor
+
+func (s Sensors) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return marshalXMLList(e, "sensors", "sensor", s)
+}
+
+func (s Sensors) marshalCSV() [][]string {
+	records := [][]string{{
+		"id", "serial_number", "type", "current_state", "current_value", "unit", "description",
+		"is_active", "registered_at", "last_activity", "payload_range_min", "payload_range_max",
+	}}
+	for _, sensor := range s {
+		var currentValue, payloadMin, payloadMax string
+		if sensor.CurrentValue != nil {
+			currentValue = formatCSVFloat(*sensor.CurrentValue)
+		}
+		if sensor.PayloadRange != nil {
+			payloadMin = strconv.FormatInt(sensor.PayloadRange.Min, 10)
+			payloadMax = strconv.FormatInt(sensor.PayloadRange.Max, 10)
+		}
+		records = append(records, []string{
+			strconv.FormatInt(sensor.ID, 10),
+			sensor.SerialNumber,
+			sensor.Type,
+			strconv.FormatInt(sensor.CurrentState, 10),
+			currentValue,
+			sensor.Unit,
+			sensor.Description,
+			strconv.FormatBool(sensor.IsActive),
+			formatCSVTime(sensor.RegisteredAt),
+			formatCSVTime(sensor.LastActivity),
+			payloadMin,
+			payloadMax,
+		})
+	}
+
+	return records
+}
+
+// History - история значений датчика, в xml элемент history с элементами point
+type History []HistoryPoint
+
+func (h History) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return marshalXMLList(e, "history", "point", h)
+}
+
+func (h History) marshalCSV() [][]string {
+	records := [][]string{{"timestamp", "min", "max", "avg", "count", "value"}}
+	for _, point := range h {
+		records = append(records, []string{
+			formatCSVTime(point.Timestamp),
+			strconv.FormatInt(point.Min, 10),
+			strconv.FormatInt(point.Max, 10),
+			formatCSVFloat(point.Avg),
+			strconv.FormatInt(point.Count, 10),
+			formatCSVFloat(point.Value),
+		})
+	}
+
+	return records
+}
+
+// QuarantinedEvents - список отклонённых событий, в xml элемент events с элементами event
+type QuarantinedEvents []QuarantinedEvent
+
+func (q QuarantinedEvents) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return marshalXMLList(e, "events", "event", q)
+}
+
+// UnknownDevices - список незарегистрированных датчиков, в xml элемент devices с элементами device
+type UnknownDevices []UnknownDevice
+
+func (u UnknownDevices) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return marshalXMLList(e, "devices", "device", u)
+}
+
+// EventMessage - сообщение, отправляемое подписчикам ws датчика.
+// Value и Unit заполняются, если для датчика задана калибровка.
+type EventMessage struct {
+	domain.Event
+	Value *float64 `json:",omitempty"`
+	Unit  string   `json:",omitempty"`
+}
+
+func newUser(user *domain.User) User {
+	return User{
+		ID:   user.ID,
+		Name: user.Name,
+	}
+}
+
+func newSensor(sensor *domain.Sensor) Sensor {
+	s := Sensor{
+		ID:           sensor.ID,
+		SerialNumber: sensor.SerialNumber,
+		Type:         string(sensor.Type),
+		CurrentState: sensor.CurrentState,
+		Description:  sensor.Description,
+		IsActive:     sensor.IsActive,
+		RegisteredAt: sensor.RegisteredAt,
+		LastActivity: sensor.LastActivity,
+	}
+
+	if sensor.Calibration != nil {
+		value := sensor.Calibration.Convert(sensor.CurrentState)
+		s.CurrentValue = &value
+		s.Unit = sensor.Calibration.Unit
+		s.Calibration = newCalibration(sensor.Calibration)
+	}
+	if sensor.PayloadRange != nil {
+		s.PayloadRange = &PayloadRange{Min: sensor.PayloadRange.Min, Max: sensor.PayloadRange.Max}
+	}
+
+	return s
+}
+
+func newSensors(sensors []domain.Sensor) Sensors {
+	result := make(Sensors, 0, len(sensors))
+	for i := range sensors {
+		result = append(result, newSensor(&sensors[i]))
+	}
+
+	return result
+}
+
+func newCalibration(calibration *domain.Calibration) *Calibration {
+	c := &Calibration{
+		Kind:      string(calibration.Kind),
+		Scale:     calibration.Scale,
+		Offset:    calibration.Offset,
+		Unit:      calibration.Unit,
+		Precision: calibration.Precision,
+	}
+	for _, p := range calibration.Points {
+		c.Points = append(c.Points, CalibrationPoint(p))
+	}
+
+	return c
+}
+
+func newQuarantinedEvents(events []domain.QuarantinedEvent) QuarantinedEvents {
+	result := make(QuarantinedEvents, 0, len(events))
+	for _, event := range events {
+		result = append(result, QuarantinedEvent{
+			Timestamp:          event.Timestamp,
+			SensorSerialNumber: event.SensorSerialNumber,
+			SensorID:           event.SensorID,
+			Payload:            event.Payload,
+			Reason:             string(event.Reason),
+			Details:            event.Details,
+		})
+	}
+
+	return result
+}
+
+func newStateReport(report *domain.StateReport) StateReport {
+	r := StateReport{
+		SensorID:    report.SensorID,
+		From:        report.From,
+		To:          report.To,
+		States:      make([]StateTotal, 0, len(report.Durations)),
+		Transitions: report.Transitions,
+	}
+	for _, d := range report.Durations {
+		r.States = append(r.States, StateTotal{State: d.State, DurationSeconds: d.Duration.Seconds()})
+	}
+	if report.Longest != nil {
+		r.LongestInterval = &StateInterval{
+			State:           report.Longest.State,
+			From:            report.Longest.From,
+			To:              report.Longest.To,
+			DurationSeconds: report.Longest.Duration().Seconds(),
+		}
+	}
+
+	return r
+}
+
+func newHistory(history *domain.History) History {
+	result := make(History, 0, len(history.Points))
+	for _, rollup := range history.Points {
+		result = append(result, HistoryPoint{
+			Timestamp: rollup.Bucket,
+			Min:       rollup.Min,
+			Max:       rollup.Max,
+			Avg:       rollup.Average(),
+			Count:     rollup.Count,
+			Value:     rollup.Value(history.Aggregation),
+		})
+	}
+
+	return result
+}
+
+func newUnknownDevices(devices []domain.UnknownDevice) UnknownDevices {
+	result := make(UnknownDevices, 0, len(devices))
+	for _, device := range devices {
+		result = append(result, UnknownDevice(device))
+	}
+
+	return result
+}
+
+func newEventMessage(event *domain.Event, sensor *domain.Sensor) EventMessage {
+	msg := EventMessage{Event: *event}
+
+	if sensor.Calibration != nil {
+		value := sensor.Calibration.Convert(event.Payload)
+		msg.Value = &value
+		msg.Unit = sensor.Calibration.Unit
+	}
+
+	return msg
+}
+
+func (s SensorToCreate) toDomain() *domain.Sensor {
+	return &domain.Sensor{
+		SerialNumber: s.SerialNumber,
+		Type:         domain.SensorType(s.Type),
+		Description:  s.Description,
+		IsActive:     s.IsActive,
+		Calibration:  s.Calibration.toDomain(),
+		PayloadRange: s.PayloadRange.toDomain(),
+	}
+}
+
+func (p SensorPatch) toUpdate() usecase.SensorUpdate {
+	return usecase.SensorUpdate{
+		Description:     p.Description,
+		SetCalibration:  p.Calibration.Set,
+		Calibration:     p.Calibration.Value.toDomain(),
+		SetPayloadRange: p.PayloadRange.Set,
+		PayloadRange:    p.PayloadRange.Value.toDomain(),
+	}
+}
+
+func (r *PayloadRange) toDomain() *domain.PayloadRange {
+	if r == nil {
+		return nil
+	}
+
+	return &domain.PayloadRange{Min: r.Min, Max: r.Max}
+}
+
+func (c *Calibration) toDomain() *domain.Calibration {
+	if c == nil {
+		return nil
+	}
+
+	calibration := &domain.Calibration{
+		Kind:      domain.CalibrationKind(c.Kind),
+		Scale:     c.Scale,
+		Offset:    c.Offset,
+		Unit:      c.Unit,
+		Precision: c.Precision,
+	}
+	for _, p := range c.Points {
+		calibration.Points = append(calibration.Points, domain.CalibrationPoint(p))
+	}
+
+	return calibration
+}
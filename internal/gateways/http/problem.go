@@ -0,0 +1,163 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"homework/internal/usecase"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// mimeProblemJSON - формат ошибки по RFC 7807
+	mimeProblemJSON = "application/problem+json"
+	// problemTypePrefix - префикс type ошибки, за ним следует её code
+	problemTypePrefix = "urn:smarthome:problem:"
+)
+
+// problemKind - вид ошибки: код ответа, стабильный код для клиентов
+// и поле запроса, к которому по умолчанию относится ошибка валидации
+type problemKind struct {
+	err    error
+	status int
+	code   string
+	field  string
+}
+
+// problemKinds - соответствие ошибок сценариев использования и шлюза кодам ответа.
+// Код ошибки - часть API, его нельзя менять после выпуска.
+var problemKinds = []problemKind{
+	{err: usecase.ErrWrongSensorSerialNumber, status: http.StatusUnprocessableEntity, code: "wrong_sensor_serial_number", field: "serial_number"},
+	{err: usecase.ErrWrongSensorType, status: http.StatusUnprocessableEntity, code: "wrong_sensor_type", field: "type"},
+	{err: usecase.ErrInvalidEventTimestamp, status: http.StatusUnprocessableEntity, code: "invalid_event_timestamp", field: "timestamp"},
+	{err: usecase.ErrInvalidEventPayload, status: http.StatusUnprocessableEntity, code: "invalid_event_payload", field: "payload"},
+	{err: usecase.ErrInvalidUserName, status: http.StatusUnprocessableEntity, code: "invalid_user_name", field: "name"},
+	{err: usecase.ErrInvalidCalibration, status: http.StatusUnprocessableEntity, code: "invalid_calibration", field: "calibration"},
+	{err: usecase.ErrInvalidPayloadRange, status: http.StatusUnprocessableEntity, code: "invalid_payload_range", field: "payload_range"},
+	{err: usecase.ErrInvalidTimeRange, status: http.StatusUnprocessableEntity, code: "invalid_time_range", field: "from"},
+	{err: ErrInvalidID, status: http.StatusUnprocessableEntity, code: "invalid_id", field: "id"},
+	{err: usecase.ErrSensorNotFound, status: http.StatusNotFound, code: "sensor_not_found"},
+	{err: usecase.ErrUserNotFound, status: http.StatusNotFound, code: "user_not_found"},
+	{err: usecase.ErrEventNotFound, status: http.StatusNotFound, code: "event_not_found"},
+	{err: ErrInvalidBody, status: http.StatusBadRequest, code: "invalid_body"},
+	{err: ErrUnsupportedMediaType, status: http.StatusUnsupportedMediaType, code: "unsupported_media_type"},
+	{err: ErrNotAcceptable, status: http.StatusNotAcceptable, code: "not_acceptable"},
+	{err: ErrPreconditionFailed, status: http.StatusPreconditionFailed, code: "precondition_failed"},
+	{err: usecase.ErrRateLimited, status: http.StatusTooManyRequests, code: "rate_limited"},
+	{err: ErrWebSocketShutdown, status: http.StatusServiceUnavailable, code: "websocket_shutdown"},
+	{err: usecase.ErrIngestionQueueFull, status: http.StatusServiceUnavailable, code: "ingestion_queue_full"},
+	{err: usecase.ErrIngestionStopped, status: http.StatusServiceUnavailable, code: "ingestion_stopped"},
+	{err: ErrServerBusy, status: http.StatusServiceUnavailable, code: "server_busy"},
+}
+
+// internalProblem - вид ошибок, которых нет в problemKinds
+var internalProblem = problemKind{
+	err:    errors.New("internal server error"),
+	status: http.StatusInternalServerError,
+	code:   "internal_error",
+}
+
+// problemKindOf - вид ошибки err
+func problemKindOf(err error) problemKind {
+	for _, kind := range problemKinds {
+		if errors.Is(err, kind.err) {
+			return kind
+		}
+	}
+
+	return internalProblem
+}
+
+// fieldError - ошибка значения поля тела, пути или параметра запроса.
+// Переопределяет поле, к которому по умолчанию относится ошибка в problemKinds.
+type fieldError struct {
+	field string
+	err   error
+}
+
+func invalidField(field string, err error) error {
+	return &fieldError{field: field, err: err}
+}
+
+func (e *fieldError) Error() string {
+	return e.field + ": " + e.err.Error()
+}
+
+func (e *fieldError) Unwrap() error {
+	return e.err
+}
+
+// newProblem - ответ на запрос c с ошибкой err. Подробности внутренних ошибок клиенту не отдаются,
+// они есть в журнале запросов.
+func newProblem(c *gin.Context, err error) Problem {
+	kind := problemKindOf(err)
+	problem := Problem{
+		Type:     problemTypePrefix + kind.code,
+		Title:    kind.err.Error(),
+		Status:   kind.status,
+		Code:     kind.code,
+		Instance: c.Request.URL.Path,
+	}
+	if kind.status != http.StatusInternalServerError {
+		problem.Detail = err.Error()
+	}
+	if kind.status == http.StatusUnprocessableEntity {
+		problem.Errors = []FieldViolation{newFieldViolation(err, kind)}
+	}
+
+	return problem
+}
+
+func newFieldViolation(err error, kind problemKind) FieldViolation {
+	violation := FieldViolation{Field: kind.field, Code: kind.code, Message: err.Error()}
+
+	var fieldErr *fieldError
+	if errors.As(err, &fieldErr) {
+		violation.Field = fieldErr.field
+		violation.Message = fieldErr.err.Error()
+	}
+
+	return violation
+}
+
+// writeError - функция записи ошибки в формате application/problem+json,
+// для HEAD запроса отдаются только заголовки
+func writeError(c *gin.Context, err error) {
+	// ошибка попадает в журнал запросов
+	_ = c.Error(err)
+
+	problem := newProblem(c, err)
+	if delay, ok := retryAfterDelay(err); ok {
+		c.Header("Retry-After", strconv.Itoa(int((delay+time.Second-1)/time.Second)))
+	}
+	if c.Request.Method == http.MethodHead {
+		c.Header("Content-Type", mimeProblemJSON)
+		c.Status(problem.Status)
+		return
+	}
+
+	body, err := json.Marshal(problem)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Data(problem.Status, mimeProblemJSON, body)
+}
+
+// retryAfterDelay - через сколько повторить запрос, отклонённый с ошибкой err, если его стоит повторять
+func retryAfterDelay(err error) (time.Duration, bool) {
+	var rateLimitErr *usecase.RateLimitError
+	switch {
+	case errors.As(err, &rateLimitErr):
+		return max(rateLimitErr.RetryAfter, time.Second), true
+	case errors.Is(err, usecase.ErrIngestionQueueFull),
+		errors.Is(err, ErrServerBusy):
+		return retryAfter, true
+	default:
+		return 0, false
+	}
+}
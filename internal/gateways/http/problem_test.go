@@ -0,0 +1,125 @@
+package http
+
+import (
+	"errors"
+	"homework/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblemKinds(t *testing.T) {
+	codes := make(map[string]bool, len(problemKinds))
+	for _, kind := range problemKinds {
+		assert.False(t, codes[kind.code], "duplicate code %s", kind.code)
+		codes[kind.code] = true
+		assert.Equal(t, kind.status == http.StatusUnprocessableEntity, kind.field != "", "field of %s", kind.code)
+	}
+
+	assert.Equal(t, http.StatusNotFound, problemKindOf(errors.Join(errors.New("oops"), usecase.ErrSensorNotFound)).status)
+	assert.Equal(t, "rate_limited", problemKindOf(&usecase.RateLimitError{RetryAfter: time.Second}).code)
+	assert.Equal(t, internalProblem, problemKindOf(errors.New("oops")))
+}
+
+func TestServer_Problem(t *testing.T) {
+	serve := func(s *Server, method, target, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("ok, not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		srMock := usecase.NewMockSensorRepository(ctrl)
+		srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(1))).Return(nil, usecase.ErrSensorNotFound).Times(1)
+
+		w := serve(NewServer(newServerUseCases(t, srMock)), http.MethodGet, "/sensors/1", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, mimeProblemJSON, w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{
+			"type": "urn:smarthome:problem:sensor_not_found",
+			"title": "sensor not found",
+			"status": 404,
+			"detail": "can't get sensor by id: sensor not found",
+			"instance": "/sensors/1",
+			"code": "sensor_not_found"
+		}`, w.Body.String())
+	})
+
+	t.Run("ok, invalid path parameter", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		w := serve(NewServer(newServerUseCases(t, usecase.NewMockSensorRepository(ctrl))), http.MethodGet, "/sensors/abc", "")
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.JSONEq(t, `{
+			"type": "urn:smarthome:problem:invalid_id",
+			"title": "invalid id",
+			"status": 422,
+			"detail": "sensor_id: invalid id",
+			"instance": "/sensors/abc",
+			"code": "invalid_id",
+			"errors": [{"field": "sensor_id", "code": "invalid_id", "message": "invalid id"}]
+		}`, w.Body.String())
+	})
+
+	t.Run("ok, invalid body field", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		w := serve(NewServer(newServerUseCases(t, usecase.NewMockSensorRepository(ctrl))), http.MethodPost, "/events",
+			`{"sensor_serial_number": "123", "payload": 1}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(),
+			`"errors":[{"field":"sensor_serial_number","code":"wrong_sensor_serial_number","message":"wrong sensor serial number"}]`)
+	})
+
+	t.Run("ok, usecase validation error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		srMock := usecase.NewMockSensorRepository(ctrl)
+
+		w := serve(NewServer(newServerUseCases(t, srMock)), http.MethodPost, "/sensors",
+			`{"serial_number": "0123456789", "type": "adc", "description": "", "is_active": true, "payload_range": {"min": 2, "max": 1}}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(),
+			`"errors":[{"field":"payload_range","code":"invalid_payload_range","message":"invalid sensor payload range: min 2 is greater than max 1"}]`)
+	})
+
+	t.Run("ok, internal error details are hidden", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		srMock := usecase.NewMockSensorRepository(ctrl)
+		srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(1))).Return(nil, errors.New("connection refused")).Times(1)
+
+		w := serve(NewServer(newServerUseCases(t, srMock)), http.MethodGet, "/sensors/1", "")
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.JSONEq(t, `{
+			"type": "urn:smarthome:problem:internal_error",
+			"title": "internal server error",
+			"status": 500,
+			"instance": "/sensors/1",
+			"code": "internal_error"
+		}`, w.Body.String())
+	})
+
+	t.Run("ok, head without body", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		srMock := usecase.NewMockSensorRepository(ctrl)
+		srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(1))).Return(nil, usecase.ErrSensorNotFound).Times(1)
+
+		w := serve(NewServer(newServerUseCases(t, srMock)), http.MethodHead, "/sensors/1", "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, mimeProblemJSON, w.Header().Get("Content-Type"))
+		assert.Empty(t, w.Body.String())
+	})
+}
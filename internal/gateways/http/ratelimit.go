@@ -0,0 +1,49 @@
+package http
+
+import (
+	"homework/internal/ratelimit"
+	"homework/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clientRateLimit - ограничение частоты запросов с одного адреса клиента,
+// сверх ограничения запрос отклоняется с usecase.RateLimitError
+func clientRateLimit(limiter *ratelimit.Limiter, limit ratelimit.Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ok, delay := limiter.Allow(c.ClientIP(), limit); !ok {
+			writeError(c, &usecase.RateLimitError{RetryAfter: delay})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// concurrencyLimit - ограничение количества одновременно обрабатываемых запросов,
+// сверх ограничения запрос сразу отклоняется с ErrServerBusy.
+// ws подписки открыты долго и не учитываются, их количество видно в метриках.
+func concurrencyLimit(limit int) gin.HandlerFunc {
+	slots := make(chan struct{}, limit)
+
+	return func(c *gin.Context) {
+		if c.IsWebsocket() {
+			c.Next()
+			return
+		}
+
+		select {
+		case slots <- struct{}{}:
+		default:
+			writeError(c, ErrServerBusy)
+			c.Abort()
+			return
+		}
+		defer func() {
+			<-slots
+		}()
+
+		c.Next()
+	}
+}
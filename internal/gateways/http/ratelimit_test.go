@@ -0,0 +1,142 @@
+package http
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/ratelimit"
+	"homework/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newEventsUseCases - сценарии, принимающие любые события датчика 0123456789
+func newEventsUseCases(t *testing.T, options ...func(*usecase.Event)) UseCases {
+	ctrl := gomock.NewController(t)
+	srMock := usecase.NewMockSensorRepository(ctrl)
+	srMock.EXPECT().GetSensorBySerialNumber(gomock.Any(), "0123456789").Return(&domain.Sensor{
+		ID:           1,
+		SerialNumber: "0123456789",
+		Type:         domain.SensorTypeADC,
+	}, nil).AnyTimes()
+	srMock.EXPECT().UpdateSensorState(gomock.Any(), int64(1), gomock.Any()).Return(nil, nil).AnyTimes()
+	erMock := usecase.NewMockEventRepository(ctrl)
+	erMock.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	uc := newServerUseCases(t, srMock)
+	uc.Event = usecase.NewEvent(erMock, srMock, options...)
+
+	return uc
+}
+
+func postEvent(s *Server, remoteAddr string, forwardedFor ...string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"sensor_serial_number": "0123456789", "payload": 1}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = remoteAddr
+	for _, v := range forwardedFor {
+		req.Header.Add("X-Forwarded-For", v)
+	}
+	s.router.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestServer_ClientRateLimit(t *testing.T) {
+	s := NewServer(newEventsUseCases(t), WithClientRateLimit(ratelimit.Limit{Rate: 0.5, Burst: 2}))
+
+	assert.Equal(t, http.StatusCreated, postEvent(s, "192.0.2.1:1000").Code)
+	assert.Equal(t, http.StatusCreated, postEvent(s, "192.0.2.1:1001").Code)
+
+	w := postEvent(s, "192.0.2.1:1002")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), usecase.ErrRateLimited.Error())
+
+	// у другого клиента своё ограничение
+	assert.Equal(t, http.StatusCreated, postEvent(s, "192.0.2.2:1000").Code)
+}
+
+func TestServer_ClientRateLimitForwardedFor(t *testing.T) {
+	limit := WithClientRateLimit(ratelimit.Limit{Rate: 0.5, Burst: 1})
+
+	t.Run("ok, untrusted forwarded for is ignored", func(t *testing.T) {
+		s := NewServer(newEventsUseCases(t), limit)
+
+		assert.Equal(t, http.StatusCreated, postEvent(s, "192.0.2.1:1000", "198.51.100.1").Code)
+		assert.Equal(t, http.StatusTooManyRequests, postEvent(s, "192.0.2.1:1000", "198.51.100.2").Code)
+	})
+
+	t.Run("ok, trusted proxy", func(t *testing.T) {
+		s := NewServer(newEventsUseCases(t), limit, WithTrustedProxies([]string{"192.0.2.0/24"}))
+
+		assert.Equal(t, http.StatusCreated, postEvent(s, "192.0.2.1:1000", "198.51.100.1").Code)
+		assert.Equal(t, http.StatusCreated, postEvent(s, "192.0.2.1:1000", "198.51.100.2").Code)
+		assert.Equal(t, http.StatusTooManyRequests, postEvent(s, "192.0.2.2:1000", "198.51.100.1").Code)
+	})
+}
+
+func TestServer_SensorRateLimit(t *testing.T) {
+	limiter := ratelimit.NewSensorLimiter(ratelimit.Limit{Rate: 1, Burst: 1}, nil)
+	s := NewServer(newEventsUseCases(t, usecase.WithRateLimiter(limiter)))
+
+	assert.Equal(t, http.StatusCreated, postEvent(s, "192.0.2.1:1000").Code)
+
+	// ограничение датчика действует для всех клиентов
+	w := postEvent(s, "192.0.2.2:1000")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+}
+
+func TestServer_MaxConcurrentRequests(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	ctrl := gomock.NewController(t)
+	srMock := usecase.NewMockSensorRepository(ctrl)
+	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(1))).DoAndReturn(func(context.Context, int64) (*domain.Sensor, error) {
+		close(started)
+		<-release
+		return &domain.Sensor{ID: 1, SerialNumber: "0123456789", Type: domain.SensorTypeADC}, nil
+	}).Times(1)
+
+	s := NewServer(newServerUseCases(t, srMock), WithMaxConcurrentRequests(1))
+	serve := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	done := make(chan int, 1)
+	go func() {
+		done <- serve("/sensors/1").Code
+	}()
+	select {
+	case <-started:
+	case <-ctx.Done():
+		t.Fatal("request was not started")
+	}
+
+	w := serve("/sensors/2")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, serve("/healthz").Code)
+
+	close(release)
+	select {
+	case code := <-done:
+		require.Equal(t, http.StatusOK, code)
+	case <-ctx.Done():
+		t.Fatal("request was not finished")
+	}
+
+	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(2))).Return(nil, usecase.ErrSensorNotFound).Times(1)
+	assert.Equal(t, http.StatusNotFound, serve("/sensors/2").Code)
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"homework/internal/usecase"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	mimeJSON = "application/json"
+	// defaultReportPeriod - период отчёта, если в запросе не указано начало
+	defaultReportPeriod = 24 * time.Hour
+	// defaultHistoryStep - длина интервала истории, если в запросе не указан step
+	defaultHistoryStep = time.Hour
+	// retryAfter - через сколько клиенту повторить запрос, не принятый из-за заполненной очереди или нагрузки
+	retryAfter = time.Second
+)
+
+var (
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	ErrNotAcceptable        = errors.New("requested unsupported body format")
+	ErrInvalidID            = errors.New("invalid id")
+	ErrInvalidBody          = errors.New("invalid request body")
+	ErrServerBusy           = errors.New("too many concurrent requests")
+	ErrPreconditionFailed   = usecase.ErrSensorVersionConflict
+)
+
+// writeJSON - функция записи ответа в json, для HEAD запроса отдаются только заголовки
+func writeJSON(c *gin.Context, status int, v any) {
+	writeResponse(c, status, mimeJSON, v)
+}
+
+// readJSON - функция чтения тела запроса в формате json
+func readJSON(c *gin.Context, v any) error {
+	return readBody(c, v, mimeJSON)
+}
+
+func parseID(c *gin.Context, name string) (int64, error) {
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil || id < 1 {
+		return 0, invalidField(name, ErrInvalidID)
+	}
+
+	return id, nil
+}
+
+// parseTimeRange - функция чтения периода из параметров from и to в формате RFC 3339.
+// По умолчанию to - текущее время, from - за defaultReportPeriod до to.
+func parseTimeRange(c *gin.Context) (from, to time.Time, err error) {
+	to = time.Now()
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, invalidField("to", fmt.Errorf("%w: can't parse to: %w", usecase.ErrInvalidTimeRange, err))
+		}
+	}
+
+	from = to.Add(-defaultReportPeriod)
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, invalidField("from", fmt.Errorf("%w: can't parse from: %w", usecase.ErrInvalidTimeRange, err))
+		}
+	}
+
+	return from, to, nil
+}
+
+// parseStep - функция чтения длины интервала истории из параметра step, например 15m или 24h
+func parseStep(c *gin.Context) (time.Duration, error) {
+	v := c.Query("step")
+	if v == "" {
+		return defaultHistoryStep, nil
+	}
+
+	step, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, invalidField("step", fmt.Errorf("%w: can't parse step: %w", usecase.ErrInvalidTimeRange, err))
+	}
+
+	return step, nil
+}
+
+func allow(methods ...string) gin.HandlerFunc {
+	allowed := strings.Join(methods, ",")
+
+	return func(c *gin.Context) {
+		c.Header("Allow", allowed)
+		c.Status(http.StatusNoContent)
+	}
+}
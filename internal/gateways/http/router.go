@@ -1,9 +1,64 @@
 package http
 
-import "github.com/gin-gonic/gin"
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupRouter - регистрирует маршруты API, eventsMiddleware выполняются перед приёмом события
+func setupRouter(r *gin.Engine, uc UseCases, ws *WebSocketHandler, eventsMiddleware ...gin.HandlerFunc) {
+	r.HandleMethodNotAllowed = true
+	uc.Event.OnFlush(ws.Notify)
 
-func setupRouter(r *gin.Engine, _ UseCases, _ *WebSocketHandler) {
 	r.GET("/ping", func(c *gin.Context) {
 		c.String(200, "pong")
 	})
+
+	r.POST("/users", registerUser(uc))
+	r.OPTIONS("/users", allow(http.MethodPost, http.MethodOptions))
+
+	r.GET("/users/:user_id/sensors", getUserSensors(uc))
+	r.HEAD("/users/:user_id/sensors", getUserSensors(uc))
+	r.POST("/users/:user_id/sensors", attachSensorToUser(uc))
+	r.OPTIONS("/users/:user_id/sensors", allow(http.MethodGet, http.MethodHead, http.MethodPost, http.MethodOptions))
+
+	r.GET("/sensors", getSensors(uc))
+	r.HEAD("/sensors", getSensors(uc))
+	r.POST("/sensors", registerSensor(uc))
+	r.OPTIONS("/sensors", allow(http.MethodGet, http.MethodHead, http.MethodPost, http.MethodOptions))
+
+	r.GET("/sensors/:sensor_id", getSensor(uc))
+	r.HEAD("/sensors/:sensor_id", getSensor(uc))
+	r.PATCH("/sensors/:sensor_id", updateSensor(uc))
+	r.OPTIONS("/sensors/:sensor_id", allow(http.MethodGet, http.MethodHead, http.MethodPatch, http.MethodOptions))
+
+	r.PUT("/sensors/:sensor_id/calibration", setSensorCalibration(uc))
+	r.DELETE("/sensors/:sensor_id/calibration", resetSensorCalibration(uc))
+	r.OPTIONS("/sensors/:sensor_id/calibration", allow(http.MethodPut, http.MethodDelete, http.MethodOptions))
+
+	r.PUT("/sensors/:sensor_id/payload-range", setSensorPayloadRange(uc))
+	r.DELETE("/sensors/:sensor_id/payload-range", resetSensorPayloadRange(uc))
+	r.OPTIONS("/sensors/:sensor_id/payload-range", allow(http.MethodPut, http.MethodDelete, http.MethodOptions))
+
+	r.GET("/sensors/:sensor_id/reports/states", getSensorStateReport(uc))
+	r.HEAD("/sensors/:sensor_id/reports/states", getSensorStateReport(uc))
+	r.OPTIONS("/sensors/:sensor_id/reports/states", allow(http.MethodGet, http.MethodHead, http.MethodOptions))
+
+	r.GET("/sensors/:sensor_id/history", getSensorHistory(uc))
+	r.HEAD("/sensors/:sensor_id/history", getSensorHistory(uc))
+	r.OPTIONS("/sensors/:sensor_id/history", allow(http.MethodGet, http.MethodHead, http.MethodOptions))
+
+	r.GET("/sensors/:sensor_id/events", subscribeSensorEvents(ws))
+
+	r.POST("/events", append(eventsMiddleware, receiveEvent(uc, ws))...)
+	r.OPTIONS("/events", allow(http.MethodPost, http.MethodOptions))
+
+	r.GET("/events/quarantine", getQuarantinedEvents(uc))
+	r.HEAD("/events/quarantine", getQuarantinedEvents(uc))
+	r.OPTIONS("/events/quarantine", allow(http.MethodGet, http.MethodHead, http.MethodOptions))
+
+	r.GET("/unknown-devices", getUnknownDevices(uc))
+	r.HEAD("/unknown-devices", getUnknownDevices(uc))
+	r.OPTIONS("/unknown-devices", allow(http.MethodGet, http.MethodHead, http.MethodOptions))
 }
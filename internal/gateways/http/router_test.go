@@ -191,7 +191,7 @@ func TestSensorsRoutes(t *testing.T) {
 			w := httptest.NewRecorder()
 
 			req, _ := http.NewRequest(http.MethodGet, "/sensors", nil)
-			req.Header.Add("Accept", "application/xml")
+			req.Header.Add("Accept", "text/html")
 			router.ServeHTTP(w, req)
 
 			assert.Equal(t, http.StatusNotAcceptable, w.Code, "Получили в ответ не тот код")
@@ -214,7 +214,7 @@ func TestSensorsRoutes(t *testing.T) {
 			w := httptest.NewRecorder()
 
 			req, _ := http.NewRequest(http.MethodHead, "/sensors", nil)
-			req.Header.Add("Accept", "application/xml")
+			req.Header.Add("Accept", "text/html")
 			router.ServeHTTP(w, req)
 
 			assert.Equal(t, http.StatusNotAcceptable, w.Code, "Получили в ответ не тот код")
@@ -348,7 +348,7 @@ func TestSensorsRoutes(t *testing.T) {
 			w := httptest.NewRecorder()
 
 			req, _ := http.NewRequest(http.MethodGet, "/sensors/1", nil)
-			req.Header.Add("Accept", "application/xml")
+			req.Header.Add("Accept", "text/html")
 			router.ServeHTTP(w, req)
 
 			assert.Equal(t, http.StatusNotAcceptable, w.Code, "Получили в ответ не тот код")
@@ -391,7 +391,7 @@ func TestSensorsRoutes(t *testing.T) {
 			w := httptest.NewRecorder()
 
 			req, _ := http.NewRequest(http.MethodHead, "/sensors/1", nil)
-			req.Header.Add("Accept", "application/xml")
+			req.Header.Add("Accept", "text/html")
 			router.ServeHTTP(w, req)
 
 			assert.Equal(t, http.StatusNotAcceptable, w.Code, "Получили в ответ не тот код")
@@ -418,6 +418,7 @@ func TestSensorsRoutes(t *testing.T) {
 		assert.Contains(t, allowed, http.MethodOptions, "В разрешённых методах нет OPTIONS")
 		assert.Contains(t, allowed, http.MethodGet, "В разрешённых методах нет GET")
 		assert.Contains(t, allowed, http.MethodHead, "В разрешённых методах нет HEAD")
+		assert.Contains(t, allowed, http.MethodPatch, "В разрешённых методах нет PATCH")
 	})
 
 	// Другие методы не поддерживаем.
@@ -430,7 +431,6 @@ func TestSensorsRoutes(t *testing.T) {
 			{http.MethodPost, http.MethodPost, http.StatusMethodNotAllowed},
 			{http.MethodPut, http.MethodPut, http.StatusMethodNotAllowed},
 			{http.MethodDelete, http.MethodDelete, http.StatusMethodNotAllowed},
-			{http.MethodPatch, http.MethodPatch, http.StatusMethodNotAllowed},
 			{http.MethodConnect, http.MethodConnect, http.StatusMethodNotAllowed},
 			{http.MethodTrace, http.MethodTrace, http.StatusMethodNotAllowed},
 		}
@@ -477,7 +477,7 @@ func TestUsersSensorsRoutes(t *testing.T) {
 			w := httptest.NewRecorder()
 
 			req, _ := http.NewRequest(http.MethodGet, "/users/1/sensors", nil)
-			req.Header.Add("Accept", "application/xml")
+			req.Header.Add("Accept", "text/html")
 			router.ServeHTTP(w, req)
 
 			assert.Equal(t, http.StatusNotAcceptable, w.Code, "Получили в ответ не тот код")
@@ -520,7 +520,7 @@ func TestUsersSensorsRoutes(t *testing.T) {
 			w := httptest.NewRecorder()
 
 			req, _ := http.NewRequest(http.MethodHead, "/users/1/sensors", nil)
-			req.Header.Add("Accept", "application/xml")
+			req.Header.Add("Accept", "text/html")
 			router.ServeHTTP(w, req)
 
 			assert.Equal(t, http.StatusNotAcceptable, w.Code, "Получили в ответ не тот код")
@@ -653,7 +653,7 @@ func TestEventsRoutes(t *testing.T) {
 
 			body := `{
 				"sensor_serial_number": "1234567890",
-				"payload": 10
+				"payload": 1
 			}`
 			req, _ := http.NewRequest(http.MethodPost, "/events", bytes.NewReader([]byte(body)))
 			req.Header.Add("Content-Type", "application/json")
@@ -665,12 +665,9 @@ func TestEventsRoutes(t *testing.T) {
 		t.Run("request_body_has_unsupported_format_415", func(t *testing.T) {
 			w := httptest.NewRecorder()
 
-			body := `<SensorEvent>
-				<SensorSerialNumber>1234567890</SensorSerialNumber>
-				<Payload>10</Payload>
-			</SensorEvent>`
+			body := `sensor_serial_number=1234567890&payload=10`
 			req, _ := http.NewRequest(http.MethodPost, "/events", bytes.NewReader([]byte(body)))
-			req.Header.Add("Content-Type", "application/xml")
+			req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 			router.ServeHTTP(w, req)
 
 			assert.Equal(t, http.StatusUnsupportedMediaType, w.Code, "Получили в ответ не тот код")
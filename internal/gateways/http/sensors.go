@@ -0,0 +1,302 @@
+package http
+
+import (
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func getSensors(uc UseCases) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format, err := negotiate(c, listFormats...)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		sensors, err := uc.Sensor.GetSensors(c.Request.Context())
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		if notModified(c, sensorsETag(format, sensors...), sensorsLastModified(sensors...)) {
+			return
+		}
+
+		writeResponse(c, http.StatusOK, format, newSensors(sensors))
+	}
+}
+
+func getUnknownDevices(uc UseCases) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format, err := negotiate(c, responseFormats...)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		devices, err := uc.Sensor.GetUnknownDevices(c.Request.Context())
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		writeResponse(c, http.StatusOK, format, newUnknownDevices(devices))
+	}
+}
+
+func registerSensor(uc UseCases) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body SensorToCreate
+		if err := readJSON(c, &body); err != nil {
+			writeError(c, err)
+			return
+		}
+
+		sensor, err := uc.Sensor.RegisterSensor(c.Request.Context(), body.toDomain())
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		writeJSON(c, http.StatusOK, newSensor(sensor))
+	}
+}
+
+func getSensor(uc UseCases) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format, err := negotiate(c, responseFormats...)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		sensorID, err := parseID(c, "sensor_id")
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		sensor, err := uc.Sensor.GetSensorByID(c.Request.Context(), sensorID)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		if notModified(c, sensorETag(format, sensor), sensorsLastModified(*sensor)) {
+			return
+		}
+
+		writeResponse(c, http.StatusOK, format, newSensor(sensor))
+	}
+}
+
+func updateSensor(uc UseCases) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		change, err := parseSensorChange(c)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		var body SensorPatch
+		if err := readJSON(c, &body); err != nil {
+			writeError(c, err)
+			return
+		}
+
+		sensor, err := uc.Sensor.UpdateSensor(c.Request.Context(), change.sensorID, body.toUpdate(), change.version)
+		writeSensorChange(c, change.format, sensor, err)
+	}
+}
+
+func setSensorCalibration(uc UseCases) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		change, err := parseSensorChange(c)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		var body Calibration
+		if err := readJSON(c, &body); err != nil {
+			writeError(c, err)
+			return
+		}
+
+		sensor, err := uc.Sensor.SetCalibration(c.Request.Context(), change.sensorID, body.toDomain(), change.version)
+		writeSensorChange(c, change.format, sensor, err)
+	}
+}
+
+func resetSensorCalibration(uc UseCases) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		change, err := parseSensorChange(c)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		sensor, err := uc.Sensor.SetCalibration(c.Request.Context(), change.sensorID, nil, change.version)
+		writeSensorChange(c, change.format, sensor, err)
+	}
+}
+
+func setSensorPayloadRange(uc UseCases) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		change, err := parseSensorChange(c)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		var body PayloadRange
+		if err := readJSON(c, &body); err != nil {
+			writeError(c, err)
+			return
+		}
+
+		sensor, err := uc.Sensor.SetPayloadRange(c.Request.Context(), change.sensorID, body.toDomain(), change.version)
+		writeSensorChange(c, change.format, sensor, err)
+	}
+}
+
+func resetSensorPayloadRange(uc UseCases) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		change, err := parseSensorChange(c)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		sensor, err := uc.Sensor.SetPayloadRange(c.Request.Context(), change.sensorID, nil, change.version)
+		writeSensorChange(c, change.format, sensor, err)
+	}
+}
+
+// sensorChange - параметры запроса на изменение датчика
+type sensorChange struct {
+	sensorID int64
+	// version - ожидаемая версия из If-Match, 0 - без условия
+	version int64
+	// format - формат ответа с изменённым датчиком
+	format string
+}
+
+// parseSensorChange - формат ответа, ID изменяемого датчика и ожидаемая версия из If-Match
+func parseSensorChange(c *gin.Context) (sensorChange, error) {
+	format, err := negotiate(c, responseFormats...)
+	if err != nil {
+		return sensorChange{}, err
+	}
+
+	sensorID, err := parseID(c, "sensor_id")
+	if err != nil {
+		return sensorChange{}, err
+	}
+
+	version, err := ifMatch(c)
+	if err != nil {
+		return sensorChange{}, err
+	}
+
+	return sensorChange{sensorID: sensorID, version: version, format: format}, nil
+}
+
+// writeSensorChange - ответ на изменение датчика: датчик в формате format с ETag этого представления или ошибка
+func writeSensorChange(c *gin.Context, format string, sensor *domain.Sensor, err error) {
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Header("ETag", sensorETag(format, sensor))
+
+	writeResponse(c, http.StatusOK, format, newSensor(sensor))
+}
+
+func getSensorStateReport(uc UseCases) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format, err := negotiate(c, responseFormats...)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		sensorID, err := parseID(c, "sensor_id")
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		from, to, err := parseTimeRange(c)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		report, err := uc.Event.GetStateReport(c.Request.Context(), sensorID, from, to)
+		if errors.Is(err, usecase.ErrWrongSensorType) {
+			// отчёт не строится для типа датчика, а не для поля type запроса
+			err = invalidField("sensor_id", err)
+		}
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		writeResponse(c, http.StatusOK, format, newStateReport(report))
+	}
+}
+
+func getSensorHistory(uc UseCases) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format, err := negotiate(c, listFormats...)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		sensorID, err := parseID(c, "sensor_id")
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		from, to, err := parseTimeRange(c)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		step, err := parseStep(c)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		history, err := uc.Event.GetHistory(c.Request.Context(), sensorID, from, to, step)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		writeResponse(c, http.StatusOK, format, newHistory(history))
+	}
+}
+
+func subscribeSensorEvents(ws *WebSocketHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sensorID, err := parseID(c, "sensor_id")
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		if err := ws.Handle(c, sensorID); err != nil && !c.Writer.Written() {
+			writeError(c, err)
+		}
+	}
+}
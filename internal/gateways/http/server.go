@@ -2,16 +2,45 @@ package http
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"homework/internal/ratelimit"
 	"homework/internal/usecase"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+// defaultShutdownTimeout - время на завершение обработки запросов после отмены контекста сервера
+const defaultShutdownTimeout = 15 * time.Second
+
 type Server struct {
-	host   string
-	port   uint16
-	router *gin.Engine
+	host            string
+	port            uint16
+	router          *gin.Engine
+	ws              *WebSocketHandler
+	health          *health
+	metrics         *httpMetrics
+	accessLog       *accessLog
+	clientLimit     ratelimit.Limit
+	trustedProxies  []string
+	maxConcurrent   int
+	sensorMetrics   prometheus.Gatherer
+	timeouts        Timeouts
+	shutdownTimeout time.Duration
+	shutdownDelay   time.Duration
+}
+
+// Timeouts - таймауты http.Server, нулевое значение означает отсутствие ограничения
+type Timeouts struct {
+	ReadHeader time.Duration
+	Read       time.Duration
+	Write      time.Duration
+	Idle       time.Duration
 }
 
 type UseCases struct {
@@ -21,14 +50,58 @@ type UseCases struct {
 }
 
 func NewServer(useCases UseCases, options ...func(*Server)) *Server {
-	r := gin.Default()
-	setupRouter(r, useCases, NewWebSocketHandler(useCases))
-
-	s := &Server{router: r, host: "localhost", port: 8080}
+	s := &Server{
+		health: &health{},
+		host:   "localhost",
+		port:   8080,
+		timeouts: Timeouts{
+			ReadHeader: 5 * time.Second,
+			Read:       30 * time.Second,
+			Write:      30 * time.Second,
+			Idle:       2 * time.Minute,
+		},
+		shutdownTimeout: defaultShutdownTimeout,
+	}
 	for _, o := range options {
 		o(s)
 	}
 
+	// в режиме отладки gin пишет о маршрутах и предупреждения в stdout мимо журнала сервера
+	gin.SetMode(gin.ReleaseMode)
+
+	// middleware действует только на маршруты, зарегистрированные после неё
+	r := gin.New()
+	// по умолчанию gin доверяет X-Forwarded-For от любого адреса, и клиент может подменить свой адрес,
+	// обходя ограничение частоты. Невалидный список отклоняется при проверке конфигурации,
+	// а если он всё же попал сюда, заголовки не принимаются ни от кого.
+	if err := r.SetTrustedProxies(s.trustedProxies); err != nil {
+		_ = r.SetTrustedProxies(nil)
+	}
+	r.Use(gin.Recovery(), tracingMiddleware(), requestIDMiddleware())
+	if s.accessLog != nil {
+		r.Use(s.accessLog.middleware())
+	}
+	if s.metrics != nil {
+		r.Use(s.metrics.middleware())
+		setupMetricsRoutes(r, s.metrics)
+	}
+	if s.sensorMetrics != nil {
+		setupSensorMetricsRoutes(r, s.sensorMetrics)
+	}
+	// проверки состояния отвечают и под нагрузкой, поэтому регистрируются до ограничения одновременных запросов
+	setupHealthRoutes(r, s.health)
+	if s.maxConcurrent > 0 {
+		r.Use(concurrencyLimit(s.maxConcurrent))
+	}
+
+	var eventsMiddleware []gin.HandlerFunc
+	if !s.clientLimit.Unlimited() {
+		eventsMiddleware = append(eventsMiddleware, clientRateLimit(ratelimit.New(), s.clientLimit))
+	}
+	s.ws = NewWebSocketHandler(useCases, withWebSocketMetrics(s.metrics))
+	setupRouter(r, useCases, s.ws, eventsMiddleware...)
+	s.router = r
+
 	return s
 }
 
@@ -44,6 +117,151 @@ func WithPort(port uint16) func(*Server) {
 	}
 }
 
+func WithTimeouts(timeouts Timeouts) func(*Server) {
+	return func(s *Server) {
+		s.timeouts = timeouts
+	}
+}
+
+// WithShutdownTimeout - опция, задающая время на завершение обработки запросов при остановке сервера
+func WithShutdownTimeout(timeout time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.shutdownTimeout = timeout
+	}
+}
+
+// WithShutdownDelay - опция, задающая время между началом остановки и закрытием порта.
+// В это время /readyz уже отвечает ошибкой, а запросы ещё принимаются, чтобы балансировщик успел исключить сервер.
+func WithShutdownDelay(delay time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.shutdownDelay = delay
+	}
+}
+
+// WithReadinessChecks - опция, добавляющая проверки готовности для /readyz
+func WithReadinessChecks(checks ...ReadinessCheck) func(*Server) {
+	return func(s *Server) {
+		s.health.checks = append(s.health.checks, checks...)
+	}
+}
+
+// WithMetrics - опция, включающая метрики запросов и ws подписок в reg и выдачу всех метрик reg на /metrics
+func WithMetrics(reg *prometheus.Registry) func(*Server) {
+	return func(s *Server) {
+		s.metrics = newHTTPMetrics(reg)
+	}
+}
+
+// WithSensorMetrics - опция, включающая выдачу метрик collector на /metrics/sensors.
+// Метрики датчиков отдаются отдельно от метрик сервера, чтобы их можно было собирать с другим интервалом.
+func WithSensorMetrics(collector prometheus.Collector) func(*Server) {
+	return func(s *Server) {
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(collector)
+		s.sensorMetrics = reg
+	}
+}
+
+// WithAccessLog - опция, включающая журнал запросов в logger. Запросы с ошибкой сервера записываются всегда,
+// остальные - с вероятностью sampleRatio от 0 до 1.
+func WithAccessLog(logger *slog.Logger, sampleRatio float64) func(*Server) {
+	return func(s *Server) {
+		s.accessLog = newAccessLog(logger, sampleRatio)
+	}
+}
+
+// WithClientRateLimit - опция, включающая ограничение частоты событий с одного адреса клиента.
+// Сверх ограничения POST /events отвечает 429 с заголовком Retry-After.
+func WithClientRateLimit(limit ratelimit.Limit) func(*Server) {
+	return func(s *Server) {
+		s.clientLimit = limit
+	}
+}
+
+// WithTrustedProxies - опция, задающая адреса и подсети обратных прокси, от которых принимаются
+// X-Forwarded-For и X-Real-IP. По умолчанию адрес клиента берётся из соединения.
+func WithTrustedProxies(proxies []string) func(*Server) {
+	return func(s *Server) {
+		s.trustedProxies = proxies
+	}
+}
+
+// WithMaxConcurrentRequests - опция, ограничивающая количество одновременно обрабатываемых запросов API.
+// Сверх ограничения запрос отклоняется с ответом 503, 0 - без ограничения.
+func WithMaxConcurrentRequests(n int) func(*Server) {
+	return func(s *Server) {
+		s.maxConcurrent = n
+	}
+}
+
+// Run - принимает соединения на host:port до отмены ctx, затем останавливает сервер (см. Serve)
 func (s *Server) Run(ctx context.Context) error {
-	return s.router.Run(fmt.Sprintf("%s:%d", s.host, s.port))
+	var lc net.ListenConfig
+	l, err := lc.Listen(ctx, "tcp", fmt.Sprintf("%s:%d", s.host, s.port))
+	if err != nil {
+		return fmt.Errorf("can't listen: %w", err)
+	}
+
+	return s.Serve(ctx, l)
+}
+
+// Serve - принимает соединения из l до отмены ctx. После отмены /readyz сразу перестаёт отвечать успехом,
+// через shutdownDelay новые соединения перестают приниматься,
+// ws соединения закрываются, а начатые запросы обрабатываются до конца, но не дольше shutdownTimeout.
+// Возвращает nil, если все запросы успели завершиться.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	srv := &http.Server{
+		Handler:           s.router,
+		ReadHeaderTimeout: s.timeouts.ReadHeader,
+		ReadTimeout:       s.timeouts.Read,
+		WriteTimeout:      s.timeouts.Write,
+		IdleTimeout:       s.timeouts.Idle,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(l)
+	}()
+
+	select {
+	case err := <-serveErr:
+		_ = s.ws.Shutdown()
+		return err
+	case <-ctx.Done():
+	}
+
+	s.health.shuttingDown.Store(true)
+	if s.shutdownDelay > 0 {
+		time.Sleep(s.shutdownDelay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.shutdownTimeout)
+	defer cancel()
+
+	// ws соединения перехвачены у http.Server, поэтому Shutdown их не ждёт и закрывать их нужно отдельно
+	wsDone := make(chan error, 1)
+	go func() {
+		wsDone <- s.ws.Shutdown()
+	}()
+
+	var errs []error
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, fmt.Errorf("can't drain requests: %w", err))
+		_ = srv.Close()
+	}
+
+	select {
+	case err := <-wsDone:
+		if err != nil {
+			errs = append(errs, fmt.Errorf("can't close websockets: %w", err))
+		}
+	case <-shutdownCtx.Done():
+		errs = append(errs, fmt.Errorf("can't close websockets: %w", shutdownCtx.Err()))
+	}
+
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
 }
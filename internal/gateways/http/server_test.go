@@ -0,0 +1,172 @@
+package http
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"nhooyr.io/websocket"
+)
+
+func newServerUseCases(t *testing.T, srMock *usecase.MockSensorRepository) UseCases {
+	ctrl := gomock.NewController(t)
+
+	return UseCases{
+		Event:  usecase.NewEvent(usecase.NewMockEventRepository(ctrl), srMock),
+		Sensor: usecase.NewSensor(srMock),
+		User:   usecase.NewUser(usecase.NewMockUserRepository(ctrl), usecase.NewMockSensorOwnerRepository(ctrl), srMock),
+	}
+}
+
+// startServer - запускает сервер на свободном порту, ошибка Serve попадает в возвращаемый канал
+func startServer(t *testing.T, ctx context.Context, srMock *usecase.MockSensorRepository, options ...func(*Server)) (string, <-chan error) {
+	t.Helper()
+
+	uc := newServerUseCases(t, srMock)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServer(uc, options...).Serve(ctx, l)
+	}()
+
+	return l.Addr().String(), done
+}
+
+func TestServer_Serve(t *testing.T) {
+	t.Run("ok, in-flight request is completed", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		serverCtx, stop := context.WithCancel(ctx)
+		defer stop()
+
+		started, release := make(chan struct{}), make(chan struct{})
+		srMock := usecase.NewMockSensorRepository(gomock.NewController(t))
+		srMock.EXPECT().GetSensors(gomock.Any()).DoAndReturn(func(context.Context) ([]domain.Sensor, error) {
+			close(started)
+			<-release
+			return []domain.Sensor{{ID: 1, SerialNumber: "0123456789", Type: domain.SensorTypeADC}}, nil
+		}).Times(1)
+
+		addr, done := startServer(t, serverCtx, srMock)
+
+		type result struct {
+			status int
+			body   []byte
+			err    error
+		}
+		responses := make(chan result, 1)
+		go func() {
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+"/sensors", nil)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				responses <- result{err: err}
+				return
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			responses <- result{status: resp.StatusCode, body: body, err: err}
+		}()
+
+		<-started
+		stop()
+
+		// новые соединения не принимаются, пока начатый запрос ещё обрабатывается
+		assert.Eventually(t, func() bool {
+			conn, err := net.Dial("tcp", addr)
+			if err == nil {
+				_ = conn.Close()
+			}
+			return err != nil
+		}, time.Second, 10*time.Millisecond)
+
+		select {
+		case err := <-done:
+			t.Fatalf("server stopped before request completed: %v", err)
+		default:
+		}
+
+		close(release)
+
+		r := <-responses
+		require.NoError(t, r.err)
+		assert.Equal(t, http.StatusOK, r.status)
+		assert.Contains(t, string(r.body), "0123456789")
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("ok, websockets are closed", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		serverCtx, stop := context.WithCancel(ctx)
+		defer stop()
+
+		srMock := usecase.NewMockSensorRepository(gomock.NewController(t))
+		srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(2))).Return(&domain.Sensor{ID: 2}, nil).Times(1)
+
+		addr, done := startServer(t, serverCtx, srMock)
+
+		conn, _, err := websocket.Dial(ctx, "ws://"+addr+"/sensors/2/events", nil)
+		require.NoError(t, err)
+
+		stop()
+
+		_, _, err = conn.Read(ctx)
+		assert.Equal(t, websocket.StatusNormalClosure, websocket.CloseStatus(err))
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("fail, shutdown timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		serverCtx, stop := context.WithCancel(ctx)
+		defer stop()
+
+		started, release := make(chan struct{}), make(chan struct{})
+		defer close(release)
+		srMock := usecase.NewMockSensorRepository(gomock.NewController(t))
+		srMock.EXPECT().GetSensors(gomock.Any()).DoAndReturn(func(context.Context) ([]domain.Sensor, error) {
+			close(started)
+			<-release
+			return nil, nil
+		}).Times(1)
+
+		addr, done := startServer(t, serverCtx, srMock, WithShutdownTimeout(100*time.Millisecond))
+
+		go func() {
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+"/sensors", nil)
+			resp, err := http.DefaultClient.Do(req)
+			if err == nil {
+				_ = resp.Body.Close()
+			}
+		}()
+
+		<-started
+		stop()
+
+		assert.ErrorIs(t, <-done, context.DeadlineExceeded)
+	})
+
+	t.Run("fail, address in use", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer l.Close()
+
+		port := uint16(l.Addr().(*net.TCPAddr).Port)
+		srMock := usecase.NewMockSensorRepository(gomock.NewController(t))
+		srv := NewServer(newServerUseCases(t, srMock), WithHost("127.0.0.1"), WithPort(port))
+		assert.Error(t, srv.Run(ctx))
+	})
+}
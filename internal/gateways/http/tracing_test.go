@@ -0,0 +1,81 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestServer_Tracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prevTracer, prevPropagator := tracer, otel.GetTextMapPropagator()
+	tracer = tp.Tracer("test")
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		tracer = prevTracer
+		otel.SetTextMapPropagator(prevPropagator)
+		_ = tp.Shutdown(context.Background())
+	})
+
+	ctrl := gomock.NewController(t)
+	srMock := usecase.NewMockSensorRepository(ctrl)
+	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(1))).DoAndReturn(func(ctx context.Context, _ int64) (*domain.Sensor, error) {
+		// обработчик получает контекст со span запроса
+		assert.True(t, trace.SpanContextFromContext(ctx).IsValid())
+		return &domain.Sensor{ID: 1, SerialNumber: "0123456789", Type: domain.SensorTypeADC}, nil
+	}).Times(1)
+	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(2))).Return(nil, usecase.ErrSensorNotFound).Times(1)
+	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(3))).Return(nil, errors.New("connection refused")).Times(1)
+
+	s := NewServer(newServerUseCases(t, srMock))
+	serve := func(target string, header http.Header) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		s.router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	assert.Equal(t, http.StatusOK, serve("/sensors/1", http.Header{"Traceparent": {traceparent}}))
+	assert.Equal(t, http.StatusNotFound, serve("/sensors/2", nil))
+	assert.Equal(t, http.StatusInternalServerError, serve("/sensors/3", nil))
+	assert.Equal(t, http.StatusNotFound, serve("/unknown", nil))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 4)
+
+	assert.Equal(t, "GET /sensors/:sensor_id", spans[0].Name)
+	assert.Equal(t, trace.SpanKindServer, spans[0].SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent.SpanID().String())
+	assert.True(t, spans[0].Parent.IsRemote())
+	assert.Contains(t, spans[0].Attributes, semconv.HTTPRoute("/sensors/:sensor_id"))
+	assert.Contains(t, spans[0].Attributes, semconv.HTTPResponseStatusCode(http.StatusOK))
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+
+	assert.False(t, spans[1].Parent.IsValid())
+	assert.Equal(t, codes.Unset, spans[1].Status.Code, "client errors are not span errors")
+	assert.Equal(t, codes.Error, spans[2].Status.Code)
+
+	assert.Equal(t, "GET", spans[3].Name)
+	assert.Contains(t, spans[3].Attributes, semconv.URLPath("/unknown"))
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"homework/internal/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func registerUser(uc UseCases) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body UserToCreate
+		if err := readJSON(c, &body); err != nil {
+			writeError(c, err)
+			return
+		}
+
+		user, err := uc.User.RegisterUser(c.Request.Context(), &domain.User{Name: body.Name})
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		writeJSON(c, http.StatusOK, newUser(user))
+	}
+}
+
+func getUserSensors(uc UseCases) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format, err := negotiate(c, listFormats...)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		userID, err := parseID(c, "user_id")
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		sensors, err := uc.User.GetUserSensors(c.Request.Context(), userID)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		writeResponse(c, http.StatusOK, format, newSensors(sensors))
+	}
+}
+
+func attachSensorToUser(uc UseCases) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := parseID(c, "user_id")
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		var body SensorToUserBinding
+		if err := readJSON(c, &body); err != nil {
+			writeError(c, err)
+			return
+		}
+		if body.SensorID < 1 {
+			writeError(c, invalidField("sensor_id", ErrInvalidID))
+			return
+		}
+
+		if err := uc.User.AttachSensorToUser(c.Request.Context(), userID, body.SensorID); err != nil {
+			writeError(c, err)
+			return
+		}
+
+		c.Status(http.StatusCreated)
+	}
+}
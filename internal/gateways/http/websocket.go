@@ -1,25 +1,220 @@
 package http
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"net"
+	"sync"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"nhooyr.io/websocket"
 )
 
+// eventsPollInterval - минимальный интервал между сообщениями одному подписчику,
+// частые события датчика схлопываются в одно сообщение с последним событием
+const eventsPollInterval = time.Second
+
+var ErrWebSocketShutdown = errors.New("websocket handler is shutting down")
+
 type WebSocketHandler struct {
 	useCases UseCases
+	metrics  *httpMetrics
+
+	mu       sync.Mutex
+	closed   bool
+	conns    map[*websocket.Conn]*subscription
+	handlers sync.WaitGroup
 }
 
-func NewWebSocketHandler(useCases UseCases) *WebSocketHandler {
-	return &WebSocketHandler{
+// subscription - подписка соединения на события датчика,
+// в updated появляется значение, когда по датчику пришло новое событие
+type subscription struct {
+	sensorID int64
+	updated  chan struct{}
+}
+
+func NewWebSocketHandler(useCases UseCases, options ...func(*WebSocketHandler)) *WebSocketHandler {
+	h := &WebSocketHandler{
 		useCases: useCases,
+		conns:    make(map[*websocket.Conn]*subscription),
+	}
+	for _, o := range options {
+		o(h)
+	}
+
+	return h
+}
+
+// withWebSocketMetrics - опция, включающая учёт открытых соединений и потерянных сообщений
+func withWebSocketMetrics(m *httpMetrics) func(*WebSocketHandler) {
+	return func(h *WebSocketHandler) {
+		h.metrics = m
 	}
 }
 
+// Handle - открывает ws соединение и рассылает в него последнее событие датчика с идентификатором id,
+// а затем новые события по мере их поступления (см. Notify).
+// Ошибки, возникшие до открытия соединения, возвращаются без записи ответа.
 func (h *WebSocketHandler) Handle(c *gin.Context, id int64) error {
-	// TODO implement me
+	if _, err := h.useCases.Sensor.GetSensorByID(c.Request.Context(), id); err != nil {
+		return err
+	}
+
+	conn, sub, err := h.accept(c, id)
+	if err != nil {
+		return err
+	}
+	defer h.release(conn)
+	defer func() {
+		_ = conn.CloseNow()
+	}()
+
+	ctx := conn.CloseRead(context.Background())
+
+	ticker := time.NewTicker(eventsPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		select {
+		case <-sub.updated:
+		default:
+			continue
+		}
+
+		event, err := h.useCases.Event.GetLastEventBySensorID(ctx, id)
+		if errors.Is(err, usecase.ErrEventNotFound) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("can't get last event: %w", err)
+		}
+
+		// датчик читается к каждому сообщению, чтобы значение переводилось по текущей калибровке.
+		// Сообщения отправляются не чаще eventsPollInterval, а датчики обычно берутся из кэша.
+		sensor, err := h.useCases.Sensor.GetSensorByID(ctx, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("can't get sensor by id: %w", err)
+		}
+
+		if err := h.send(ctx, conn, event, sensor); err != nil {
+			return err
+		}
+	}
+}
+
+func (h *WebSocketHandler) send(ctx context.Context, conn *websocket.Conn, event *domain.Event, sensor *domain.Sensor) error {
+	msg, err := json.Marshal(newEventMessage(event, sensor))
+	if err != nil {
+		return fmt.Errorf("can't marshal event: %w", err)
+	}
+
+	if err := conn.Write(ctx, websocket.MessageText, msg); err != nil && ctx.Err() == nil {
+		h.metrics.wsMessageDropped(droppedWriteFailed)
+		return fmt.Errorf("can't write event: %w", err)
+	}
+
 	return nil
 }
 
+func (h *WebSocketHandler) accept(c *gin.Context, sensorID int64) (*websocket.Conn, *subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, nil, ErrWebSocketShutdown
+	}
+
+	conn, err := websocket.Accept(c.Writer, c.Request, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't accept websocket: %w", err)
+	}
+
+	sub := &subscription{
+		sensorID: sensorID,
+		updated:  make(chan struct{}, 1),
+	}
+	// первое сообщение - последнее известное событие датчика
+	sub.updated <- struct{}{}
+
+	h.conns[conn] = sub
+	h.handlers.Add(1)
+	h.metrics.wsConnected()
+
+	return conn, sub, nil
+}
+
+func (h *WebSocketHandler) release(conn *websocket.Conn) {
+	h.mu.Lock()
+	delete(h.conns, conn)
+	h.mu.Unlock()
+	h.metrics.wsDisconnected()
+
+	h.handlers.Done()
+}
+
+// Notify - уведомляет подписчиков датчика о новом событии
+func (h *WebSocketHandler) Notify(ctx context.Context, sensorID int64) {
+	_, span := tracer.Start(ctx, "WebSocketHandler.Notify", trace.WithAttributes(attribute.Int64("sensor.id", sensorID)))
+	defer span.End()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var notified, coalesced int
+	for _, sub := range h.conns {
+		if sub.sensorID != sensorID {
+			continue
+		}
+		select {
+		case sub.updated <- struct{}{}:
+			notified++
+		default:
+			coalesced++
+			h.metrics.wsMessageDropped(droppedCoalesced)
+		}
+	}
+	span.SetAttributes(attribute.Int("websocket.notified", notified), attribute.Int("websocket.coalesced", coalesced))
+}
+
+// Shutdown - закрывает все открытые соединения и дожидается завершения их обработчиков.
+// После вызова новые соединения не принимаются.
 func (h *WebSocketHandler) Shutdown() error {
-	// TODO implement me
-	return nil
+	h.mu.Lock()
+	h.closed = true
+	conns := make([]*websocket.Conn, 0, len(h.conns))
+	for conn := range h.conns {
+		conns = append(conns, conn)
+	}
+	h.mu.Unlock()
+
+	var errs []error
+	for _, conn := range conns {
+		err := conn.Close(websocket.StatusNormalClosure, "server shutting down")
+		if err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+
+	h.handlers.Wait()
+
+	return errors.Join(errs...)
 }
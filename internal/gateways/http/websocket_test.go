@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -36,7 +38,7 @@ func (t *testSuite) TestWebSocketConnection() {
 	erMock := usecase.NewMockEventRepository(t.ctrl)
 	erMock.EXPECT().GetLastEventBySensorID(gomock.Any(), gomock.Eq(int64(1))).Return(&domain.Event{SensorID: 1, Payload: 100}, nil).Times(1)
 	srMock := usecase.NewMockSensorRepository(t.ctrl)
-	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(1))).Return(&domain.Sensor{ID: 1}, nil).Times(1)
+	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(1))).Return(&domain.Sensor{ID: 1}, nil).Times(2)
 	urMock := usecase.NewMockUserRepository(t.ctrl)
 	sorMock := usecase.NewMockSensorOwnerRepository(t.ctrl)
 
@@ -69,6 +71,58 @@ func (t *testSuite) TestWebSocketConnection() {
 	require.Equal(t.T(), int64(100), event.Payload)
 }
 
+func (t *testSuite) TestWebSocketConnection_Calibration() {
+	engine := gin.Default()
+
+	erMock := usecase.NewMockEventRepository(t.ctrl)
+	erMock.EXPECT().GetLastEventBySensorID(gomock.Any(), gomock.Eq(int64(3))).Return(&domain.Event{SensorID: 3, Payload: 625}, nil).Times(1)
+	srMock := usecase.NewMockSensorRepository(t.ctrl)
+	// калибровка задана после открытия соединения и всё равно применяется к сообщению
+	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(3))).Return(&domain.Sensor{ID: 3}, nil).Times(1)
+	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(3))).Return(&domain.Sensor{
+		ID: 3,
+		Calibration: &domain.Calibration{
+			Kind:      domain.CalibrationKindLinear,
+			Scale:     0.1,
+			Offset:    -40,
+			Unit:      "°C",
+			Precision: 1,
+		},
+	}, nil).Times(1)
+	urMock := usecase.NewMockUserRepository(t.ctrl)
+	sorMock := usecase.NewMockSensorOwnerRepository(t.ctrl)
+
+	uc := UseCases{
+		Event:  usecase.NewEvent(erMock, srMock),
+		Sensor: usecase.NewSensor(srMock),
+		User:   usecase.NewUser(urMock, sorMock, srMock),
+	}
+
+	ws := NewWebSocketHandler(uc)
+	setupRouter(engine, uc, ws)
+
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+
+	srvURL, _ := url.Parse(srv.URL)
+	srvURL.Scheme = "ws"
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, srvURL.String()+"/sensors/3/events", nil)
+	require.NoError(t.T(), err)
+	_, msg, err := conn.Read(ctx)
+	require.NoError(t.T(), err)
+	var event EventMessage
+	require.NoError(t.T(), json.Unmarshal(msg, &event))
+
+	require.Equal(t.T(), int64(625), event.Payload)
+	require.NotNil(t.T(), event.Value)
+	require.InDelta(t.T(), 22.5, *event.Value, 1e-9)
+	require.Equal(t.T(), "°C", event.Unit)
+	require.NoError(t.T(), conn.Close(websocket.StatusNormalClosure, ""))
+}
+
 func (t *testSuite) TestWebSocketConnectionFail() {
 	engine := gin.Default()
 
@@ -173,6 +227,106 @@ func (t *testSuite) TestWebSocketShutdown_Client() {
 	assert.NoError(t.T(), ws.Shutdown())
 }
 
+func (t *testSuite) TestWebSocketConnection_DeferredEvent() {
+	engine := gin.Default()
+
+	var saved atomic.Bool
+	event := &domain.Event{SensorID: 5, SensorSerialNumber: "0123456789", Payload: 42}
+
+	erMock := usecase.NewMockEventRepository(t.ctrl)
+	erMock.EXPECT().SaveEvents(gomock.Any(), gomock.Len(1)).DoAndReturn(func(context.Context, []domain.Event) error {
+		saved.Store(true)
+		return nil
+	}).Times(1)
+	erMock.EXPECT().GetLastEventBySensorID(gomock.Any(), gomock.Eq(int64(5))).DoAndReturn(func(context.Context, int64) (*domain.Event, error) {
+		if !saved.Load() {
+			return nil, usecase.ErrEventNotFound
+		}
+		return event, nil
+	}).MinTimes(1)
+	srMock := usecase.NewMockSensorRepository(t.ctrl)
+	srMock.EXPECT().GetSensorBySerialNumber(gomock.Any(), "0123456789").Return(&domain.Sensor{ID: 5, Type: domain.SensorTypeADC}, nil).Times(1)
+	srMock.EXPECT().GetSensorByID(gomock.Any(), gomock.Eq(int64(5))).Return(&domain.Sensor{ID: 5, Type: domain.SensorTypeADC}, nil).Times(3)
+	srMock.EXPECT().UpdateSensorState(gomock.Any(), int64(5), gomock.Any()).Return(nil, nil).Times(1)
+	urMock := usecase.NewMockUserRepository(t.ctrl)
+	sorMock := usecase.NewMockSensorOwnerRepository(t.ctrl)
+
+	ingestion := usecase.NewIngestion(erMock, srMock, usecase.IngestionConfig{Workers: 1, FlushInterval: 10 * time.Millisecond})
+	uc := UseCases{
+		Event:  usecase.NewEvent(erMock, srMock, usecase.WithIngestion(ingestion)),
+		Sensor: usecase.NewSensor(srMock),
+		User:   usecase.NewUser(urMock, sorMock, srMock),
+	}
+
+	ws := NewWebSocketHandler(uc)
+	setupRouter(engine, uc, ws)
+
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	ingestionCtx, stopIngestion := context.WithCancel(ctx)
+	ingestionDone := make(chan struct{})
+	go func() {
+		defer close(ingestionDone)
+		ingestion.Run(ingestionCtx)
+	}()
+	defer func() {
+		stopIngestion()
+		<-ingestionDone
+	}()
+
+	srvURL, _ := url.Parse(srv.URL)
+	srvURL.Scheme = "ws"
+	conn, _, err := websocket.Dial(ctx, srvURL.String()+"/sensors/5/events", nil)
+	require.NoError(t.T(), err)
+	defer func() {
+		_ = conn.CloseNow()
+	}()
+
+	resp, err := http.Post(srv.URL+"/events", "application/json", strings.NewReader(`{"sensor_serial_number":"0123456789","payload":42}`))
+	require.NoError(t.T(), err)
+	_ = resp.Body.Close()
+	assert.Equal(t.T(), http.StatusAccepted, resp.StatusCode)
+
+	// подписчик получает событие только после его сохранения из очереди
+	_, msg, err := conn.Read(ctx)
+	require.NoError(t.T(), err)
+	var got domain.Event
+	require.NoError(t.T(), json.Unmarshal(msg, &got))
+	assert.Equal(t.T(), int64(42), got.Payload)
+}
+
+func (t *testSuite) TestReceiveEvent_QueueFull() {
+	engine := gin.Default()
+
+	erMock := usecase.NewMockEventRepository(t.ctrl)
+	srMock := usecase.NewMockSensorRepository(t.ctrl)
+	srMock.EXPECT().GetSensorBySerialNumber(gomock.Any(), "0123456789").Return(&domain.Sensor{ID: 5, Type: domain.SensorTypeADC}, nil).Times(1)
+	urMock := usecase.NewMockUserRepository(t.ctrl)
+	sorMock := usecase.NewMockSensorOwnerRepository(t.ctrl)
+
+	ingestion := usecase.NewIngestion(erMock, srMock, usecase.IngestionConfig{QueueSize: 1, Workers: 1})
+	require.NoError(t.T(), ingestion.Enqueue(domain.Event{SensorID: 5}))
+
+	uc := UseCases{
+		Event:  usecase.NewEvent(erMock, srMock, usecase.WithIngestion(ingestion)),
+		Sensor: usecase.NewSensor(srMock),
+		User:   usecase.NewUser(urMock, sorMock, srMock),
+	}
+	setupRouter(engine, uc, NewWebSocketHandler(uc))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"sensor_serial_number":"0123456789","payload":42}`))
+	req.Header.Set("Content-Type", "application/json")
+	engine.ServeHTTP(w, req)
+
+	assert.Equal(t.T(), http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t.T(), "1", w.Header().Get("Retry-After"))
+}
+
 func TestWebSocketHandler(t *testing.T) {
 	ts := new(testSuite)
 	defer func() {
@@ -0,0 +1,119 @@
+// Package logging - настройка log/slog и передача идентификатора запроса через контекст
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Форматы записей
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+var (
+	ErrUnknownLevel  = errors.New("unknown log level")
+	ErrUnknownFormat = errors.New("unknown log format")
+)
+
+// Ключи атрибутов, которые добавляются к записям из контекста
+const (
+	RequestIDKey = "request_id"
+	TraceIDKey   = "trace_id"
+)
+
+// Config - настройки записей журнала
+type Config struct {
+	// Level - минимальный уровень: debug, info, warn или error
+	Level string
+	// Format - формат записей: text или json
+	Format string
+}
+
+// ParseLevel - уровень по имени: debug, info, warn или error
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownLevel, s)
+	}
+
+	return level, nil
+}
+
+// New - журнал с записями в output по c. К записям, сделанным с контекстом запроса,
+// добавляются его идентификатор и идентификатор трассы, см. WithRequestID.
+func New(c Config, output io.Writer) (*slog.Logger, error) {
+	level, err := ParseLevel(c.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch c.Format {
+	case FormatText:
+		handler = slog.NewTextHandler(output, options)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(output, options)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, c.Format)
+	}
+
+	return slog.New(NewContextHandler(handler)), nil
+}
+
+type requestIDKey struct{}
+
+// WithRequestID - контекст с идентификатором запроса id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID - идентификатор запроса из ctx, пустая строка вне запроса
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ContextHandler - slog.Handler, добавляющий к записи идентификаторы запроса и трассы из контекста
+type ContextHandler struct {
+	next slog.Handler
+}
+
+var _ slog.Handler = (*ContextHandler)(nil)
+
+func NewContextHandler(next slog.Handler) *ContextHandler {
+	return &ContextHandler{next: next}
+}
+
+func (h *ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	// записи без контекста делаются с context.Background()
+	if ctx != nil {
+		if id := RequestID(ctx); id != "" {
+			r.AddAttrs(slog.String(RequestIDKey, id))
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String(TraceIDKey, sc.TraceID().String()))
+		}
+	}
+
+	return h.next.Handle(ctx, r)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{next: h.next.WithGroup(name)}
+}
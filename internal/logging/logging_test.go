@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestNew(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	t.Run("ok, json with request and trace ids", func(t *testing.T) {
+		var output bytes.Buffer
+		logger, err := New(Config{Level: "debug", Format: FormatJSON}, &output)
+		require.NoError(t, err)
+
+		tp := sdktrace.NewTracerProvider()
+		defer func() {
+			_ = tp.Shutdown(context.Background())
+		}()
+		spanCtx, span := tp.Tracer("test").Start(WithRequestID(ctx, "abc"), "test")
+		defer span.End()
+
+		logger.With("component", "test").DebugContext(spanCtx, "hello", "answer", 42)
+
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(output.Bytes(), &record))
+		assert.Equal(t, "DEBUG", record["level"])
+		assert.Equal(t, "hello", record["msg"])
+		assert.Equal(t, "test", record["component"])
+		assert.Equal(t, float64(42), record["answer"])
+		assert.Equal(t, "abc", record[RequestIDKey])
+		assert.Equal(t, span.SpanContext().TraceID().String(), record[TraceIDKey])
+	})
+
+	t.Run("ok, text without request", func(t *testing.T) {
+		var output bytes.Buffer
+		logger, err := New(Config{Level: "warn", Format: FormatText}, &output)
+		require.NoError(t, err)
+
+		logger.InfoContext(ctx, "skipped")
+		logger.Warn("written")
+
+		assert.NotContains(t, output.String(), "skipped")
+		assert.Contains(t, output.String(), "level=WARN msg=written")
+		assert.NotContains(t, output.String(), RequestIDKey)
+	})
+
+	t.Run("err, unknown level", func(t *testing.T) {
+		_, err := New(Config{Level: "verbose", Format: FormatText}, &bytes.Buffer{})
+		assert.ErrorIs(t, err, ErrUnknownLevel)
+	})
+
+	t.Run("err, unknown format", func(t *testing.T) {
+		_, err := New(Config{Level: "info", Format: "logfmt"}, &bytes.Buffer{})
+		assert.ErrorIs(t, err, ErrUnknownFormat)
+	})
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("error")
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelError, level)
+
+	_, err = ParseLevel("")
+	assert.ErrorIs(t, err, ErrUnknownLevel)
+}
+
+func TestRequestID(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.Equal(t, "", RequestID(ctx))
+	assert.Equal(t, "abc", RequestID(WithRequestID(ctx, "abc")))
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"homework/internal/domain"
+	"homework/internal/usecase"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Namespace - общий префикс имён метрик сервиса
+const Namespace = "smarthome"
+
+// unknownSensorType - значение метки sensor_type, когда датчик не найден
+const unknownSensorType = "unknown"
+
+// unknownSerial - значение метки serial для незарегистрированных датчиков,
+// чтобы произвольные серийные номера не порождали новые серии
+const unknownSerial = "unknown"
+
+// NewRegistry - создаёт реестр метрик со стандартными метриками рантайма Go и процесса
+func NewRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return reg
+}
+
+// Events - счётчики принятых и отклонённых событий по типу датчика
+// и событий, отклонённых ограничением частоты, по датчику. Реализует usecase.EventObserver.
+type Events struct {
+	received  *prometheus.CounterVec
+	rejected  *prometheus.CounterVec
+	throttled *prometheus.CounterVec
+}
+
+var _ usecase.EventObserver = (*Events)(nil)
+
+func NewEvents(reg prometheus.Registerer) *Events {
+	e := &Events{
+		received: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "events_received_total",
+			Help:      "Number of accepted sensor events.",
+		}, []string{"sensor_type"}),
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "events_rejected_total",
+			Help:      "Number of rejected sensor events by reason.",
+		}, []string{"sensor_type", "reason"}),
+		throttled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "events_throttled_total",
+			Help:      "Number of sensor events rejected by the rate limit.",
+		}, []string{"serial", "sensor_type"}),
+	}
+	reg.MustRegister(e.received, e.rejected, e.throttled)
+
+	return e
+}
+
+func (e *Events) EventReceived(sensorType domain.SensorType) {
+	e.received.WithLabelValues(sensorTypeLabel(sensorType)).Inc()
+}
+
+func (e *Events) EventRejected(sensorType domain.SensorType, reason string) {
+	e.rejected.WithLabelValues(sensorTypeLabel(sensorType), reason).Inc()
+}
+
+func (e *Events) EventThrottled(serialNumber string, sensorType domain.SensorType) {
+	if sensorType == "" {
+		serialNumber = unknownSerial
+	}
+	e.throttled.WithLabelValues(serialNumber, sensorTypeLabel(sensorType)).Inc()
+}
+
+func sensorTypeLabel(sensorType domain.SensorType) string {
+	if sensorType == "" {
+		return unknownSensorType
+	}
+
+	return string(sensorType)
+}
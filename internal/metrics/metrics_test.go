@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sensorCache "homework/internal/repository/sensor/cache"
+)
+
+func TestEvents(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	e := NewEvents(reg)
+
+	e.EventReceived(domain.SensorTypeADC)
+	e.EventReceived(domain.SensorTypeADC)
+	e.EventRejected(domain.SensorTypeContactClosure, string(domain.QuarantineReasonInvalidPayload))
+	e.EventRejected("", string(domain.QuarantineReasonUnknownSensor))
+	e.EventThrottled("0123456789", domain.SensorTypeADC)
+	e.EventThrottled("9876543210", "")
+
+	err := testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP smarthome_events_received_total Number of accepted sensor events.
+# TYPE smarthome_events_received_total counter
+smarthome_events_received_total{sensor_type="adc"} 2
+# HELP smarthome_events_rejected_total Number of rejected sensor events by reason.
+# TYPE smarthome_events_rejected_total counter
+smarthome_events_rejected_total{reason="invalid_payload",sensor_type="cc"} 1
+smarthome_events_rejected_total{reason="unknown_sensor",sensor_type="unknown"} 1
+# HELP smarthome_events_throttled_total Number of sensor events rejected by the rate limit.
+# TYPE smarthome_events_throttled_total counter
+smarthome_events_throttled_total{sensor_type="adc",serial="0123456789"} 1
+smarthome_events_throttled_total{sensor_type="unknown",serial="unknown"} 1
+`))
+	assert.NoError(t, err)
+}
+
+func TestPoolCollector(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// пул не открывает соединений до первого запроса
+	pool, err := pgxpool.New(ctx, "postgres://localhost:1/db?pool_max_conns=7")
+	require.NoError(t, err)
+	defer pool.Close()
+
+	c := NewPoolCollector(pool)
+	assert.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP smarthome_db_pool_max_connections Maximum size of the pool.
+# TYPE smarthome_db_pool_max_connections gauge
+smarthome_db_pool_max_connections 7
+# HELP smarthome_db_pool_total_connections Total number of connections in the pool.
+# TYPE smarthome_db_pool_total_connections gauge
+smarthome_db_pool_total_connections 0
+`), "smarthome_db_pool_max_connections", "smarthome_db_pool_total_connections"))
+	assert.Equal(t, 12, testutil.CollectAndCount(c))
+}
+
+func TestRegisterSensorCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	next := usecase.NewMockSensorRepository(ctrl)
+	next.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, SerialNumber: "0123456789"}, nil)
+
+	cache := sensorCache.NewSensorRepository(next, 10, time.Minute)
+	reg := prometheus.NewPedanticRegistry()
+	RegisterSensorCache(reg, cache)
+
+	for range 3 {
+		_, err := cache.GetSensorByID(ctx, 1)
+		require.NoError(t, err)
+	}
+
+	err := testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP smarthome_sensor_cache_hits_total Number of sensor lookups served from cache.
+# TYPE smarthome_sensor_cache_hits_total counter
+smarthome_sensor_cache_hits_total 2
+# HELP smarthome_sensor_cache_misses_total Number of sensor lookups passed to storage.
+# TYPE smarthome_sensor_cache_misses_total counter
+smarthome_sensor_cache_misses_total 1
+# HELP smarthome_sensor_cache_size Number of cached sensors.
+# TYPE smarthome_sensor_cache_size gauge
+smarthome_sensor_cache_size 1
+`), "smarthome_sensor_cache_hits_total", "smarthome_sensor_cache_misses_total", "smarthome_sensor_cache_size")
+	assert.NoError(t, err)
+}
+
+func TestRegisterIngestion(t *testing.T) {
+	ingestion := usecase.NewIngestion(nil, nil, usecase.IngestionConfig{QueueSize: 1, Workers: 1})
+	reg := prometheus.NewPedanticRegistry()
+	RegisterIngestion(reg, ingestion)
+
+	require.NoError(t, ingestion.Enqueue(domain.Event{SensorID: 1}))
+	assert.ErrorIs(t, ingestion.Enqueue(domain.Event{SensorID: 1}), usecase.ErrIngestionQueueFull)
+
+	err := testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP smarthome_ingestion_queue_depth Number of events waiting in the queue.
+# TYPE smarthome_ingestion_queue_depth gauge
+smarthome_ingestion_queue_depth 1
+# HELP smarthome_ingestion_rejected_total Number of events rejected because the queue was full.
+# TYPE smarthome_ingestion_rejected_total counter
+smarthome_ingestion_rejected_total 1
+`), "smarthome_ingestion_queue_depth", "smarthome_ingestion_rejected_total")
+	assert.NoError(t, err)
+}
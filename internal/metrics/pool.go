@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolCollector - метрики пула соединений pgx, значения читаются из pgxpool.Stat при каждом сборе
+type PoolCollector struct {
+	pool *pgxpool.Pool
+
+	acquireCount         *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	newConnsCount        *prometheus.Desc
+	maxLifetimeDestroyed *prometheus.Desc
+	maxIdleDestroyed     *prometheus.Desc
+	acquiredConns        *prometheus.Desc
+	constructingConns    *prometheus.Desc
+	idleConns            *prometheus.Desc
+	totalConns           *prometheus.Desc
+	maxConns             *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*PoolCollector)(nil)
+
+func NewPoolCollector(pool *pgxpool.Pool) *PoolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(Namespace, "db_pool", name), help, nil, nil)
+	}
+
+	return &PoolCollector{
+		pool:                 pool,
+		acquireCount:         desc("acquires_total", "Number of successful connection acquires from the pool."),
+		acquireDuration:      desc("acquire_duration_seconds_total", "Total time spent acquiring connections from the pool."),
+		canceledAcquireCount: desc("canceled_acquires_total", "Number of acquires canceled by context."),
+		emptyAcquireCount:    desc("empty_acquires_total", "Number of acquires that waited for a connection because the pool was empty."),
+		newConnsCount:        desc("new_connections_total", "Number of new connections opened by the pool."),
+		maxLifetimeDestroyed: desc("max_lifetime_destroyed_total", "Number of connections closed because of max connection lifetime."),
+		maxIdleDestroyed:     desc("max_idle_destroyed_total", "Number of connections closed because of max connection idle time."),
+		acquiredConns:        desc("acquired_connections", "Number of connections currently acquired."),
+		constructingConns:    desc("constructing_connections", "Number of connections being opened."),
+		idleConns:            desc("idle_connections", "Number of idle connections in the pool."),
+		totalConns:           desc("total_connections", "Total number of connections in the pool."),
+		maxConns:             desc("max_connections", "Maximum size of the pool."),
+	}
+}
+
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.Stat()
+
+	counter := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v)
+	}
+	gauge := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v)
+	}
+
+	counter(c.acquireCount, float64(s.AcquireCount()))
+	counter(c.acquireDuration, s.AcquireDuration().Seconds())
+	counter(c.canceledAcquireCount, float64(s.CanceledAcquireCount()))
+	counter(c.emptyAcquireCount, float64(s.EmptyAcquireCount()))
+	counter(c.newConnsCount, float64(s.NewConnsCount()))
+	counter(c.maxLifetimeDestroyed, float64(s.MaxLifetimeDestroyCount()))
+	counter(c.maxIdleDestroyed, float64(s.MaxIdleDestroyCount()))
+	gauge(c.acquiredConns, float64(s.AcquiredConns()))
+	gauge(c.constructingConns, float64(s.ConstructingConns()))
+	gauge(c.idleConns, float64(s.IdleConns()))
+	gauge(c.totalConns, float64(s.TotalConns()))
+	gauge(c.maxConns, float64(s.MaxConns()))
+}
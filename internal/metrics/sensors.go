@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"homework/internal/domain"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// sensorsCollectTimeout - ограничение времени чтения датчиков и владельцев при сборе
+const sensorsCollectTimeout = 10 * time.Second
+
+// SensorSource - источник датчиков для SensorCollector, например usecase.Sensor
+type SensorSource interface {
+	GetSensors(ctx context.Context) ([]domain.Sensor, error)
+}
+
+// OwnerSource - источник владельцев датчиков для SensorCollector, например usecase.User
+type OwnerSource interface {
+	GetSensorOwners(ctx context.Context) (map[int64][]domain.User, error)
+}
+
+// SensorCollector - состояние и время последней активности каждого активного датчика.
+// Датчики и владельцы читаются двумя запросами на весь список, а готовые метрики
+// переиспользуются в течение ttl, так что частые и одновременные сборы не нагружают хранилище.
+type SensorCollector struct {
+	sensors SensorSource
+	owners  OwnerSource
+	ttl     time.Duration
+	now     func() time.Time
+
+	state        *prometheus.Desc
+	lastActivity *prometheus.Desc
+
+	mu        sync.Mutex
+	collected time.Time
+	metrics   []prometheus.Metric
+}
+
+var _ prometheus.Collector = (*SensorCollector)(nil)
+
+func NewSensorCollector(sensors SensorSource, owners OwnerSource, ttl time.Duration) *SensorCollector {
+	labels := []string{"id", "serial", "type", "description", "owner"}
+
+	return &SensorCollector{
+		sensors: sensors,
+		owners:  owners,
+		ttl:     ttl,
+		now:     time.Now,
+		state: prometheus.NewDesc(prometheus.BuildFQName(Namespace, "sensor", "current_state"),
+			"Current state of the sensor in raw units.", labels, nil),
+		lastActivity: prometheus.NewDesc(prometheus.BuildFQName(Namespace, "sensor", "last_activity_timestamp_seconds"),
+			"Time of the last event of the sensor.", labels, nil),
+	}
+}
+
+func (c *SensorCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.state
+	ch <- c.lastActivity
+}
+
+func (c *SensorCollector) Collect(ch chan<- prometheus.Metric) {
+	metrics, err := c.collect()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.state, err)
+		return
+	}
+
+	for _, m := range metrics {
+		ch <- m
+	}
+}
+
+// collect - возвращает метрики, собранные не раньше ttl назад, иначе собирает их заново.
+// Одновременные сборы ждут одного обращения к хранилищу.
+func (c *SensorCollector) collect() ([]prometheus.Metric, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.metrics != nil && now.Sub(c.collected) < c.ttl {
+		return c.metrics, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sensorsCollectTimeout)
+	defer cancel()
+
+	sensors, err := c.sensors.GetSensors(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can't get sensors: %w", err)
+	}
+	owners, err := c.owners.GetSensorOwners(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can't get sensor owners: %w", err)
+	}
+
+	metrics := make([]prometheus.Metric, 0, 2*len(sensors))
+	for _, sensor := range sensors {
+		if !sensor.IsActive {
+			continue
+		}
+
+		labels := []string{
+			strconv.FormatInt(sensor.ID, 10),
+			sensor.SerialNumber,
+			string(sensor.Type),
+			sensor.Description,
+			ownerLabel(owners[sensor.ID]),
+		}
+		metrics = append(metrics, prometheus.MustNewConstMetric(c.state, prometheus.GaugeValue, float64(sensor.CurrentState), labels...))
+		// датчик без событий не имеет времени активности
+		if !sensor.LastActivity.IsZero() {
+			seconds := float64(sensor.LastActivity.UnixNano()) / float64(time.Second)
+			metrics = append(metrics, prometheus.MustNewConstMetric(c.lastActivity, prometheus.GaugeValue, seconds, labels...))
+		}
+	}
+
+	c.metrics, c.collected = metrics, now
+
+	return metrics, nil
+}
+
+// ownerLabel - имена владельцев через запятую, пустая строка для датчика без владельцев
+func ownerLabel(users []domain.User) string {
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		names = append(names, user.Name)
+	}
+
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sensorSource - SensorSource и OwnerSource поверх заданных датчиков и владельцев, считает обращения
+type sensorSource struct {
+	sensors []domain.Sensor
+	owners  map[int64][]domain.User
+	err     error
+	calls   int
+}
+
+func (s *sensorSource) GetSensors(context.Context) ([]domain.Sensor, error) {
+	s.calls++
+	return s.sensors, s.err
+}
+
+func (s *sensorSource) GetSensorOwners(context.Context) (map[int64][]domain.User, error) {
+	return s.owners, nil
+}
+
+func TestSensorCollector(t *testing.T) {
+	lastActivity := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("ok, active sensors exported", func(t *testing.T) {
+		source := &sensorSource{
+			sensors: []domain.Sensor{
+				{ID: 1, SerialNumber: "0000000001", Type: domain.SensorTypeADC, Description: "kitchen", IsActive: true, CurrentState: 215, LastActivity: lastActivity},
+				{ID: 2, SerialNumber: "0000000002", Type: domain.SensorTypeContactClosure, Description: "door", IsActive: true},
+				{ID: 3, SerialNumber: "0000000003", Type: domain.SensorTypeADC, Description: "attic", CurrentState: 100, LastActivity: lastActivity},
+			},
+			owners: map[int64][]domain.User{1: {{ID: 1, Name: "Homer"}, {ID: 2, Name: "Marge"}}},
+		}
+
+		c := NewSensorCollector(source, source, time.Minute)
+		err := testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP smarthome_sensor_current_state Current state of the sensor in raw units.
+# TYPE smarthome_sensor_current_state gauge
+smarthome_sensor_current_state{description="kitchen",id="1",owner="Homer,Marge",serial="0000000001",type="adc"} 215
+smarthome_sensor_current_state{description="door",id="2",owner="",serial="0000000002",type="cc"} 0
+# HELP smarthome_sensor_last_activity_timestamp_seconds Time of the last event of the sensor.
+# TYPE smarthome_sensor_last_activity_timestamp_seconds gauge
+smarthome_sensor_last_activity_timestamp_seconds{description="kitchen",id="1",owner="Homer,Marge",serial="0000000001",type="adc"} 1.7672688e+09
+`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("ok, metrics reused within ttl", func(t *testing.T) {
+		now := lastActivity
+		source := &sensorSource{}
+		for i := range 5000 {
+			source.sensors = append(source.sensors, domain.Sensor{
+				ID:           int64(i + 1),
+				SerialNumber: fmt.Sprintf("%010d", i+1),
+				Type:         domain.SensorTypeADC,
+				IsActive:     true,
+				LastActivity: lastActivity,
+			})
+		}
+
+		c := NewSensorCollector(source, source, time.Minute)
+		c.now = func() time.Time { return now }
+
+		for range 3 {
+			assert.Equal(t, 10000, testutil.CollectAndCount(c))
+		}
+		assert.Equal(t, 1, source.calls)
+
+		now = now.Add(time.Minute)
+		source.sensors = source.sensors[:10]
+		assert.Equal(t, 20, testutil.CollectAndCount(c))
+		assert.Equal(t, 2, source.calls)
+	})
+
+	t.Run("fail, source error", func(t *testing.T) {
+		source := &sensorSource{err: errors.New("connection refused")}
+
+		reg := prometheus.NewPedanticRegistry()
+		require.NoError(t, reg.Register(NewSensorCollector(source, source, time.Minute)))
+
+		_, err := reg.Gather()
+		assert.ErrorContains(t, err, "connection refused")
+
+		// ошибка не запоминается, следующий сбор снова обращается к источнику
+		_, _ = reg.Gather()
+		assert.Equal(t, 2, source.calls)
+	})
+}
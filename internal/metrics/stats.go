@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"homework/internal/usecase"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	sensorCache "homework/internal/repository/sensor/cache"
+)
+
+// RegisterSensorCache - регистрирует метрики кэша датчиков, значения читаются из Stats при каждом сборе
+func RegisterSensorCache(reg prometheus.Registerer, cache *sensorCache.SensorRepository) {
+	opts := func(name, help string) prometheus.Opts {
+		return prometheus.Opts{Namespace: Namespace, Subsystem: "sensor_cache", Name: name, Help: help}
+	}
+
+	reg.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts(opts("hits_total", "Number of sensor lookups served from cache.")), func() float64 {
+			return float64(cache.Stats().Hits)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts(opts("misses_total", "Number of sensor lookups passed to storage.")), func() float64 {
+			return float64(cache.Stats().Misses)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts(opts("evictions_total", "Number of sensors evicted from cache.")), func() float64 {
+			return float64(cache.Stats().Evictions)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts(opts("size", "Number of cached sensors.")), func() float64 {
+			return float64(cache.Stats().Size)
+		}),
+	)
+}
+
+// RegisterIngestion - регистрирует метрики очереди приёма событий, значения читаются из Stats при каждом сборе
+func RegisterIngestion(reg prometheus.Registerer, ingestion *usecase.Ingestion) {
+	opts := func(name, help string) prometheus.Opts {
+		return prometheus.Opts{Namespace: Namespace, Subsystem: "ingestion", Name: name, Help: help}
+	}
+
+	reg.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts(opts("queue_depth", "Number of events waiting in the queue.")), func() float64 {
+			return float64(ingestion.Stats().Depth)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts(opts("queue_capacity", "Total capacity of the queue.")), func() float64 {
+			return float64(ingestion.Stats().Capacity)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts(opts("enqueued_total", "Number of events put into the queue.")), func() float64 {
+			return float64(ingestion.Stats().Enqueued)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts(opts("rejected_total", "Number of events rejected because the queue was full.")), func() float64 {
+			return float64(ingestion.Stats().Rejected)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts(opts("flushed_total", "Number of events saved from the queue.")), func() float64 {
+			return float64(ingestion.Stats().Flushed)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts(opts("failed_total", "Number of events lost because their batch could not be saved.")), func() float64 {
+			return float64(ingestion.Stats().Failed)
+		}),
+	)
+}
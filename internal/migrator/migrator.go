@@ -0,0 +1,255 @@
+// Package migrator - применение встроенных миграций схемы postgres.
+package migrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+
+	"github.com/golang-migrate/migrate/v4"
+	pgxMigrate "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"homework/migrations"
+)
+
+const (
+	// ModeUp - применить недостающие миграции при запуске
+	ModeUp = "up"
+	// ModeCheck - только проверить, что схема соответствует последней миграции
+	ModeCheck = "check"
+	// ModeNone - не проверять схему
+	ModeNone = "none"
+)
+
+// lockID - ключ advisory lock, под которым реплики по очереди применяют миграции
+const lockID int64 = 0x686f6d65776f726b
+
+var (
+	ErrDirty           = errors.New("database schema is dirty")
+	ErrVersionMismatch = errors.New("database schema version mismatch")
+	ErrUnknownMode     = errors.New("unknown migrate mode")
+)
+
+type Migrator struct {
+	pool   *pgxpool.Pool
+	source fs.FS
+}
+
+// WithSource - опция, задающая файлы миграций вместо встроенных
+func WithSource(source fs.FS) func(*Migrator) {
+	return func(m *Migrator) {
+		m.source = source
+	}
+}
+
+func New(pool *pgxpool.Pool, options ...func(*Migrator)) *Migrator {
+	m := &Migrator{pool: pool, source: migrations.FS}
+	for _, option := range options {
+		option(m)
+	}
+
+	return m
+}
+
+// Run - действие при запуске сервера в зависимости от режима mode
+func (m *Migrator) Run(ctx context.Context, mode string) error {
+	switch mode {
+	case ModeUp:
+		return m.Up(ctx)
+	case ModeCheck:
+		return m.Check(ctx)
+	case ModeNone:
+		return nil
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownMode, mode)
+	}
+}
+
+// Up - применяет все недостающие миграции
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(mg *migrate.Migrate) error {
+		from, _, err := version(mg)
+		if err != nil {
+			return err
+		}
+
+		if err := mg.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("can't apply migrations: %w", err)
+		}
+
+		to, _, err := version(mg)
+		if err != nil {
+			return err
+		}
+		if from != to {
+			slog.InfoContext(ctx, "schema migrated", "from", from, "to", to)
+		}
+
+		return nil
+	})
+}
+
+// Down - откатывает steps последних миграций
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("steps should be positive, got %d", steps)
+	}
+
+	return m.withLock(ctx, func(mg *migrate.Migrate) error {
+		if err := mg.Steps(-steps); err != nil {
+			return fmt.Errorf("can't roll back migrations: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// Version - текущая версия схемы; 0, если миграции ещё не применялись.
+// Читает таблицу golang-migrate напрямую, поэтому подходит для частых проверок готовности.
+func (m *Migrator) Version(ctx context.Context) (uint, bool, error) {
+	var (
+		v     int64
+		dirty bool
+	)
+	err := m.pool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&v, &dirty)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows),
+		errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UndefinedTable:
+		return 0, false, nil
+	case err != nil:
+		return 0, false, fmt.Errorf("can't get schema version: %w", err)
+	}
+
+	return uint(v), dirty, nil
+}
+
+// Latest - версия последней встроенной миграции
+func (m *Migrator) Latest() (uint, error) {
+	src, err := iofs.New(m.source, ".")
+	if err != nil {
+		return 0, fmt.Errorf("can't open migrations: %w", err)
+	}
+	defer src.Close()
+
+	v, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("can't read migrations: %w", err)
+	}
+	for {
+		next, err := src.Next(v)
+		if errors.Is(err, fs.ErrNotExist) {
+			return v, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("can't read migrations: %w", err)
+		}
+		v = next
+	}
+}
+
+// Check - проверяет, что к схеме применена последняя встроенная миграция и она применилась полностью
+func (m *Migrator) Check(ctx context.Context) error {
+	latest, err := m.Latest()
+	if err != nil {
+		return err
+	}
+
+	v, dirty, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkVersion(v, dirty, latest)
+}
+
+func checkVersion(v uint, dirty bool, latest uint) error {
+	if dirty {
+		return fmt.Errorf("%w: migration %d failed, fix the schema and force the version", ErrDirty, v)
+	}
+	if v != latest {
+		return fmt.Errorf("%w: database has version %d, expected %d", ErrVersionMismatch, v, latest)
+	}
+
+	return nil
+}
+
+// withLock - выполняет fn под advisory lock, пока другая реплика применяет миграции, вызов ждёт её завершения
+func (m *Migrator) withLock(ctx context.Context, fn func(mg *migrate.Migrate) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("can't acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return fmt.Errorf("can't acquire migrations lock: %w", err)
+	}
+	defer func() {
+		// блокировка сессии снимается и при закрытии соединения
+		if _, err := conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockID); err != nil {
+			slog.ErrorContext(ctx, "can't release migrations lock", "err", err)
+			_ = conn.Conn().Close(context.Background())
+		}
+	}()
+
+	return m.withMigrate(ctx, fn)
+}
+
+func (m *Migrator) withMigrate(ctx context.Context, fn func(mg *migrate.Migrate) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	src, err := iofs.New(m.source, ".")
+	if err != nil {
+		return fmt.Errorf("can't open migrations: %w", err)
+	}
+
+	driver, err := pgxMigrate.WithInstance(stdlib.OpenDBFromPool(m.pool), &pgxMigrate.Config{})
+	if err != nil {
+		_ = src.Close()
+		return fmt.Errorf("can't create migrate driver: %w", err)
+	}
+
+	mg, err := migrate.NewWithInstance("iofs", src, "pgx5", driver)
+	if err != nil {
+		_ = src.Close()
+		_ = driver.Close()
+		return fmt.Errorf("can't create migrate instance: %w", err)
+	}
+	defer mg.Close()
+
+	// migrate не принимает контекст, поэтому отмена прерывает его между миграциями
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			mg.GracefulStop <- true
+		case <-done:
+		}
+	}()
+
+	return fn(mg)
+}
+
+func version(mg *migrate.Migrate) (uint, bool, error) {
+	v, dirty, err := mg.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("can't get schema version: %w", err)
+	}
+
+	return v, dirty, nil
+}
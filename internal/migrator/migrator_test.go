@@ -0,0 +1,121 @@
+package migrator
+
+import (
+	"context"
+	"homework/pkg/pg_test"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestMigrator_Latest(t *testing.T) {
+	t.Run("ok, embedded migrations", func(t *testing.T) {
+		latest, err := New(nil).Latest()
+		assert.NoError(t, err)
+		assert.Greater(t, latest, uint(0))
+	})
+
+	t.Run("ok, last version", func(t *testing.T) {
+		m := New(nil, WithSource(fstest.MapFS{
+			"000001_a.up.sql":   {},
+			"000001_a.down.sql": {},
+			"000003_b.up.sql":   {},
+			"000003_b.down.sql": {},
+		}))
+
+		latest, err := m.Latest()
+		assert.NoError(t, err)
+		assert.Equal(t, uint(3), latest)
+	})
+
+	t.Run("fail, no migrations", func(t *testing.T) {
+		_, err := New(nil, WithSource(fstest.MapFS{})).Latest()
+		assert.Error(t, err)
+	})
+}
+
+func TestCheckVersion(t *testing.T) {
+	assert.NoError(t, checkVersion(12, false, 12))
+	assert.ErrorIs(t, checkVersion(11, false, 12), ErrVersionMismatch)
+	assert.ErrorIs(t, checkVersion(13, false, 12), ErrVersionMismatch)
+	assert.ErrorIs(t, checkVersion(12, true, 12), ErrDirty)
+}
+
+type MigratorTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	migrator *Migrator
+}
+
+func (suite *MigratorTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.migrator = New(suite.testDbInstance)
+}
+
+func (suite *MigratorTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *MigratorTestSuite) TestMigrator_UpDown() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	latest, err := suite.migrator.Latest()
+	require.NoError(suite.T(), err)
+
+	// база уже мигрирована в pg_test
+	assert.NoError(suite.T(), suite.migrator.Run(ctx, ModeCheck))
+	assert.NoError(suite.T(), suite.migrator.Run(ctx, ModeUp))
+
+	require.NoError(suite.T(), suite.migrator.Down(ctx, 1))
+	v, dirty, err := suite.migrator.Version(ctx)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), dirty)
+	assert.Equal(suite.T(), latest-1, v)
+	assert.ErrorIs(suite.T(), suite.migrator.Run(ctx, ModeCheck), ErrVersionMismatch)
+
+	require.NoError(suite.T(), suite.migrator.Run(ctx, ModeUp))
+	v, _, err = suite.migrator.Version(ctx)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), latest, v)
+}
+
+func (suite *MigratorTestSuite) TestMigrator_ConcurrentUp() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	require.NoError(suite.T(), suite.migrator.Down(ctx, 2))
+
+	// реплики применяют миграции по очереди под advisory lock
+	errs := make(chan error, 3)
+	for range 3 {
+		go func() {
+			errs <- New(suite.testDbInstance).Up(ctx)
+		}()
+	}
+	for range 3 {
+		assert.NoError(suite.T(), <-errs)
+	}
+
+	assert.NoError(suite.T(), suite.migrator.Check(ctx))
+}
+
+func (suite *MigratorTestSuite) TestMigrator_UnknownMode() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.ErrorIs(suite.T(), suite.migrator.Run(ctx, "down"), ErrUnknownMode)
+}
+
+func TestMigratorTestSuite(t *testing.T) {
+	suite.Run(t, new(MigratorTestSuite))
+}
@@ -0,0 +1,132 @@
+// Package ratelimit - ограничение частоты запросов по ключу алгоритмом token bucket
+package ratelimit
+
+import (
+	"homework/internal/domain"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// defaultIdleTTL - время, после которого удаляется корзина ключа без обращений
+const defaultIdleTTL = 10 * time.Minute
+
+// Limit - ограничение частоты: в среднем Rate раз в секунду и до Burst раз подряд. Нулевой Rate - без ограничения.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Unlimited - ограничение не задано
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0
+}
+
+// Limiter - token bucket на каждый ключ. Корзины ключей, к которым не обращались дольше idleTTL, удаляются,
+// к этому времени они всё равно снова заполнены.
+type Limiter struct {
+	idleTTL time.Duration
+	now     func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+type bucket struct {
+	limiter *rate.Limiter
+	used    time.Time
+}
+
+func New() *Limiter {
+	return &Limiter{
+		idleTTL: defaultIdleTTL,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow - забирает токен из корзины key с ограничением limit. Если токенов нет,
+// возвращает false и время, через которое появится следующий.
+func (l *Limiter) Allow(key string, limit Limit) (bool, time.Duration) {
+	if limit.Unlimited() {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst)}
+		l.buckets[key] = b
+	}
+	// ограничение ключа могло измениться, например при смене типа датчика
+	if b.limiter.Limit() != rate.Limit(limit.Rate) || b.limiter.Burst() != limit.Burst {
+		b.limiter.SetLimitAt(now, rate.Limit(limit.Rate))
+		b.limiter.SetBurstAt(now, limit.Burst)
+	}
+	b.used = now
+
+	r := b.limiter.ReserveN(now, 1)
+	if !r.OK() {
+		return false, time.Second
+	}
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+
+	return true, 0
+}
+
+// Len - количество корзин
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.buckets)
+}
+
+// sweep - удаляет корзины без обращений дольше idleTTL, не чаще раза в idleTTL
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < l.idleTTL {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.used) >= l.idleTTL {
+			delete(l.buckets, key)
+		}
+	}
+	l.swept = now
+}
+
+// SensorLimiter - ограничение частоты событий каждого датчика по серийному номеру,
+// реализует usecase.EventRateLimiter. Ограничение выбирается по типу датчика,
+// для типов без своего ограничения и незарегистрированных датчиков действует ограничение по умолчанию.
+type SensorLimiter struct {
+	limiter      *Limiter
+	defaultLimit Limit
+	byType       map[domain.SensorType]Limit
+}
+
+func NewSensorLimiter(defaultLimit Limit, byType map[domain.SensorType]Limit) *SensorLimiter {
+	return &SensorLimiter{
+		limiter:      New(),
+		defaultLimit: defaultLimit,
+		byType:       byType,
+	}
+}
+
+func (s *SensorLimiter) Allow(serialNumber string, sensorType domain.SensorType) (bool, time.Duration) {
+	limit, ok := s.byType[sensorType]
+	if !ok {
+		limit = s.defaultLimit
+	}
+
+	return s.limiter.Allow(serialNumber, limit)
+}
@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock - управляемое время для Limiter
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestLimiter() (*Limiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := New()
+	l.now = clock.Now
+
+	return l, clock
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	t.Run("ok, burst then rate", func(t *testing.T) {
+		l, clock := newTestLimiter()
+		limit := Limit{Rate: 2, Burst: 3}
+
+		for i := 0; i < 3; i++ {
+			ok, _ := l.Allow("a", limit)
+			assert.True(t, ok, "request %d", i)
+		}
+		ok, retryAfter := l.Allow("a", limit)
+		assert.False(t, ok)
+		assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+		// отклонённый запрос не расходует токен
+		clock.now = clock.now.Add(500 * time.Millisecond)
+		ok, _ = l.Allow("a", limit)
+		assert.True(t, ok)
+		ok, _ = l.Allow("a", limit)
+		assert.False(t, ok)
+	})
+
+	t.Run("ok, keys are independent", func(t *testing.T) {
+		l, _ := newTestLimiter()
+		limit := Limit{Rate: 1, Burst: 1}
+
+		ok, _ := l.Allow("a", limit)
+		assert.True(t, ok)
+		ok, _ = l.Allow("a", limit)
+		assert.False(t, ok)
+		ok, _ = l.Allow("b", limit)
+		assert.True(t, ok)
+	})
+
+	t.Run("ok, unlimited", func(t *testing.T) {
+		l, _ := newTestLimiter()
+
+		for i := 0; i < 100; i++ {
+			ok, _ := l.Allow("a", Limit{})
+			assert.True(t, ok)
+		}
+		assert.Equal(t, 0, l.Len())
+	})
+
+	t.Run("ok, limit change is applied", func(t *testing.T) {
+		l, clock := newTestLimiter()
+
+		ok, _ := l.Allow("a", Limit{Rate: 1, Burst: 1})
+		assert.True(t, ok)
+		ok, retryAfter := l.Allow("a", Limit{Rate: 100, Burst: 1})
+		assert.False(t, ok)
+		assert.Equal(t, 10*time.Millisecond, retryAfter)
+
+		clock.now = clock.now.Add(10 * time.Millisecond)
+		ok, _ = l.Allow("a", Limit{Rate: 100, Burst: 1})
+		assert.True(t, ok)
+	})
+
+	t.Run("ok, idle buckets are removed", func(t *testing.T) {
+		l, clock := newTestLimiter()
+		limit := Limit{Rate: 1, Burst: 1}
+
+		l.Allow("a", limit)
+		clock.now = clock.now.Add(defaultIdleTTL / 2)
+		l.Allow("b", limit)
+		assert.Equal(t, 2, l.Len())
+
+		clock.now = clock.now.Add(defaultIdleTTL / 2)
+		l.Allow("b", limit)
+		assert.Equal(t, 1, l.Len())
+	})
+}
+
+func TestSensorLimiter_Allow(t *testing.T) {
+	s := NewSensorLimiter(Limit{Rate: 1, Burst: 1}, map[domain.SensorType]Limit{
+		domain.SensorTypeADC: {Rate: 1, Burst: 3},
+	})
+
+	allowed := func(serialNumber string, sensorType domain.SensorType) int {
+		n := 0
+		for i := 0; i < 5; i++ {
+			if ok, _ := s.Allow(serialNumber, sensorType); ok {
+				n++
+			}
+		}
+		return n
+	}
+
+	assert.Equal(t, 3, allowed("0000000001", domain.SensorTypeADC))
+	assert.Equal(t, 1, allowed("0000000002", domain.SensorTypeCounter))
+	assert.Equal(t, 1, allowed("0000000003", ""))
+}
@@ -0,0 +1,329 @@
+// Package eventtest - общие проверки поведения хранилищ событий, которые запускаются
+// в тестах каждой реализации usecase.EventRepository.
+package eventtest
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Run - функция запуска проверок, newRepository должна возвращать пустое хранилище для каждой проверки
+func Run(t *testing.T, newRepository func(t *testing.T) usecase.EventRepository) {
+	t.Run("SaveEvent", func(t *testing.T) {
+		testSaveEvent(t, newRepository)
+	})
+	t.Run("SaveEvents", func(t *testing.T) {
+		testSaveEvents(t, newRepository)
+	})
+	t.Run("GetLastEventBySensorID", func(t *testing.T) {
+		testGetLastEventBySensorID(t, newRepository)
+	})
+	t.Run("GetStateIntervals", func(t *testing.T) {
+		testGetStateIntervals(t, newRepository(t))
+	})
+	t.Run("DeleteEventsBefore", func(t *testing.T) {
+		testDeleteEventsBefore(t, newRepository(t))
+	})
+	t.Run("GetRollups", func(t *testing.T) {
+		testGetRollups(t, newRepository(t))
+	})
+}
+
+// utcNow - текущее время с точностью хранения postgres
+func utcNow() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func testSaveEvent(t *testing.T, newRepository func(t *testing.T) usecase.EventRepository) {
+	t.Run("err, event is nil", func(t *testing.T) {
+		er := newRepository(t)
+		err := er.SaveEvent(context.Background(), nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		er := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := er.SaveEvent(ctx, &domain.Event{})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("fail, ctx deadline exceeded", func(t *testing.T) {
+		er := newRepository(t)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		defer cancel()
+
+		err := er.SaveEvent(ctx, &domain.Event{})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("ok, save and get one", func(t *testing.T) {
+		er := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		event := &domain.Event{
+			Timestamp:          utcNow(),
+			SensorSerialNumber: "12345",
+			Payload:            0,
+		}
+
+		err := er.SaveEvent(ctx, event)
+		assert.NoError(t, err)
+
+		actualEvent, err := er.GetLastEventBySensorID(ctx, event.SensorID)
+		assert.NoError(t, err)
+		assert.NotNil(t, actualEvent)
+		assert.Equal(t, event.Timestamp, actualEvent.Timestamp)
+		assert.Equal(t, event.SensorSerialNumber, actualEvent.SensorSerialNumber)
+		assert.Equal(t, event.Payload, actualEvent.Payload)
+	})
+
+	t.Run("ok, collision test", func(t *testing.T) {
+		er := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		wg := sync.WaitGroup{}
+		var lastEvent domain.Event
+		for i := 0; i < 1000; i++ {
+			event := &domain.Event{
+				Timestamp:          utcNow(),
+				SensorSerialNumber: "12345",
+				Payload:            0,
+			}
+			lastEvent = *event
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, er.SaveEvent(ctx, event))
+			}()
+		}
+
+		wg.Wait()
+
+		actualEvent, err := er.GetLastEventBySensorID(ctx, lastEvent.SensorID)
+		assert.NoError(t, err)
+		assert.NotNil(t, actualEvent)
+		assert.Equal(t, lastEvent.Timestamp, actualEvent.Timestamp)
+		assert.Equal(t, lastEvent.SensorSerialNumber, actualEvent.SensorSerialNumber)
+		assert.Equal(t, lastEvent.Payload, actualEvent.Payload)
+	})
+}
+
+func testSaveEvents(t *testing.T, newRepository func(t *testing.T) usecase.EventRepository) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		er := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := er.SaveEvents(ctx, []domain.Event{{SensorID: 1}})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("ok, events saved in time order", func(t *testing.T) {
+		er := newRepository(t)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		err := er.SaveEvents(ctx, []domain.Event{
+			{Timestamp: day.Add(time.Minute), SensorID: 1, Payload: 2},
+			{Timestamp: day, SensorID: 1, Payload: 1},
+			{Timestamp: day, SensorID: 2, Payload: 3},
+		})
+		assert.NoError(t, err)
+
+		events, err := er.GetEvents(ctx, 1, day, day.Add(time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.Event{
+			{Timestamp: day, SensorID: 1, Payload: 1},
+			{Timestamp: day.Add(time.Minute), SensorID: 1, Payload: 2},
+		}, events)
+
+		last, err := er.GetLastEventBySensorID(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), last.Payload)
+
+		rollups, err := er.GetRollups(ctx, 2, domain.RollupResolutionHour, day, day.Add(time.Hour))
+		assert.NoError(t, err)
+		assert.Len(t, rollups, 1)
+	})
+}
+
+func testGetLastEventBySensorID(t *testing.T, newRepository func(t *testing.T) usecase.EventRepository) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		er := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := er.GetLastEventBySensorID(ctx, 0)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("fail, ctx deadline exceeded", func(t *testing.T) {
+		er := newRepository(t)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		defer cancel()
+
+		_, err := er.GetLastEventBySensorID(ctx, 0)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("fail, event not found", func(t *testing.T) {
+		er := newRepository(t)
+		_, err := er.GetLastEventBySensorID(context.Background(), 234)
+		assert.ErrorIs(t, err, usecase.ErrEventNotFound)
+	})
+
+	t.Run("ok, save and get one", func(t *testing.T) {
+		er := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sensorID := int64(12345)
+		var lastEvent *domain.Event
+		for i := 0; i < 10; i++ {
+			lastEvent = &domain.Event{
+				Timestamp: utcNow(),
+				SensorID:  sensorID,
+				Payload:   0,
+			}
+			time.Sleep(10 * time.Millisecond)
+			assert.NoError(t, er.SaveEvent(ctx, lastEvent))
+		}
+
+		for i := 0; i < 10; i++ {
+			event := &domain.Event{
+				Timestamp: utcNow(),
+				SensorID:  54321,
+				Payload:   0,
+			}
+			assert.NoError(t, er.SaveEvent(ctx, event))
+		}
+
+		actualEvent, err := er.GetLastEventBySensorID(ctx, lastEvent.SensorID)
+		assert.NoError(t, err)
+		assert.NotNil(t, actualEvent)
+		assert.Equal(t, lastEvent.Timestamp, actualEvent.Timestamp)
+		assert.Equal(t, lastEvent.SensorSerialNumber, actualEvent.SensorSerialNumber)
+		assert.Equal(t, lastEvent.Payload, actualEvent.Payload)
+	})
+}
+
+func testGetStateIntervals(t *testing.T, er usecase.EventRepository) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	// события сохраняются не по порядку
+	for _, event := range []domain.Event{
+		{Timestamp: from.Add(10 * time.Hour), SensorID: 1, Payload: 0},
+		{Timestamp: from.Add(-time.Hour), SensorID: 1, Payload: 0},
+		{Timestamp: from.Add(8 * time.Hour), SensorID: 1, Payload: 1},
+		{Timestamp: from.Add(-2 * time.Hour), SensorID: 1, Payload: 1},
+		{Timestamp: from.Add(25 * time.Hour), SensorID: 1, Payload: 1},
+		{Timestamp: from.Add(time.Hour), SensorID: 2, Payload: 1},
+	} {
+		err := er.SaveEvent(ctx, &event)
+		assert.NoError(t, err)
+	}
+
+	intervals, err := er.GetStateIntervals(ctx, 1, from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.StateInterval{
+		{State: 0, From: from, To: from.Add(8 * time.Hour)},
+		{State: 1, From: from.Add(8 * time.Hour), To: from.Add(10 * time.Hour)},
+		{State: 0, From: from.Add(10 * time.Hour), To: to},
+	}, intervals)
+
+	intervals, err = er.GetStateIntervals(ctx, 3, from, to)
+	assert.NoError(t, err)
+	assert.Empty(t, intervals)
+
+	last, err := er.GetLastEventBySensorID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, from.Add(25*time.Hour), last.Timestamp)
+}
+
+func testDeleteEventsBefore(t *testing.T, er usecase.EventRepository) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := utcNow()
+	for i := 0; i < 5; i++ {
+		err := er.SaveEvent(ctx, &domain.Event{Timestamp: now.Add(time.Duration(i) * time.Hour), SensorID: 1, Payload: int64(i)})
+		assert.NoError(t, err)
+	}
+
+	removed, err := er.DeleteEventsBefore(ctx, 1, now.Add(3*time.Hour), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), removed)
+
+	removed, err = er.DeleteEventsBefore(ctx, 1, now.Add(3*time.Hour), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+
+	removed, err = er.DeleteEventsBefore(ctx, 1, now.Add(3*time.Hour), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), removed)
+
+	intervals, err := er.GetStateIntervals(ctx, 1, now, now.Add(10*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(3*time.Hour), intervals[0].From)
+
+	removed, err = er.DeleteEventsBefore(ctx, 1, now.Add(10*time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), removed)
+
+	// снимок последнего события не удаляется вместе с событиями
+	last, err := er.GetLastEventBySensorID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), last.Payload)
+}
+
+func testGetRollups(t *testing.T, er usecase.EventRepository) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, event := range []domain.Event{
+		{Timestamp: day.Add(10 * time.Minute), SensorID: 1, Payload: 10},
+		{Timestamp: day.Add(20 * time.Minute), SensorID: 1, Payload: 30},
+		{Timestamp: day.Add(90 * time.Minute), SensorID: 1, Payload: -5},
+	} {
+		err := er.SaveEvent(ctx, &event)
+		assert.NoError(t, err)
+	}
+
+	events, err := er.GetEvents(ctx, 1, day, day.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	// свёртки не удаляются вместе с событиями
+	_, err = er.DeleteEventsBefore(ctx, 1, day.Add(24*time.Hour), 10)
+	assert.NoError(t, err)
+
+	hourly, err := er.GetRollups(ctx, 1, domain.RollupResolutionHour, day, day.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Rollup{
+		{SensorID: 1, Bucket: day, Min: 10, Max: 30, Sum: 40, Count: 2, Last: 30, LastAt: day.Add(20 * time.Minute)},
+		{SensorID: 1, Bucket: day.Add(time.Hour), Min: -5, Max: -5, Sum: -5, Count: 1, Last: -5, LastAt: day.Add(90 * time.Minute)},
+	}, hourly)
+
+	daily, err := er.GetRollups(ctx, 1, domain.RollupResolutionDay, day, day.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Rollup{
+		{SensorID: 1, Bucket: day, Min: -5, Max: 30, Sum: 35, Count: 3, Last: -5, LastAt: day.Add(90 * time.Minute)},
+	}, daily)
+}
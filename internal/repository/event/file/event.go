@@ -0,0 +1,177 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/repository/event/inmemory"
+	"homework/pkg/journal"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	opSaveEvents         = "save_events"
+	opDeleteEventsBefore = "delete_events_before"
+)
+
+var (
+	ErrEventNotFound = inmemory.ErrEventNotFound
+	ErrEventIsNil    = inmemory.ErrEventIsNil
+	ErrUnknownOp     = errors.New("unknown journal operation")
+)
+
+// deleteEventsBefore - запись журнала об удалении событий, при повторе удаляет те же события
+type deleteEventsBefore struct {
+	SensorID int64     `json:"sensor_id"`
+	Before   time.Time `json:"before"`
+	Limit    int       `json:"limit"`
+}
+
+// EventRepository - репозиторий событий в локальных файлах каталога dir.
+// События, свёртки и последние события датчиков хранятся в памяти, каждое изменение сначала записывается в журнал.
+// Снимок содержит все события, поэтому объём ограничивается только сроком хранения (см. config.Retention).
+type EventRepository struct {
+	mu      sync.Mutex
+	mem     *inmemory.EventRepository
+	journal *journal.Journal
+}
+
+func NewEventRepository(dir string, options ...func(*journal.Journal)) (*EventRepository, error) {
+	r := &EventRepository{mem: inmemory.NewEventRepository()}
+
+	j, err := journal.Open(dir, "events", r.restore, r.apply, options...)
+	if err != nil {
+		return nil, fmt.Errorf("can't open events journal: %w", err)
+	}
+	r.journal = j
+
+	return r, nil
+}
+
+func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) error {
+	if event == nil {
+		return ErrEventIsNil
+	}
+
+	if err := r.SaveEvents(ctx, []domain.Event{*event}); err != nil {
+		return err
+	}
+	event.Timestamp = event.Timestamp.UTC()
+
+	return nil
+}
+
+func (r *EventRepository) SaveEvents(ctx context.Context, events []domain.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	saved := make([]domain.Event, len(events))
+	for i, event := range events {
+		event.Timestamp = event.Timestamp.UTC()
+		saved[i] = event
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.journal.Append(opSaveEvents, saved); err != nil {
+		return fmt.Errorf("can't save events: %w", err)
+	}
+	if err := r.mem.SaveEvents(context.Background(), saved); err != nil {
+		return err
+	}
+
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error) {
+	return r.mem.GetLastEventBySensorID(ctx, id)
+}
+
+func (r *EventRepository) GetStateIntervals(ctx context.Context, id int64, from, to time.Time) ([]domain.StateInterval, error) {
+	return r.mem.GetStateIntervals(ctx, id, from, to)
+}
+
+func (r *EventRepository) GetEvents(ctx context.Context, id int64, from, to time.Time) ([]domain.Event, error) {
+	return r.mem.GetEvents(ctx, id, from, to)
+}
+
+func (r *EventRepository) GetRollups(ctx context.Context, id int64, resolution domain.RollupResolution, from, to time.Time) ([]domain.Rollup, error) {
+	return r.mem.GetRollups(ctx, id, resolution, from, to)
+}
+
+func (r *EventRepository) DeleteEventsBefore(ctx context.Context, id int64, before time.Time, limit int) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record := deleteEventsBefore{SensorID: id, Before: before.UTC(), Limit: limit}
+	if err := r.journal.Append(opDeleteEventsBefore, record); err != nil {
+		return 0, fmt.Errorf("can't delete events: %w", err)
+	}
+	n, err := r.mem.DeleteEventsBefore(context.Background(), record.SensorID, record.Before, record.Limit)
+	if err != nil {
+		return 0, err
+	}
+
+	r.snapshotIfNeeded(ctx)
+
+	return n, nil
+}
+
+// Close - сохраняет снимок состояния и закрывает журнал
+func (r *EventRepository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return errors.Join(r.journal.Snapshot(r.mem.Snapshot()), r.journal.Close())
+}
+
+func (r *EventRepository) restore(data json.RawMessage) error {
+	var snapshot inmemory.Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("can't unmarshal events: %w", err)
+	}
+	r.mem.Restore(snapshot)
+
+	return nil
+}
+
+func (r *EventRepository) apply(op string, data json.RawMessage) error {
+	switch op {
+	case opSaveEvents:
+		var events []domain.Event
+		if err := json.Unmarshal(data, &events); err != nil {
+			return fmt.Errorf("can't unmarshal events: %w", err)
+		}
+		return r.mem.SaveEvents(context.Background(), events)
+	case opDeleteEventsBefore:
+		var record deleteEventsBefore
+		if err := json.Unmarshal(data, &record); err != nil {
+			return fmt.Errorf("can't unmarshal events deletion: %w", err)
+		}
+		_, err := r.mem.DeleteEventsBefore(context.Background(), record.SensorID, record.Before, record.Limit)
+		return err
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownOp, op)
+	}
+}
+
+func (r *EventRepository) snapshotIfNeeded(ctx context.Context) {
+	if !r.journal.NeedsSnapshot() {
+		return
+	}
+	if err := r.journal.Snapshot(r.mem.Snapshot()); err != nil {
+		slog.ErrorContext(ctx, "can't snapshot events journal", "err", err)
+	}
+}
@@ -0,0 +1,81 @@
+package file
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/repository/event/eventtest"
+	"homework/internal/usecase"
+	"homework/pkg/journal"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEventRepository(t *testing.T) *EventRepository {
+	t.Helper()
+
+	// снимок каждые несколько записей, чтобы тесты проходили и через снимки, и через журнал
+	r, err := NewEventRepository(t.TempDir(), journal.WithSnapshotEvery(3))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, r.Close())
+	})
+
+	return r
+}
+
+func TestEventRepository(t *testing.T) {
+	eventtest.Run(t, func(t *testing.T) usecase.EventRepository {
+		return newEventRepository(t)
+	})
+}
+
+func TestEventRepository_Reopen(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	dir := t.TempDir()
+	er, err := NewEventRepository(dir, journal.WithSnapshotEvery(2))
+	require.NoError(t, err)
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		assert.NoError(t, er.SaveEvent(ctx, &domain.Event{
+			Timestamp:          day.Add(time.Duration(i) * time.Hour),
+			SensorSerialNumber: "0000000001",
+			SensorID:           1,
+			Payload:            int64(i),
+		}))
+	}
+	removed, err := er.DeleteEventsBefore(ctx, 1, day.Add(2*time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), removed)
+	assert.NoError(t, er.SaveEvents(ctx, []domain.Event{
+		{Timestamp: day, SensorSerialNumber: "0000000002", SensorID: 2, Payload: 7},
+	}))
+
+	for _, reopen := range []bool{false, true} {
+		if reopen {
+			assert.NoError(t, er.Close())
+		}
+		er, err = NewEventRepository(dir)
+		require.NoError(t, err)
+
+		events, err := er.GetEvents(ctx, 1, day, day.Add(24*time.Hour))
+		assert.NoError(t, err)
+		assert.Len(t, events, 3)
+		assert.Equal(t, int64(2), events[0].Payload)
+
+		last, err := er.GetLastEventBySensorID(ctx, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), last.Payload)
+
+		// свёртки переживают удаление событий и перезапуск
+		rollups, err := er.GetRollups(ctx, 1, domain.RollupResolutionDay, day, day.Add(24*time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.Rollup{{SensorID: 1, Bucket: day, Min: 0, Max: 4, Sum: 10, Count: 5, Last: 4, LastAt: day.Add(4 * time.Hour)}}, rollups)
+	}
+	assert.NoError(t, er.Close())
+}
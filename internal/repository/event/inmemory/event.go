@@ -4,24 +4,316 @@ import (
 	"context"
 	"errors"
 	"homework/internal/domain"
+	"homework/internal/usecase"
+	"sort"
+	"sync"
+	"time"
 )
 
-var ErrEventNotFound = errors.New("event not found")
+var (
+	ErrEventNotFound = usecase.ErrEventNotFound
+	ErrEventIsNil    = errors.New("event is nil")
+)
+
+var rollupResolutions = []domain.RollupResolution{domain.RollupResolutionHour, domain.RollupResolutionDay}
+
+// Snapshot - состояние репозитория событий
+type Snapshot struct {
+	// Events - события по возрастанию ID датчика и времени
+	Events []domain.Event `json:"events"`
+	// Last - последние события датчиков, в том числе уже удалённые из Events
+	Last []domain.Event `json:"last"`
+	// Rollups - свёртки по разрешению
+	Rollups map[domain.RollupResolution][]domain.Rollup `json:"rollups"`
+}
 
 type EventRepository struct {
-	// TODO добавьте реализацию
+	mu sync.RWMutex
+	// events - события по ID датчика, отсортированные по времени
+	events map[int64][]domain.Event
+	// last - снимок последнего события датчика, как и свёртки, не удаляется вместе с событиями
+	last map[int64]domain.Event
+	// rollups - свёртки по разрешению, ID датчика и началу интервала
+	rollups map[domain.RollupResolution]map[int64]map[time.Time]domain.Rollup
 }
 
 func NewEventRepository() *EventRepository {
-	return &EventRepository{}
+	rollups := make(map[domain.RollupResolution]map[int64]map[time.Time]domain.Rollup)
+	for _, resolution := range rollupResolutions {
+		rollups[resolution] = make(map[int64]map[time.Time]domain.Rollup)
+	}
+
+	return &EventRepository{
+		events:  make(map[int64][]domain.Event),
+		last:    make(map[int64]domain.Event),
+		rollups: rollups,
+	}
 }
 
 func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) error {
-	// TODO добавьте реализацию
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if event == nil {
+		return ErrEventIsNil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.saveEvent(event)
+
 	return nil
 }
 
+func (r *EventRepository) SaveEvents(ctx context.Context, events []domain.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range events {
+		r.saveEvent(&events[i])
+	}
+
+	return nil
+}
+
+func (r *EventRepository) saveEvent(event *domain.Event) {
+	// обычно события приходят по порядку и вставляются в конец
+	events := r.events[event.SensorID]
+	i := sort.Search(len(events), func(i int) bool {
+		return events[i].Timestamp.After(event.Timestamp)
+	})
+	events = append(events, domain.Event{})
+	copy(events[i+1:], events[i:])
+	events[i] = *event
+	r.events[event.SensorID] = events
+
+	if last, ok := r.last[event.SensorID]; !ok || !event.Timestamp.Before(last.Timestamp) {
+		r.last[event.SensorID] = *event
+	}
+	r.addToRollups(event)
+}
+
+func (r *EventRepository) addToRollups(event *domain.Event) {
+	for _, resolution := range rollupResolutions {
+		buckets, ok := r.rollups[resolution][event.SensorID]
+		if !ok {
+			buckets = make(map[time.Time]domain.Rollup)
+			r.rollups[resolution][event.SensorID] = buckets
+		}
+
+		bucket := event.Timestamp.UTC().Truncate(resolution.Duration())
+		rollup, ok := buckets[bucket]
+		if !ok {
+			rollup = domain.Rollup{SensorID: event.SensorID, Bucket: bucket}
+		}
+		rollup.Add(event.Timestamp.UTC(), event.Payload)
+		buckets[bucket] = rollup
+	}
+}
+
 func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error) {
-	// TODO добавьте реализацию
-	return nil, nil
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	event, ok := r.last[id]
+	if !ok {
+		return nil, ErrEventNotFound
+	}
+
+	return &event, nil
+}
+
+func (r *EventRepository) GetEvents(ctx context.Context, id int64, from, to time.Time) ([]domain.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := r.events[id]
+	start := sort.Search(len(events), func(i int) bool {
+		return !events[i].Timestamp.Before(from)
+	})
+	end := sort.Search(len(events), func(i int) bool {
+		return !events[i].Timestamp.Before(to)
+	})
+	if start >= end {
+		return []domain.Event{}, nil
+	}
+
+	return append([]domain.Event(nil), events[start:end]...), nil
+}
+
+func (r *EventRepository) GetRollups(ctx context.Context, id int64, resolution domain.RollupResolution, from, to time.Time) ([]domain.Rollup, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rollups := make([]domain.Rollup, 0)
+	for bucket, rollup := range r.rollups[resolution][id] {
+		if !bucket.Before(from) && bucket.Before(to) {
+			rollups = append(rollups, rollup)
+		}
+	}
+	sort.Slice(rollups, func(i, j int) bool {
+		return rollups[i].Bucket.Before(rollups[j].Bucket)
+	})
+
+	return rollups, nil
+}
+
+func (r *EventRepository) GetStateIntervals(ctx context.Context, id int64, from, to time.Time) ([]domain.StateInterval, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := r.events[id]
+	// последнее событие не позже from задаёт начальное состояние
+	start := sort.Search(len(events), func(i int) bool {
+		return events[i].Timestamp.After(from)
+	})
+	if start > 0 {
+		start--
+	}
+	end := sort.Search(len(events), func(i int) bool {
+		return !events[i].Timestamp.Before(to)
+	})
+
+	return domain.NewStateIntervals(events[start:end], from, to), nil
+}
+
+func (r *EventRepository) DeleteEventsBefore(ctx context.Context, id int64, before time.Time, limit int) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := r.events[id]
+	n := sort.Search(len(events), func(i int) bool {
+		return !events[i].Timestamp.Before(before)
+	})
+	if n > limit {
+		n = limit
+	}
+	if n == 0 {
+		return 0, nil
+	}
+
+	if n == len(events) {
+		delete(r.events, id)
+		return int64(n), nil
+	}
+	// копирование, чтобы не держать удалённые события в памяти через общий массив
+	r.events[id] = append([]domain.Event(nil), events[n:]...)
+
+	return int64(n), nil
+}
+
+// Snapshot - функция получения копии состояния репозитория
+func (r *EventRepository) Snapshot() Snapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshot := Snapshot{
+		Events:  make([]domain.Event, 0),
+		Last:    make([]domain.Event, 0, len(r.last)),
+		Rollups: make(map[domain.RollupResolution][]domain.Rollup, len(r.rollups)),
+	}
+	for _, id := range sortedKeys(r.events) {
+		snapshot.Events = append(snapshot.Events, r.events[id]...)
+	}
+	for _, id := range sortedKeys(r.last) {
+		snapshot.Last = append(snapshot.Last, r.last[id])
+	}
+	for resolution, bySensor := range r.rollups {
+		rollups := make([]domain.Rollup, 0)
+		for _, id := range sortedKeys(bySensor) {
+			for _, rollup := range bySensor[id] {
+				rollups = append(rollups, rollup)
+			}
+		}
+		sort.SliceStable(rollups, func(i, j int) bool {
+			if rollups[i].SensorID != rollups[j].SensorID {
+				return rollups[i].SensorID < rollups[j].SensorID
+			}
+			return rollups[i].Bucket.Before(rollups[j].Bucket)
+		})
+		snapshot.Rollups[resolution] = rollups
+	}
+
+	return snapshot
+}
+
+// Restore - заменяет состояние репозитория снимком, полученным из Snapshot
+func (r *EventRepository) Restore(snapshot Snapshot) {
+	events := make(map[int64][]domain.Event)
+	for _, event := range snapshot.Events {
+		events[event.SensorID] = append(events[event.SensorID], event)
+	}
+	for _, byTime := range events {
+		sort.SliceStable(byTime, func(i, j int) bool {
+			return byTime[i].Timestamp.Before(byTime[j].Timestamp)
+		})
+	}
+
+	last := make(map[int64]domain.Event, len(snapshot.Last))
+	for _, event := range snapshot.Last {
+		last[event.SensorID] = event
+	}
+
+	rollups := make(map[domain.RollupResolution]map[int64]map[time.Time]domain.Rollup)
+	for _, resolution := range rollupResolutions {
+		rollups[resolution] = make(map[int64]map[time.Time]domain.Rollup)
+	}
+	for resolution, list := range snapshot.Rollups {
+		bySensor, ok := rollups[resolution]
+		if !ok {
+			continue
+		}
+		for _, rollup := range list {
+			buckets, ok := bySensor[rollup.SensorID]
+			if !ok {
+				buckets = make(map[time.Time]domain.Rollup)
+				bySensor[rollup.SensorID] = buckets
+			}
+			buckets[rollup.Bucket.UTC()] = rollup
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = events
+	r.last = last
+	r.rollups = rollups
+}
+
+func sortedKeys[V any](m map[int64]V) []int64 {
+	keys := make([]int64, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+
+	return keys
 }
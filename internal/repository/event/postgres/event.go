@@ -3,12 +3,83 @@ package postgres
 import (
 	"context"
 	"errors"
+	"fmt"
 	"homework/internal/domain"
+	"homework/internal/usecase"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-var ErrEventNotFound = errors.New("event not found")
+var (
+	ErrEventNotFound           = usecase.ErrEventNotFound
+	ErrEventIsNil              = errors.New("event is nil")
+	ErrUnknownRollupResolution = errors.New("unknown rollup resolution")
+)
+
+const (
+	// insertEventQuery - событие и снимок последнего события датчика сохраняются одним запросом,
+	// снимок заменяется только более новым событием; колонка timestamp без часового пояса,
+	// поэтому время передаётся в UTC
+	insertEventQuery = `with inserted as (
+			insert into events (timestamp, sensor_serial_number, sensor_id, payload)
+			values ($1, $2, $3, $4)
+			returning sensor_id, timestamp, sensor_serial_number, payload
+		)
+		insert into sensor_last_event as l (sensor_id, timestamp, sensor_serial_number, payload)
+		select sensor_id, timestamp, sensor_serial_number, payload from inserted
+		on conflict (sensor_id) do update
+			set timestamp = excluded.timestamp, sensor_serial_number = excluded.sensor_serial_number, payload = excluded.payload
+			where l.timestamp <= excluded.timestamp`
+	// selectLastEventBySensorIDQuery - поиск по первичному ключу, снимок не удаляется вместе с событиями
+	selectLastEventBySensorIDQuery = `select timestamp, sensor_serial_number, sensor_id, payload
+		from sensor_last_event
+		where sensor_id = $1`
+	selectEventsQuery = `select timestamp, sensor_serial_number, sensor_id, payload
+		from events
+		where sensor_id = $1 and timestamp >= $2 and timestamp < $3
+		order by timestamp`
+	// время последнего события неизвестно для свёрток, события которых удалены до его появления
+	selectHourlyRollupsQuery = `select sensor_id, bucket, min, max, sum, count, last, coalesce(last_at, bucket)
+		from events_hourly
+		where sensor_id = $1 and bucket >= $2 and bucket < $3
+		order by bucket`
+	selectDailyRollupsQuery = `select sensor_id, bucket, min, max, sum, count, last, coalesce(last_at, bucket)
+		from events_daily
+		where sensor_id = $1 and bucket >= $2 and bucket < $3
+		order by bucket`
+	// deleteEventsBeforeQuery - удаление пачки событий, tableoid нужен, т.к. ctid уникален только в пределах таблицы
+	deleteEventsBeforeQuery = `delete from events
+		where (tableoid, ctid) in (
+			select tableoid, ctid
+			from events
+			where sensor_id = $1 and timestamp < $2
+			limit $3
+		)`
+	// selectStateIntervalsQuery - интервалы состояний за [$2, $3): начальное состояние берётся из последнего события
+	// не позже $2, из событий оставляются только смены значения, конец интервала - время следующей смены
+	selectStateIntervalsQuery = `with initial as (
+			select timestamp, payload
+			from events
+			where sensor_id = $1 and timestamp <= $2
+			order by timestamp desc
+			limit 1
+		), changes as (
+			select timestamp, payload, lag(payload) over (order by timestamp) as previous
+			from (
+				select timestamp, payload from initial
+				union all
+				select timestamp, payload
+				from events
+				where sensor_id = $1 and timestamp > $2 and timestamp < $3
+			) e
+		)
+		select payload, greatest(timestamp, $2), coalesce(lead(timestamp) over (order by timestamp), $3)
+		from changes
+		where previous is null or previous <> payload
+		order by timestamp`
+)
 
 type EventRepository struct {
 	pool *pgxpool.Pool
@@ -21,11 +92,140 @@ func NewEventRepository(pool *pgxpool.Pool) *EventRepository {
 }
 
 func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) error {
-	// TODO добавьте реализацию
+	if event == nil {
+		return ErrEventIsNil
+	}
+
+	_, err := r.pool.Exec(ctx, insertEventQuery, event.Timestamp.UTC(), event.SensorSerialNumber, event.SensorID, event.Payload)
+	if err != nil {
+		return fmt.Errorf("can't insert event: %w", err)
+	}
+
+	return nil
+}
+
+// SaveEvents - сохраняет события одним пакетом запросов в одной транзакции
+func (r *EventRepository) SaveEvents(ctx context.Context, events []domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, event := range events {
+		batch.Queue(insertEventQuery, event.Timestamp.UTC(), event.SensorSerialNumber, event.SensorID, event.Payload)
+	}
+
+	results := r.pool.SendBatch(ctx, batch)
+	for range events {
+		if _, err := results.Exec(); err != nil {
+			_ = results.Close()
+			return fmt.Errorf("can't insert events: %w", err)
+		}
+	}
+	if err := results.Close(); err != nil {
+		return fmt.Errorf("can't insert events: %w", err)
+	}
+
 	return nil
 }
 
 func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error) {
-	// TODO добавьте реализацию
-	return nil, nil
+	var event domain.Event
+
+	err := r.pool.QueryRow(ctx, selectLastEventBySensorIDQuery, id).
+		Scan(&event.Timestamp, &event.SensorSerialNumber, &event.SensorID, &event.Payload)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrEventNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't select last event: %w", err)
+	}
+
+	return &event, nil
+}
+
+func (r *EventRepository) GetStateIntervals(ctx context.Context, id int64, from, to time.Time) ([]domain.StateInterval, error) {
+	rows, err := r.pool.Query(ctx, selectStateIntervalsQuery, id, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("can't select state intervals: %w", err)
+	}
+	defer rows.Close()
+
+	intervals := make([]domain.StateInterval, 0)
+	for rows.Next() {
+		var interval domain.StateInterval
+		if err := rows.Scan(&interval.State, &interval.From, &interval.To); err != nil {
+			return nil, fmt.Errorf("can't scan state interval: %w", err)
+		}
+		intervals = append(intervals, interval)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't select state intervals: %w", err)
+	}
+
+	return intervals, nil
+}
+
+func (r *EventRepository) GetEvents(ctx context.Context, id int64, from, to time.Time) ([]domain.Event, error) {
+	rows, err := r.pool.Query(ctx, selectEventsQuery, id, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("can't select events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]domain.Event, 0)
+	for rows.Next() {
+		var event domain.Event
+		if err := rows.Scan(&event.Timestamp, &event.SensorSerialNumber, &event.SensorID, &event.Payload); err != nil {
+			return nil, fmt.Errorf("can't scan event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't select events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *EventRepository) GetRollups(ctx context.Context, id int64, resolution domain.RollupResolution, from, to time.Time) ([]domain.Rollup, error) {
+	var query string
+	switch resolution {
+	case domain.RollupResolutionHour:
+		query = selectHourlyRollupsQuery
+	case domain.RollupResolutionDay:
+		query = selectDailyRollupsQuery
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownRollupResolution, resolution)
+	}
+
+	rows, err := r.pool.Query(ctx, query, id, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("can't select rollups: %w", err)
+	}
+	defer rows.Close()
+
+	rollups := make([]domain.Rollup, 0)
+	for rows.Next() {
+		var rollup domain.Rollup
+		err := rows.Scan(&rollup.SensorID, &rollup.Bucket, &rollup.Min, &rollup.Max, &rollup.Sum, &rollup.Count, &rollup.Last, &rollup.LastAt)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan rollup: %w", err)
+		}
+		rollups = append(rollups, rollup)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't select rollups: %w", err)
+	}
+
+	return rollups, nil
+}
+
+func (r *EventRepository) DeleteEventsBefore(ctx context.Context, id int64, before time.Time, limit int) (int64, error) {
+	tag, err := r.pool.Exec(ctx, deleteEventsBeforeQuery, id, before.UTC(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("can't delete events: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
 }
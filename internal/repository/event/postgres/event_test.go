@@ -3,12 +3,15 @@ package postgres
 import (
 	"context"
 	"homework/internal/domain"
+	"homework/internal/repository/event/eventtest"
+	"homework/internal/usecase"
 	"homework/pkg/pg_test"
 	"testing"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -25,12 +28,33 @@ func (suite *EventTestSuite) SetupSuite() {
 	suite.testDbInstance = suite.testDB.DbInstance
 
 	suite.repo = NewEventRepository(suite.testDbInstance)
+
+	// миграция создаёт секции только вокруг текущего месяца, тесты пишут события и в 2024 год
+	_, err := NewPartitionManager(suite.testDbInstance).
+		EnsurePartitions(context.Background(), time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), time.Now())
+	suite.Require().NoError(err)
 }
 
 func (suite *EventTestSuite) TearDownSuite() {
 	suite.testDB.TearDown()
 }
 
+// TestEventRepository_Behaviour - общие проверки хранилищ событий, каждая на пустых таблицах,
+// после проверок таблицы остаются пустыми для остальных тестов набора
+func (suite *EventTestSuite) TestEventRepository_Behaviour() {
+	eventtest.Run(suite.T(), func(t *testing.T) usecase.EventRepository {
+		truncate := func() {
+			_, err := suite.testDbInstance.Exec(context.Background(),
+				`truncate events, sensor_last_event, events_hourly, events_daily`)
+			require.NoError(t, err)
+		}
+		truncate()
+		t.Cleanup(truncate)
+
+		return suite.repo
+	})
+}
+
 func (suite *EventTestSuite) TestEventRepository_SaveEvent() {
 	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second) //nolint: govet // test stub
 
@@ -73,6 +97,226 @@ func (suite *EventTestSuite) TestEventRepository_GetLastEventBySensorID() {
 	assert.Equal(suite.T(), secondEvent, *event)
 }
 
+func (suite *EventTestSuite) TestEventRepository_GetStateIntervals() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	for _, event := range []domain.Event{
+		{Timestamp: from.Add(-time.Hour), SensorSerialNumber: "1111111111", SensorID: 10, Payload: 0},
+		{Timestamp: from.Add(8 * time.Hour), SensorSerialNumber: "1111111111", SensorID: 10, Payload: 1},
+		{Timestamp: from.Add(9 * time.Hour), SensorSerialNumber: "1111111111", SensorID: 10, Payload: 1},
+		{Timestamp: from.Add(10 * time.Hour), SensorSerialNumber: "1111111111", SensorID: 10, Payload: 0},
+		{Timestamp: from.Add(25 * time.Hour), SensorSerialNumber: "1111111111", SensorID: 10, Payload: 1},
+	} {
+		err := suite.repo.SaveEvent(ctx, &event)
+		assert.Nil(suite.T(), err)
+	}
+
+	intervals, err := suite.repo.GetStateIntervals(ctx, 10, from, to)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []domain.StateInterval{
+		{State: 0, From: from, To: from.Add(8 * time.Hour)},
+		{State: 1, From: from.Add(8 * time.Hour), To: from.Add(10 * time.Hour)},
+		{State: 0, From: from.Add(10 * time.Hour), To: to},
+	}, intervals)
+}
+
+func (suite *EventTestSuite) TestEventRepository_DeleteEventsBefore() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	for i := 0; i < 3; i++ {
+		err := suite.repo.SaveEvent(ctx, &domain.Event{
+			Timestamp:          now.Add(time.Duration(i) * time.Hour),
+			SensorSerialNumber: "2222222222",
+			SensorID:           20,
+			Payload:            int64(i),
+		})
+		assert.Nil(suite.T(), err)
+	}
+
+	removed, err := suite.repo.DeleteEventsBefore(ctx, 20, now.Add(2*time.Hour), 1)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), removed)
+
+	removed, err = suite.repo.DeleteEventsBefore(ctx, 20, now.Add(2*time.Hour), 10)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), removed)
+
+	event, err := suite.repo.GetLastEventBySensorID(ctx, 20)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), int64(2), event.Payload)
+}
+
+func (suite *EventTestSuite) TestEventRepository_GetRollups() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, event := range []domain.Event{
+		{Timestamp: day.Add(10 * time.Minute), SensorSerialNumber: "3333333333", SensorID: 30, Payload: 10},
+		{Timestamp: day.Add(20 * time.Minute), SensorSerialNumber: "3333333333", SensorID: 30, Payload: 30},
+		{Timestamp: day.Add(90 * time.Minute), SensorSerialNumber: "3333333333", SensorID: 30, Payload: -5},
+	} {
+		err := suite.repo.SaveEvent(ctx, &event)
+		assert.Nil(suite.T(), err)
+	}
+
+	events, err := suite.repo.GetEvents(ctx, 30, day, day.Add(time.Hour))
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), events, 2)
+
+	_, err = suite.repo.DeleteEventsBefore(ctx, 30, day.Add(24*time.Hour), 10)
+	assert.Nil(suite.T(), err)
+
+	hourly, err := suite.repo.GetRollups(ctx, 30, domain.RollupResolutionHour, day, day.Add(24*time.Hour))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []domain.Rollup{
+		{SensorID: 30, Bucket: day, Min: 10, Max: 30, Sum: 40, Count: 2, Last: 30, LastAt: day.Add(20 * time.Minute)},
+		{SensorID: 30, Bucket: day.Add(time.Hour), Min: -5, Max: -5, Sum: -5, Count: 1, Last: -5, LastAt: day.Add(90 * time.Minute)},
+	}, hourly)
+
+	daily, err := suite.repo.GetRollups(ctx, 30, domain.RollupResolutionDay, day, day.Add(24*time.Hour))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []domain.Rollup{
+		{SensorID: 30, Bucket: day, Min: -5, Max: 30, Sum: 35, Count: 3, Last: -5, LastAt: day.Add(90 * time.Minute)},
+	}, daily)
+}
+
+func (suite *EventTestSuite) TestEventRepository_SaveEventInLocalZone() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	day := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	zone := time.FixedZone("UTC+3", 3*60*60)
+	event := domain.Event{Timestamp: day.Add(time.Hour).In(zone), SensorSerialNumber: "6666666666", SensorID: 60, Payload: 1}
+	err := suite.repo.SaveEvent(ctx, &event)
+	assert.Nil(suite.T(), err)
+
+	err = suite.repo.SaveEvents(ctx, []domain.Event{
+		{Timestamp: day.Add(2 * time.Hour).In(zone), SensorSerialNumber: "6666666666", SensorID: 60, Payload: 2},
+	})
+	assert.Nil(suite.T(), err)
+
+	events, err := suite.repo.GetEvents(ctx, 60, day.Add(time.Hour), day.Add(3*time.Hour))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []domain.Event{
+		{Timestamp: day.Add(time.Hour), SensorSerialNumber: "6666666666", SensorID: 60, Payload: 1},
+		{Timestamp: day.Add(2 * time.Hour), SensorSerialNumber: "6666666666", SensorID: 60, Payload: 2},
+	}, events)
+}
+
+func (suite *EventTestSuite) TestEventRepository_SaveEvents() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	events := []domain.Event{
+		{Timestamp: day, SensorSerialNumber: "4444444444", SensorID: 40, Payload: 1},
+		{Timestamp: day.Add(time.Minute), SensorSerialNumber: "4444444444", SensorID: 40, Payload: 2},
+		{Timestamp: day.Add(time.Minute), SensorSerialNumber: "5555555555", SensorID: 41, Payload: 3},
+	}
+
+	err := suite.repo.SaveEvents(ctx, events)
+	assert.Nil(suite.T(), err)
+
+	saved, err := suite.repo.GetEvents(ctx, 40, day, day.Add(time.Hour))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), events[:2], saved)
+
+	event, err := suite.repo.GetLastEventBySensorID(ctx, 41)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), events[2], *event)
+
+	// события без месячной секции сохраняются в секцию по умолчанию и не обрывают пакет
+	old := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	err = suite.repo.SaveEvents(ctx, []domain.Event{
+		{Timestamp: day.Add(2 * time.Minute), SensorSerialNumber: "4444444444", SensorID: 40, Payload: 4},
+		{Timestamp: old, SensorSerialNumber: "4444444444", SensorID: 40, Payload: 5},
+	})
+	assert.Nil(suite.T(), err)
+
+	saved, err = suite.repo.GetEvents(ctx, 40, day, day.Add(time.Hour))
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), saved, 3)
+
+	saved, err = suite.repo.GetEvents(ctx, 40, old, old.Add(time.Hour))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []domain.Event{
+		{Timestamp: old, SensorSerialNumber: "4444444444", SensorID: 40, Payload: 5},
+	}, saved)
+}
+
 func TestEventTestSuite(t *testing.T) {
 	suite.Run(t, new(EventTestSuite))
 }
+
+// selectLastEventFromEventsQuery - поиск последнего события по таблице events, для сравнения со снимком
+const selectLastEventFromEventsQuery = `select timestamp, sensor_serial_number, sensor_id, payload
+	from events
+	where sensor_id = $1
+	order by timestamp desc
+	limit 1`
+
+// BenchmarkEventRepository_GetLastEventBySensorID - сравнение поиска по снимку sensor_last_event
+// с поиском по секционированной таблице events на 1000 датчиков по 1000 событий
+func BenchmarkEventRepository_GetLastEventBySensorID(b *testing.B) {
+	const (
+		sensors         = 1000
+		eventsPerSensor = 1000
+	)
+
+	testDB := pg_test.SetupTestDatabase()
+	defer testDB.TearDown()
+
+	ctx := context.Background()
+	pool := testDB.DbInstance
+	repo := NewEventRepository(pool)
+
+	now := time.Now().UTC()
+	if _, err := NewPartitionManager(pool).EnsurePartitions(ctx, now.AddDate(0, -12, 0), now); err != nil {
+		b.Fatal(err)
+	}
+
+	// события за последний год, снимок заполняется так же, как при миграции
+	_, err := pool.Exec(ctx, `insert into events (timestamp, sensor_serial_number, sensor_id, payload)
+		select $1::timestamp - make_interval(mins => e * 500), lpad(s::text, 10, '0'), s, e
+		from generate_series(1, $2::int) s, generate_series(1, $3::int) e`, now, sensors, eventsPerSensor)
+	if err != nil {
+		b.Fatal(err)
+	}
+	_, err = pool.Exec(ctx, `insert into sensor_last_event (sensor_id, timestamp, sensor_serial_number, payload)
+		select distinct on (sensor_id) sensor_id, timestamp, sensor_serial_number, payload
+		from events
+		order by sensor_id, timestamp desc`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if _, err := pool.Exec(ctx, `analyze`); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("sensor_last_event", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.GetLastEventBySensorID(ctx, int64(i%sensors+1)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("events", func(b *testing.B) {
+		var event domain.Event
+		for i := 0; i < b.N; i++ {
+			err := pool.QueryRow(ctx, selectLastEventFromEventsQuery, int64(i%sensors+1)).
+				Scan(&event.Timestamp, &event.SensorSerialNumber, &event.SensorID, &event.Payload)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// partitionNameLayout - имя месячной секции events, например events_y2024m01
+const partitionNameLayout = "events_y2006m01"
+
+// defaultPartition - секция по умолчанию для событий вне месячных секций
+const defaultPartition = "events_default"
+
+const (
+	selectPartitionsQuery = `select c.relname
+		from pg_inherits i
+			join pg_class c on c.oid = i.inhrelid
+		where i.inhparent = 'events'::regclass`
+	// lockDefaultPartitionQuery - вставка в секцию по умолчанию блокируется до присоединения новой секции,
+	// иначе присоединение не пройдёт проверку пересечения диапазонов
+	lockDefaultPartitionQuery = `lock table ` + defaultPartition + ` in share row exclusive mode`
+	partitionExistsQuery      = `select to_regclass($1) is not null`
+	// новая секция заполняется событиями из секции по умолчанию до присоединения,
+	// поэтому триггер свёрток, которые уже учитывают эти события, не срабатывает
+	createPartitionQuery = `create table %s (like events)`
+	movePartitionQuery   = `with moved as (
+			delete from ` + defaultPartition + `
+			where timestamp >= $1 and timestamp < $2
+			returning timestamp, sensor_serial_number, sensor_id, payload
+		)
+		insert into %s (timestamp, sensor_serial_number, sensor_id, payload)
+		select timestamp, sensor_serial_number, sensor_id, payload from moved`
+	attachPartitionQuery        = `alter table events attach partition %s for values from ('%s') to ('%s')`
+	dropPartitionQuery          = `drop table if exists %s`
+	deleteDefaultPartitionQuery = `delete from ` + defaultPartition + ` where timestamp < $1`
+)
+
+// PartitionManager - управление месячными секциями таблицы events
+type PartitionManager struct {
+	pool *pgxpool.Pool
+}
+
+func NewPartitionManager(pool *pgxpool.Pool) *PartitionManager {
+	return &PartitionManager{
+		pool: pool,
+	}
+}
+
+// Partitions - функция получения начала месяцев, для которых есть секции, по возрастанию
+func (m *PartitionManager) Partitions(ctx context.Context) ([]time.Time, error) {
+	rows, err := m.pool.Query(ctx, selectPartitionsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("can't select partitions: %w", err)
+	}
+	defer rows.Close()
+
+	months := make([]time.Time, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("can't scan partition: %w", err)
+		}
+		// секции, созданные не по соглашению об именах, не трогаем
+		month, err := time.Parse(partitionNameLayout, name)
+		if err != nil {
+			continue
+		}
+		months = append(months, month)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't select partitions: %w", err)
+	}
+
+	sort.Slice(months, func(i, j int) bool {
+		return months[i].Before(months[j])
+	})
+
+	return months, nil
+}
+
+// EnsurePartitions - функция создания недостающих секций для месяцев с from по to включительно,
+// возвращает начала месяцев созданных секций
+func (m *PartitionManager) EnsurePartitions(ctx context.Context, from, to time.Time) ([]time.Time, error) {
+	existing, err := m.Partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[time.Time]bool, len(existing))
+	for _, month := range existing {
+		exists[month] = true
+	}
+
+	created := make([]time.Time, 0)
+	for month := monthStart(from); !month.After(monthStart(to)); month = month.AddDate(0, 1, 0) {
+		if exists[month] {
+			continue
+		}
+
+		ok, err := m.createPartition(ctx, month)
+		if err != nil {
+			return created, fmt.Errorf("can't create partition %s: %w", partitionName(month), err)
+		}
+		if ok {
+			created = append(created, month)
+		}
+	}
+
+	return created, nil
+}
+
+// createPartition - функция создания секции месяца с переносом в неё событий из секции по умолчанию,
+// возвращает false, если секцию уже создали
+func (m *PartitionManager) createPartition(ctx context.Context, month time.Time) (bool, error) {
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("can't begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := tx.Exec(ctx, lockDefaultPartitionQuery); err != nil {
+		return false, fmt.Errorf("can't lock default partition: %w", err)
+	}
+
+	// секцию мог создать другой экземпляр сервиса, пока ждали блокировку
+	name := partitionName(month)
+	var exists bool
+	if err := tx.QueryRow(ctx, partitionExistsQuery, name).Scan(&exists); err != nil {
+		return false, fmt.Errorf("can't check partition: %w", err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	next := month.AddDate(0, 1, 0)
+	if _, err := tx.Exec(ctx, fmt.Sprintf(createPartitionQuery, name)); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf(movePartitionQuery, name), month, next); err != nil {
+		return false, fmt.Errorf("can't move events from default partition: %w", err)
+	}
+	query := fmt.Sprintf(attachPartitionQuery, name, month.Format(time.DateOnly), next.Format(time.DateOnly))
+	if _, err := tx.Exec(ctx, query); err != nil {
+		return false, fmt.Errorf("can't attach partition: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("can't commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// DropPartitionsBefore - функция удаления секций, все события которых старше before, и событий
+// старше before из секции по умолчанию, возвращает начала месяцев удалённых секций
+func (m *PartitionManager) DropPartitionsBefore(ctx context.Context, before time.Time) ([]time.Time, error) {
+	existing, err := m.Partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := m.pool.Exec(ctx, deleteDefaultPartitionQuery, before.UTC()); err != nil {
+		return nil, fmt.Errorf("can't delete events from default partition: %w", err)
+	}
+
+	dropped := make([]time.Time, 0)
+	for _, month := range existing {
+		if month.AddDate(0, 1, 0).After(before.UTC()) {
+			break
+		}
+
+		if _, err := m.pool.Exec(ctx, fmt.Sprintf(dropPartitionQuery, partitionName(month))); err != nil {
+			return dropped, fmt.Errorf("can't drop partition %s: %w", partitionName(month), err)
+		}
+		dropped = append(dropped, month)
+	}
+
+	return dropped, nil
+}
+
+func partitionName(month time.Time) string {
+	return pgx.Identifier{month.Format(partitionNameLayout)}.Sanitize()
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
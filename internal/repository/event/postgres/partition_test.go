@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/pkg/pg_test"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type PartitionTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	manager *PartitionManager
+	repo    *EventRepository
+}
+
+func (suite *PartitionTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.manager = NewPartitionManager(suite.testDbInstance)
+	suite.repo = NewEventRepository(suite.testDbInstance)
+}
+
+func (suite *PartitionTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *PartitionTestSuite) TestPartitionManager() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	january := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	created, err := suite.manager.EnsurePartitions(ctx, january.Add(10*24*time.Hour), january.AddDate(0, 1, 5))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []time.Time{january, january.AddDate(0, 1, 0)}, created)
+
+	created, err = suite.manager.EnsurePartitions(ctx, january, january)
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), created)
+
+	// события в разных секциях
+	for _, ts := range []time.Time{january.Add(time.Hour), january.AddDate(0, 1, 0).Add(time.Hour)} {
+		err := suite.repo.SaveEvent(ctx, &domain.Event{Timestamp: ts, SensorSerialNumber: "1234567890", SensorID: 1, Payload: 1})
+		assert.Nil(suite.T(), err)
+	}
+	event, err := suite.repo.GetLastEventBySensorID(ctx, 1)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), january.AddDate(0, 1, 0).Add(time.Hour), event.Timestamp)
+
+	dropped, err := suite.manager.DropPartitionsBefore(ctx, january.AddDate(0, 1, 10))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []time.Time{january}, dropped)
+
+	event, err = suite.repo.GetLastEventBySensorID(ctx, 1)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), january.AddDate(0, 1, 0).Add(time.Hour), event.Timestamp)
+
+	partitions, err := suite.manager.Partitions(ctx)
+	assert.Nil(suite.T(), err)
+	assert.NotContains(suite.T(), partitions, january)
+	assert.Contains(suite.T(), partitions, january.AddDate(0, 1, 0))
+}
+
+func (suite *PartitionTestSuite) TestPartitionManager_DefaultPartition() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	june := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// событие без месячной секции попадает в секцию по умолчанию
+	event := domain.Event{Timestamp: june.Add(time.Hour), SensorSerialNumber: "2345678901", SensorID: 2, Payload: 7}
+	err := suite.repo.SaveEvent(ctx, &event)
+	assert.Nil(suite.T(), err)
+
+	partitions, err := suite.manager.Partitions(ctx)
+	assert.Nil(suite.T(), err)
+	assert.NotContains(suite.T(), partitions, june)
+
+	// при создании секции событие переносится в неё, свёртки не меняются
+	created, err := suite.manager.EnsurePartitions(ctx, june, june)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []time.Time{june}, created)
+
+	var inDefault int
+	err = suite.testDbInstance.QueryRow(ctx, `select count(*) from events_default where sensor_id = 2`).Scan(&inDefault)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 0, inDefault)
+
+	events, err := suite.repo.GetEvents(ctx, 2, june, june.AddDate(0, 1, 0))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []domain.Event{event}, events)
+
+	rollups, err := suite.repo.GetRollups(ctx, 2, domain.RollupResolutionDay, june, june.AddDate(0, 1, 0))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []domain.Rollup{
+		{SensorID: 2, Bucket: june, Min: 7, Max: 7, Sum: 7, Count: 1, Last: 7, LastAt: june.Add(time.Hour)},
+	}, rollups)
+
+	// устаревшие события секции по умолчанию удаляются вместе с секциями
+	old := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	err = suite.repo.SaveEvent(ctx, &domain.Event{Timestamp: old, SensorSerialNumber: "2345678901", SensorID: 2, Payload: 1})
+	assert.Nil(suite.T(), err)
+
+	_, err = suite.manager.DropPartitionsBefore(ctx, old.AddDate(0, 1, 0))
+	assert.Nil(suite.T(), err)
+
+	events, err = suite.repo.GetEvents(ctx, 2, old, old.AddDate(0, 1, 0))
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), events)
+}
+
+func TestPartitionTestSuite(t *testing.T) {
+	suite.Run(t, new(PartitionTestSuite))
+}
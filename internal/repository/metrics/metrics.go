@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"errors"
+	"homework/internal/usecase"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	appMetrics "homework/internal/metrics"
+)
+
+// Значения метки status
+const (
+	statusOK       = "ok"
+	statusNotFound = "not_found"
+	statusError    = "error"
+)
+
+// Observer - гистограмма длительности операций репозиториев, общая для всех обёрток пакета
+type Observer struct {
+	duration *prometheus.HistogramVec
+}
+
+func NewObserver(reg prometheus.Registerer) *Observer {
+	o := &Observer{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: appMetrics.Namespace,
+			Name:      "repository_operation_duration_seconds",
+			Help:      "Duration of repository operations.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"repository", "operation", "status"}),
+	}
+	reg.MustRegister(o.duration)
+
+	return o
+}
+
+// observe - учитывает операцию, начатую в started. Ненайденная запись - штатный результат, а не ошибка хранилища.
+func (o *Observer) observe(repository, operation string, started time.Time, err error) {
+	o.duration.WithLabelValues(repository, operation, status(err)).Observe(time.Since(started).Seconds())
+}
+
+func status(err error) string {
+	switch {
+	case err == nil:
+		return statusOK
+	case errors.Is(err, usecase.ErrSensorNotFound), errors.Is(err, usecase.ErrUserNotFound), errors.Is(err, usecase.ErrEventNotFound):
+		return statusNotFound
+	default:
+		return statusError
+	}
+}
@@ -0,0 +1,203 @@
+package metrics
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"time"
+)
+
+// SensorRepository - обёртка над usecase.SensorRepository, учитывающая длительность операций
+type SensorRepository struct {
+	next usecase.SensorRepository
+	o    *Observer
+}
+
+var _ usecase.SensorRepository = (*SensorRepository)(nil)
+
+func (o *Observer) SensorRepository(next usecase.SensorRepository) *SensorRepository {
+	return &SensorRepository{next: next, o: o}
+}
+
+func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) (err error) {
+	defer r.observe("SaveSensor", time.Now(), &err)
+	return r.next.SaveSensor(ctx, sensor)
+}
+
+func (r *SensorRepository) UpdateSensorState(ctx context.Context, id int64, update domain.SensorStateUpdate) (_ *domain.Sensor, err error) {
+	defer r.observe("UpdateSensorState", time.Now(), &err)
+	return r.next.UpdateSensorState(ctx, id, update)
+}
+
+func (r *SensorRepository) GetSensors(ctx context.Context) (_ []domain.Sensor, err error) {
+	defer r.observe("GetSensors", time.Now(), &err)
+	return r.next.GetSensors(ctx)
+}
+
+func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (_ *domain.Sensor, err error) {
+	defer r.observe("GetSensorByID", time.Now(), &err)
+	return r.next.GetSensorByID(ctx, id)
+}
+
+func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (_ *domain.Sensor, err error) {
+	defer r.observe("GetSensorBySerialNumber", time.Now(), &err)
+	return r.next.GetSensorBySerialNumber(ctx, sn)
+}
+
+func (r *SensorRepository) observe(operation string, started time.Time, err *error) {
+	r.o.observe("sensor", operation, started, *err)
+}
+
+// EventRepository - обёртка над usecase.EventRepository, учитывающая длительность операций
+type EventRepository struct {
+	next usecase.EventRepository
+	o    *Observer
+}
+
+var _ usecase.EventRepository = (*EventRepository)(nil)
+
+func (o *Observer) EventRepository(next usecase.EventRepository) *EventRepository {
+	return &EventRepository{next: next, o: o}
+}
+
+func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) (err error) {
+	defer r.observe("SaveEvent", time.Now(), &err)
+	return r.next.SaveEvent(ctx, event)
+}
+
+func (r *EventRepository) SaveEvents(ctx context.Context, events []domain.Event) (err error) {
+	defer r.observe("SaveEvents", time.Now(), &err)
+	return r.next.SaveEvents(ctx, events)
+}
+
+func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (_ *domain.Event, err error) {
+	defer r.observe("GetLastEventBySensorID", time.Now(), &err)
+	return r.next.GetLastEventBySensorID(ctx, id)
+}
+
+func (r *EventRepository) GetStateIntervals(ctx context.Context, id int64, from, to time.Time) (_ []domain.StateInterval, err error) {
+	defer r.observe("GetStateIntervals", time.Now(), &err)
+	return r.next.GetStateIntervals(ctx, id, from, to)
+}
+
+func (r *EventRepository) GetEvents(ctx context.Context, id int64, from, to time.Time) (_ []domain.Event, err error) {
+	defer r.observe("GetEvents", time.Now(), &err)
+	return r.next.GetEvents(ctx, id, from, to)
+}
+
+func (r *EventRepository) GetRollups(ctx context.Context, id int64, resolution domain.RollupResolution, from, to time.Time) (_ []domain.Rollup, err error) {
+	defer r.observe("GetRollups", time.Now(), &err)
+	return r.next.GetRollups(ctx, id, resolution, from, to)
+}
+
+func (r *EventRepository) DeleteEventsBefore(ctx context.Context, id int64, before time.Time, limit int) (_ int64, err error) {
+	defer r.observe("DeleteEventsBefore", time.Now(), &err)
+	return r.next.DeleteEventsBefore(ctx, id, before, limit)
+}
+
+func (r *EventRepository) observe(operation string, started time.Time, err *error) {
+	r.o.observe("event", operation, started, *err)
+}
+
+// UserRepository - обёртка над usecase.UserRepository, учитывающая длительность операций
+type UserRepository struct {
+	next usecase.UserRepository
+	o    *Observer
+}
+
+var _ usecase.UserRepository = (*UserRepository)(nil)
+
+func (o *Observer) UserRepository(next usecase.UserRepository) *UserRepository {
+	return &UserRepository{next: next, o: o}
+}
+
+func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) (err error) {
+	defer r.observe("SaveUser", time.Now(), &err)
+	return r.next.SaveUser(ctx, user)
+}
+
+func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (_ *domain.User, err error) {
+	defer r.observe("GetUserByID", time.Now(), &err)
+	return r.next.GetUserByID(ctx, id)
+}
+
+func (r *UserRepository) GetUsers(ctx context.Context) (_ []domain.User, err error) {
+	defer r.observe("GetUsers", time.Now(), &err)
+	return r.next.GetUsers(ctx)
+}
+
+func (r *UserRepository) observe(operation string, started time.Time, err *error) {
+	r.o.observe("user", operation, started, *err)
+}
+
+// SensorOwnerRepository - обёртка над usecase.SensorOwnerRepository, учитывающая длительность операций
+type SensorOwnerRepository struct {
+	next usecase.SensorOwnerRepository
+	o    *Observer
+}
+
+var _ usecase.SensorOwnerRepository = (*SensorOwnerRepository)(nil)
+
+func (o *Observer) SensorOwnerRepository(next usecase.SensorOwnerRepository) *SensorOwnerRepository {
+	return &SensorOwnerRepository{next: next, o: o}
+}
+
+func (r *SensorOwnerRepository) SaveSensorOwner(ctx context.Context, sensorOwner domain.SensorOwner) (err error) {
+	defer r.observe("SaveSensorOwner", time.Now(), &err)
+	return r.next.SaveSensorOwner(ctx, sensorOwner)
+}
+
+func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID int64) (_ []domain.SensorOwner, err error) {
+	defer r.observe("GetSensorsByUserID", time.Now(), &err)
+	return r.next.GetSensorsByUserID(ctx, userID)
+}
+
+func (r *SensorOwnerRepository) GetSensorOwners(ctx context.Context) (_ []domain.SensorOwner, err error) {
+	defer r.observe("GetSensorOwners", time.Now(), &err)
+	return r.next.GetSensorOwners(ctx)
+}
+
+func (r *SensorOwnerRepository) observe(operation string, started time.Time, err *error) {
+	r.o.observe("sensor_owner", operation, started, *err)
+}
+
+// QuarantineRepository - обёртка над usecase.QuarantineRepository, учитывающая длительность операций
+type QuarantineRepository struct {
+	next usecase.QuarantineRepository
+	o    *Observer
+}
+
+var _ usecase.QuarantineRepository = (*QuarantineRepository)(nil)
+
+func (o *Observer) QuarantineRepository(next usecase.QuarantineRepository) *QuarantineRepository {
+	return &QuarantineRepository{next: next, o: o}
+}
+
+func (r *QuarantineRepository) SaveQuarantinedEvent(ctx context.Context, event *domain.QuarantinedEvent) (err error) {
+	defer r.observe("SaveQuarantinedEvent", time.Now(), &err)
+	return r.next.SaveQuarantinedEvent(ctx, event)
+}
+
+func (r *QuarantineRepository) GetQuarantinedEvents(ctx context.Context, reason domain.QuarantineReason) (_ []domain.QuarantinedEvent, err error) {
+	defer r.observe("GetQuarantinedEvents", time.Now(), &err)
+	return r.next.GetQuarantinedEvents(ctx, reason)
+}
+
+func (r *QuarantineRepository) GetUnknownDevices(ctx context.Context, sampleSize int) (_ []domain.UnknownDevice, err error) {
+	defer r.observe("GetUnknownDevices", time.Now(), &err)
+	return r.next.GetUnknownDevices(ctx, sampleSize)
+}
+
+func (r *QuarantineRepository) TakeQuarantinedEvents(ctx context.Context, sn string, reason domain.QuarantineReason) (_ []domain.QuarantinedEvent, err error) {
+	defer r.observe("TakeQuarantinedEvents", time.Now(), &err)
+	return r.next.TakeQuarantinedEvents(ctx, sn, reason)
+}
+
+func (r *QuarantineRepository) DeleteQuarantinedEventsBefore(ctx context.Context, before time.Time) (_ int64, err error) {
+	defer r.observe("DeleteQuarantinedEventsBefore", time.Now(), &err)
+	return r.next.DeleteQuarantinedEventsBefore(ctx, before)
+}
+
+func (r *QuarantineRepository) observe(operation string, started time.Time, err *error) {
+	r.o.observe("quarantine", operation, started, *err)
+}
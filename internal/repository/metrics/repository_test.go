@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestSensorRepository(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	next := usecase.NewMockSensorRepository(ctrl)
+	next.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1}, nil)
+	next.EXPECT().GetSensorByID(ctx, int64(2)).Times(1).Return(nil, usecase.ErrSensorNotFound)
+	next.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Return(errors.New("connection refused"))
+
+	o := NewObserver(prometheus.NewPedanticRegistry())
+	r := o.SensorRepository(next)
+
+	sensor, err := r.GetSensorByID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), sensor.ID)
+
+	_, err = r.GetSensorByID(ctx, 2)
+	assert.ErrorIs(t, err, usecase.ErrSensorNotFound)
+
+	assert.Error(t, r.SaveSensor(ctx, &domain.Sensor{}))
+
+	count := func(operation, status string) uint64 {
+		var m dto.Metric
+		require.NoError(t, o.duration.WithLabelValues("sensor", operation, status).(prometheus.Metric).Write(&m))
+		return m.GetHistogram().GetSampleCount()
+	}
+	assert.Equal(t, uint64(1), count("GetSensorByID", statusOK))
+	assert.Equal(t, uint64(1), count("GetSensorByID", statusNotFound))
+	assert.Equal(t, uint64(1), count("SaveSensor", statusError))
+	assert.Equal(t, 3, testutil.CollectAndCount(o.duration))
+}
+
+func TestEventRepository(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	before := time.Now()
+	next := usecase.NewMockEventRepository(ctrl)
+	next.EXPECT().DeleteEventsBefore(ctx, int64(1), before, 10).Times(1).Return(int64(3), nil)
+
+	o := NewObserver(prometheus.NewPedanticRegistry())
+	removed, err := o.EventRepository(next).DeleteEventsBefore(ctx, 1, before, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), removed)
+
+	assert.Equal(t, 1, testutil.CollectAndCount(o.duration, "smarthome_repository_operation_duration_seconds"))
+}
@@ -0,0 +1,167 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrEventIsNil = errors.New("quarantined event is nil")
+
+type QuarantineRepository struct {
+	mu     sync.RWMutex
+	events []domain.QuarantinedEvent
+}
+
+func NewQuarantineRepository() *QuarantineRepository {
+	return &QuarantineRepository{}
+}
+
+func (r *QuarantineRepository) SaveQuarantinedEvent(ctx context.Context, event *domain.QuarantinedEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if event == nil {
+		return ErrEventIsNil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, *event)
+
+	return nil
+}
+
+func (r *QuarantineRepository) GetQuarantinedEvents(ctx context.Context, reason domain.QuarantineReason) ([]domain.QuarantinedEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]domain.QuarantinedEvent, 0)
+	for _, event := range r.events {
+		if reason == "" || event.Reason == reason {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+func (r *QuarantineRepository) GetUnknownDevices(ctx context.Context, sampleSize int) ([]domain.UnknownDevice, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	devices := make([]domain.UnknownDevice, 0)
+	index := make(map[string]int)
+	for _, event := range r.events {
+		if event.Reason != domain.QuarantineReasonUnknownSensor {
+			continue
+		}
+
+		i, ok := index[event.SensorSerialNumber]
+		if !ok {
+			i = len(devices)
+			index[event.SensorSerialNumber] = i
+			devices = append(devices, domain.UnknownDevice{
+				SerialNumber: event.SensorSerialNumber,
+				FirstSeen:    event.Timestamp,
+				LastSeen:     event.Timestamp,
+			})
+		}
+
+		device := &devices[i]
+		device.EventCount++
+		if event.Timestamp.Before(device.FirstSeen) {
+			device.FirstSeen = event.Timestamp
+		}
+		if event.Timestamp.After(device.LastSeen) {
+			device.LastSeen = event.Timestamp
+		}
+	}
+
+	for i := range devices {
+		devices[i].SamplePayloads = r.samplePayloads(devices[i].SerialNumber, sampleSize)
+	}
+	sort.SliceStable(devices, func(i, j int) bool {
+		return devices[i].FirstSeen.Before(devices[j].FirstSeen)
+	})
+
+	return devices, nil
+}
+
+func (r *QuarantineRepository) TakeQuarantinedEvents(ctx context.Context, sn string, reason domain.QuarantineReason) ([]domain.QuarantinedEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	taken := make([]domain.QuarantinedEvent, 0)
+	kept := r.events[:0]
+	for _, event := range r.events {
+		if event.SensorSerialNumber == sn && event.Reason == reason {
+			taken = append(taken, event)
+			continue
+		}
+		kept = append(kept, event)
+	}
+	r.events = kept
+
+	sort.SliceStable(taken, func(i, j int) bool {
+		return taken[i].Timestamp.Before(taken[j].Timestamp)
+	})
+
+	return taken, nil
+}
+
+func (r *QuarantineRepository) DeleteQuarantinedEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := make([]domain.QuarantinedEvent, 0, len(r.events))
+	for _, event := range r.events {
+		if !event.Timestamp.Before(before) {
+			kept = append(kept, event)
+		}
+	}
+	removed := len(r.events) - len(kept)
+	r.events = kept
+
+	return int64(removed), nil
+}
+
+// samplePayloads - последние по времени значения событий датчика, от новых к старым
+func (r *QuarantineRepository) samplePayloads(sn string, sampleSize int) []int64 {
+	events := make([]domain.QuarantinedEvent, 0)
+	for _, event := range r.events {
+		if event.SensorSerialNumber == sn && event.Reason == domain.QuarantineReasonUnknownSensor {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.After(events[j].Timestamp)
+	})
+
+	payloads := make([]int64, 0, sampleSize)
+	for i := 0; i < len(events) && i < sampleSize; i++ {
+		payloads = append(payloads, events[i].Payload)
+	}
+
+	return payloads
+}
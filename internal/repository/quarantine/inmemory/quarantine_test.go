@@ -0,0 +1,128 @@
+package inmemory
+
+import (
+	"context"
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuarantineRepository_SaveQuarantinedEvent(t *testing.T) {
+	t.Run("err, event is nil", func(t *testing.T) {
+		qr := NewQuarantineRepository()
+		err := qr.SaveQuarantinedEvent(context.Background(), nil)
+		assert.ErrorIs(t, err, ErrEventIsNil)
+	})
+
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		qr := NewQuarantineRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := qr.SaveQuarantinedEvent(ctx, &domain.QuarantinedEvent{})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("ok, save and filter by reason", func(t *testing.T) {
+		qr := NewQuarantineRepository()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		event := &domain.QuarantinedEvent{
+			Event: domain.Event{
+				Timestamp:          time.Now(),
+				SensorSerialNumber: "1234567890",
+				SensorID:           1,
+				Payload:            10,
+			},
+			Reason:  domain.QuarantineReasonInvalidPayload,
+			Details: "invalid event payload",
+		}
+
+		err := qr.SaveQuarantinedEvent(ctx, event)
+		assert.NoError(t, err)
+
+		events, err := qr.GetQuarantinedEvents(ctx, "")
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.QuarantinedEvent{*event}, events)
+
+		events, err = qr.GetQuarantinedEvents(ctx, domain.QuarantineReasonInvalidPayload)
+		assert.NoError(t, err)
+		assert.Len(t, events, 1)
+
+		events, err = qr.GetQuarantinedEvents(ctx, "other")
+		assert.NoError(t, err)
+		assert.Empty(t, events)
+	})
+}
+
+func TestQuarantineRepository_UnknownDevices(t *testing.T) {
+	qr := NewQuarantineRepository()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now()
+	for i, sn := range []string{"1111111111", "2222222222", "1111111111", "1111111111"} {
+		err := qr.SaveQuarantinedEvent(ctx, &domain.QuarantinedEvent{
+			Event: domain.Event{
+				Timestamp:          now.Add(time.Duration(i) * time.Minute),
+				SensorSerialNumber: sn,
+				Payload:            int64(i),
+			},
+			Reason: domain.QuarantineReasonUnknownSensor,
+		})
+		assert.NoError(t, err)
+	}
+	err := qr.SaveQuarantinedEvent(ctx, &domain.QuarantinedEvent{
+		Event:  domain.Event{Timestamp: now, SensorSerialNumber: "3333333333", SensorID: 3},
+		Reason: domain.QuarantineReasonInvalidPayload,
+	})
+	assert.NoError(t, err)
+
+	devices, err := qr.GetUnknownDevices(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.UnknownDevice{
+		{SerialNumber: "1111111111", FirstSeen: now, LastSeen: now.Add(3 * time.Minute), EventCount: 3, SamplePayloads: []int64{3, 2}},
+		{SerialNumber: "2222222222", FirstSeen: now.Add(time.Minute), LastSeen: now.Add(time.Minute), EventCount: 1, SamplePayloads: []int64{1}},
+	}, devices)
+
+	events, err := qr.TakeQuarantinedEvents(ctx, "1111111111", domain.QuarantineReasonUnknownSensor)
+	assert.NoError(t, err)
+	assert.Len(t, events, 3)
+	assert.Equal(t, int64(0), events[0].Payload)
+	assert.Equal(t, int64(3), events[2].Payload)
+
+	devices, err = qr.GetUnknownDevices(ctx, 2)
+	assert.NoError(t, err)
+	assert.Len(t, devices, 1)
+
+	events, err = qr.GetQuarantinedEvents(ctx, "")
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+}
+
+func TestQuarantineRepository_DeleteQuarantinedEventsBefore(t *testing.T) {
+	qr := NewQuarantineRepository()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		err := qr.SaveQuarantinedEvent(ctx, &domain.QuarantinedEvent{
+			Event:  domain.Event{Timestamp: now.Add(time.Duration(i) * time.Hour), SensorSerialNumber: "1111111111", Payload: int64(i)},
+			Reason: domain.QuarantineReasonUnknownSensor,
+		})
+		assert.NoError(t, err)
+	}
+
+	removed, err := qr.DeleteQuarantinedEventsBefore(ctx, now.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), removed)
+
+	events, err := qr.GetQuarantinedEvents(ctx, "")
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, int64(2), events[0].Payload)
+}
@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrEventIsNil = errors.New("quarantined event is nil")
+
+const (
+	quarantinedEventColumns = `timestamp, sensor_serial_number, sensor_id, payload, reason, details`
+
+	insertQuarantinedEventQuery = `insert into quarantined_events (` + quarantinedEventColumns + `)
+		values ($1, $2, $3, $4, $5, $6)`
+	selectQuarantinedEventsQuery = `select ` + quarantinedEventColumns + `
+		from quarantined_events
+		where $1 = '' or reason = $1
+		order by timestamp`
+	selectUnknownDevicesQuery = `select sensor_serial_number, min(timestamp), max(timestamp), count(*),
+			(array_agg(payload order by timestamp desc))[1:$2]
+		from quarantined_events
+		where reason = $1
+		group by sensor_serial_number
+		order by min(timestamp)`
+	deleteQuarantinedEventsQuery = `delete from quarantined_events
+		where sensor_serial_number = $1 and reason = $2
+		returning ` + quarantinedEventColumns
+	deleteQuarantinedEventsBeforeQuery = `delete from quarantined_events where timestamp < $1`
+)
+
+type QuarantineRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewQuarantineRepository(pool *pgxpool.Pool) *QuarantineRepository {
+	return &QuarantineRepository{
+		pool: pool,
+	}
+}
+
+func (r *QuarantineRepository) SaveQuarantinedEvent(ctx context.Context, event *domain.QuarantinedEvent) error {
+	if event == nil {
+		return ErrEventIsNil
+	}
+
+	_, err := r.pool.Exec(ctx, insertQuarantinedEventQuery,
+		event.Timestamp.UTC(), event.SensorSerialNumber, event.SensorID, event.Payload, string(event.Reason), event.Details,
+	)
+	if err != nil {
+		return fmt.Errorf("can't insert quarantined event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *QuarantineRepository) GetQuarantinedEvents(ctx context.Context, reason domain.QuarantineReason) ([]domain.QuarantinedEvent, error) {
+	rows, err := r.pool.Query(ctx, selectQuarantinedEventsQuery, string(reason))
+	if err != nil {
+		return nil, fmt.Errorf("can't select quarantined events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]domain.QuarantinedEvent, 0)
+	for rows.Next() {
+		event, err := scanQuarantinedEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't select quarantined events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *QuarantineRepository) GetUnknownDevices(ctx context.Context, sampleSize int) ([]domain.UnknownDevice, error) {
+	rows, err := r.pool.Query(ctx, selectUnknownDevicesQuery, string(domain.QuarantineReasonUnknownSensor), sampleSize)
+	if err != nil {
+		return nil, fmt.Errorf("can't select unknown devices: %w", err)
+	}
+	defer rows.Close()
+
+	devices := make([]domain.UnknownDevice, 0)
+	for rows.Next() {
+		var device domain.UnknownDevice
+		err := rows.Scan(&device.SerialNumber, &device.FirstSeen, &device.LastSeen, &device.EventCount, &device.SamplePayloads)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan unknown device: %w", err)
+		}
+		devices = append(devices, device)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't select unknown devices: %w", err)
+	}
+
+	return devices, nil
+}
+
+func (r *QuarantineRepository) TakeQuarantinedEvents(ctx context.Context, sn string, reason domain.QuarantineReason) ([]domain.QuarantinedEvent, error) {
+	rows, err := r.pool.Query(ctx, deleteQuarantinedEventsQuery, sn, string(reason))
+	if err != nil {
+		return nil, fmt.Errorf("can't delete quarantined events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]domain.QuarantinedEvent, 0)
+	for rows.Next() {
+		event, err := scanQuarantinedEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't delete quarantined events: %w", err)
+	}
+
+	// delete ... returning не гарантирует порядок строк
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+
+	return events, nil
+}
+
+func (r *QuarantineRepository) DeleteQuarantinedEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, deleteQuarantinedEventsBeforeQuery, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("can't delete quarantined events: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func scanQuarantinedEvent(row pgx.Row) (*domain.QuarantinedEvent, error) {
+	var (
+		event  domain.QuarantinedEvent
+		reason string
+	)
+
+	err := row.Scan(&event.Timestamp, &event.SensorSerialNumber, &event.SensorID, &event.Payload, &reason, &event.Details)
+	if err != nil {
+		return nil, fmt.Errorf("can't scan quarantined event: %w", err)
+	}
+	event.Reason = domain.QuarantineReason(reason)
+
+	return &event, nil
+}
@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/pkg/pg_test"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type QuarantineTestSuite struct {
+	suite.Suite
+	testDbInstance *pgxpool.Pool
+	testDB         *pg_test.TestDatabase
+
+	repo *QuarantineRepository
+}
+
+func (suite *QuarantineTestSuite) SetupSuite() {
+	suite.testDB = pg_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewQuarantineRepository(suite.testDbInstance)
+}
+
+func (suite *QuarantineTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *QuarantineTestSuite) TestQuarantineRepository_GetQuarantinedEvents() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	event := domain.QuarantinedEvent{
+		Event: domain.Event{
+			Timestamp:          time.Now().Truncate(time.Microsecond).In(time.UTC),
+			SensorSerialNumber: "1234567890",
+			SensorID:           1,
+			Payload:            10,
+		},
+		Reason:  domain.QuarantineReasonInvalidPayload,
+		Details: "invalid event payload",
+	}
+
+	err := suite.repo.SaveQuarantinedEvent(ctx, &event)
+	assert.Nil(suite.T(), err)
+
+	events, err := suite.repo.GetQuarantinedEvents(ctx, domain.QuarantineReasonInvalidPayload)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []domain.QuarantinedEvent{event}, events)
+
+	events, err = suite.repo.GetQuarantinedEvents(ctx, "other")
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), events)
+}
+
+func (suite *QuarantineTestSuite) TestQuarantineRepository_UnknownDevices() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	for i := 0; i < 3; i++ {
+		err := suite.repo.SaveQuarantinedEvent(ctx, &domain.QuarantinedEvent{
+			Event: domain.Event{
+				Timestamp:          now.Add(time.Duration(i) * time.Minute),
+				SensorSerialNumber: "5555555555",
+				Payload:            int64(i),
+			},
+			Reason: domain.QuarantineReasonUnknownSensor,
+		})
+		assert.Nil(suite.T(), err)
+	}
+
+	devices, err := suite.repo.GetUnknownDevices(ctx, 2)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []domain.UnknownDevice{{
+		SerialNumber:   "5555555555",
+		FirstSeen:      now,
+		LastSeen:       now.Add(2 * time.Minute),
+		EventCount:     3,
+		SamplePayloads: []int64{2, 1},
+	}}, devices)
+
+	events, err := suite.repo.TakeQuarantinedEvents(ctx, "5555555555", domain.QuarantineReasonUnknownSensor)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), events, 3)
+	assert.Equal(suite.T(), int64(0), events[0].Payload)
+
+	devices, err = suite.repo.GetUnknownDevices(ctx, 2)
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), devices)
+}
+
+func (suite *QuarantineTestSuite) TestQuarantineRepository_DeleteQuarantinedEventsBefore() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	for i := 0; i < 3; i++ {
+		err := suite.repo.SaveQuarantinedEvent(ctx, &domain.QuarantinedEvent{
+			Event: domain.Event{
+				Timestamp:          now.Add(time.Duration(i) * time.Hour),
+				SensorSerialNumber: "6666666666",
+				Payload:            int64(i),
+			},
+			Reason: domain.QuarantineReasonUnknownSensor,
+		})
+		assert.Nil(suite.T(), err)
+	}
+
+	removed, err := suite.repo.DeleteQuarantinedEventsBefore(ctx, now.Add(2*time.Hour))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), int64(2), removed)
+
+	events, err := suite.repo.TakeQuarantinedEvents(ctx, "6666666666", domain.QuarantineReasonUnknownSensor)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), events, 1)
+	assert.Equal(suite.T(), int64(2), events[0].Payload)
+}
+
+func TestQuarantineTestSuite(t *testing.T) {
+	suite.Run(t, new(QuarantineTestSuite))
+}
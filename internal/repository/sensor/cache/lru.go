@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lru - кэш ограниченного размера с вытеснением давно не использованных записей и сроком жизни записей
+type lru[K comparable, V any] struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	now     func() time.Time
+	order   *list.List
+	entries map[K]*list.Element
+}
+
+type lruEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+func newLRU[K comparable, V any](size int, ttl time.Duration, now func() time.Time) *lru[K, V] {
+	return &lru[K, V]{
+		size:    size,
+		ttl:     ttl,
+		now:     now,
+		order:   list.New(),
+		entries: make(map[K]*list.Element),
+	}
+}
+
+// get - возвращает значение, если оно есть и не устарело
+func (c *lru[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	el, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+
+	entry := el.Value.(*lruEntry[K, V])
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(el)
+		return zero, false
+	}
+	c.order.MoveToFront(el)
+
+	return entry.value, true
+}
+
+// set - сохраняет значение, возвращает количество вытесненных записей
+func (c *lru[K, V]) set(key K, value V) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*lruEntry[K, V])
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(el)
+
+		return 0
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, expiresAt: expiresAt})
+
+	evicted := 0
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+		evicted++
+	}
+
+	return evicted
+}
+
+// peek - возвращает значение без учёта срока жизни и без изменения порядка вытеснения
+func (c *lru[K, V]) peek(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	return el.Value.(*lruEntry[K, V]).value, true
+}
+
+func (c *lru[K, V]) delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+}
+
+func (c *lru[K, V]) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *lru[K, V]) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*lruEntry[K, V]).key)
+}
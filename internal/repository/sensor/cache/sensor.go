@@ -0,0 +1,225 @@
+package cache
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats - счётчики обращений к кэшу датчиков
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+// SensorRepository - кэширующая обёртка над usecase.SensorRepository.
+// Датчики кэшируются по ID и по серийному номеру. SaveSensor сбрасывает записи датчика,
+// а UpdateSensorState заменяет их датчиком из хранилища, поэтому события не вытесняют датчик из кэша.
+// Список датчиков не кэшируется.
+type SensorRepository struct {
+	next usecase.SensorRepository
+
+	byID           *lru[int64, domain.Sensor]
+	bySerialNumber *lru[string, domain.Sensor]
+
+	// generation - количество сбросов записей и изменений состояния. Датчик, прочитанный из хранилища до них,
+	// не кэшируется, иначе запись со старыми калибровкой, диапазоном или состоянием пережила бы их изменение.
+	// invalidations - количество сбросов записей, updating - выполняющиеся изменения состояния по ID датчика.
+	mu            sync.Mutex
+	generation    uint64
+	invalidations uint64
+	updating      map[int64]*stateUpdates
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+// stateUpdates - выполняющиеся изменения состояния датчика, overlapped - изменения пересекались по времени
+// и неизвестно, какое из них применено в хранилище последним
+type stateUpdates struct {
+	running    int
+	overlapped bool
+}
+
+// NewSensorRepository - создаёт кэш на size датчиков в каждом индексе, записи живут не дольше ttl
+func NewSensorRepository(next usecase.SensorRepository, size int, ttl time.Duration) *SensorRepository {
+	return newSensorRepository(next, size, ttl, time.Now)
+}
+
+func newSensorRepository(next usecase.SensorRepository, size int, ttl time.Duration, now func() time.Time) *SensorRepository {
+	return &SensorRepository{
+		next:           next,
+		byID:           newLRU[int64, domain.Sensor](size, ttl, now),
+		bySerialNumber: newLRU[string, domain.Sensor](size, ttl, now),
+		updating:       make(map[int64]*stateUpdates),
+	}
+}
+
+func (r *SensorRepository) UpdateSensorState(ctx context.Context, id int64, update domain.SensorStateUpdate) (*domain.Sensor, error) {
+	invalidations := r.beginUpdate(id)
+
+	sensor, err := r.next.UpdateSensorState(ctx, id, update)
+	r.endUpdate(id, sensor, invalidations)
+	if err != nil {
+		return nil, err
+	}
+
+	return sensor, nil
+}
+
+func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) error {
+	if sensor != nil {
+		r.invalidate(sensor)
+	}
+
+	if err := r.next.SaveSensor(ctx, sensor); err != nil {
+		return err
+	}
+
+	// ID нового датчика известен только после сохранения
+	r.invalidate(sensor)
+
+	return nil
+}
+
+func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
+	return r.next.GetSensors(ctx)
+}
+
+func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error) {
+	if sensor, ok := r.byID.get(id); ok {
+		r.hits.Add(1)
+		return &sensor, nil
+	}
+	r.misses.Add(1)
+
+	generation := r.currentGeneration()
+	sensor, err := r.next.GetSensorByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	r.store(sensor, generation)
+
+	return sensor, nil
+}
+
+func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error) {
+	if sensor, ok := r.bySerialNumber.get(sn); ok {
+		r.hits.Add(1)
+		return &sensor, nil
+	}
+	r.misses.Add(1)
+
+	generation := r.currentGeneration()
+	sensor, err := r.next.GetSensorBySerialNumber(ctx, sn)
+	if err != nil {
+		return nil, err
+	}
+	r.store(sensor, generation)
+
+	return sensor, nil
+}
+
+// Stats - функция получения счётчиков кэша
+func (r *SensorRepository) Stats() Stats {
+	return Stats{
+		Hits:      r.hits.Load(),
+		Misses:    r.misses.Load(),
+		Evictions: r.evictions.Load(),
+		Size:      r.byID.len(),
+	}
+}
+
+func (r *SensorRepository) currentGeneration() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.generation
+}
+
+// store - кэширует датчик, прочитанный из хранилища в поколении generation,
+// если с тех пор не было сбросов записей и изменений состояния
+func (r *SensorRepository) store(sensor *domain.Sensor, generation uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if generation != r.generation {
+		return
+	}
+	r.setLocked(sensor)
+}
+
+// beginUpdate - отмечает начало изменения состояния датчика id и возвращает количество сбросов записей
+func (r *SensorRepository) beginUpdate(id int64) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.updating[id]
+	if !ok {
+		u = &stateUpdates{}
+		r.updating[id] = u
+	}
+	if u.running > 0 {
+		u.overlapped = true
+	}
+	u.running++
+
+	return r.invalidations
+}
+
+// endUpdate - заменяет записи датчика id результатом изменения состояния sensor.
+// Если изменение завершилось ошибкой, пересеклось с другим изменением датчика или со сбросом записей,
+// записи датчика сбрасываются: датчик из хранилища может быть новее результата.
+func (r *SensorRepository) endUpdate(id int64, sensor *domain.Sensor, invalidations uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+	u := r.updating[id]
+	u.running--
+	overlapped := u.overlapped
+	if u.running == 0 {
+		delete(r.updating, id)
+	}
+
+	switch {
+	case sensor == nil:
+		r.deleteLocked(id, "")
+	case overlapped || invalidations != r.invalidations:
+		r.deleteLocked(id, sensor.SerialNumber)
+	default:
+		r.setLocked(sensor)
+	}
+}
+
+// invalidate - сбрасывает записи датчика, в том числе по старому серийному номеру
+func (r *SensorRepository) invalidate(sensor *domain.Sensor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+	r.invalidations++
+	r.deleteLocked(sensor.ID, sensor.SerialNumber)
+}
+
+func (r *SensorRepository) setLocked(sensor *domain.Sensor) {
+	evicted := r.byID.set(sensor.ID, *sensor) + r.bySerialNumber.set(sensor.SerialNumber, *sensor)
+	r.evictions.Add(uint64(evicted))
+}
+
+// deleteLocked - удаляет записи датчика по ID, по серийному номеру закэшированного датчика и по sn
+func (r *SensorRepository) deleteLocked(id int64, sn string) {
+	if cached, ok := r.byID.peek(id); ok {
+		r.bySerialNumber.delete(cached.SerialNumber)
+	}
+	r.byID.delete(id)
+	if sn != "" {
+		r.bySerialNumber.delete(sn)
+	}
+}
@@ -0,0 +1,395 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/repository/sensor/inmemory"
+	"homework/internal/usecase"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSensorRepository_GetSensorByID(t *testing.T) {
+	t.Run("ok, second read served from cache", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		next := usecase.NewMockSensorRepository(ctrl)
+		next.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, SerialNumber: "0123456789"}, nil)
+
+		r := NewSensorRepository(next, 10, time.Minute)
+		for range 3 {
+			sensor, err := r.GetSensorByID(ctx, 1)
+			assert.NoError(t, err)
+			assert.Equal(t, "0123456789", sensor.SerialNumber)
+		}
+
+		// датчик, полученный по ID, доступен и по серийному номеру
+		_, err := r.GetSensorBySerialNumber(ctx, "0123456789")
+		assert.NoError(t, err)
+
+		assert.Equal(t, Stats{Hits: 3, Misses: 1, Size: 1}, r.Stats())
+	})
+
+	t.Run("ok, cached sensor can't be modified by caller", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		next := usecase.NewMockSensorRepository(ctrl)
+		next.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, Description: "kitchen"}, nil)
+
+		r := NewSensorRepository(next, 10, time.Minute)
+		sensor, err := r.GetSensorByID(ctx, 1)
+		assert.NoError(t, err)
+		sensor.Description = "changed"
+
+		sensor, err = r.GetSensorByID(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "kitchen", sensor.Description)
+	})
+
+	t.Run("ok, expired entry reloaded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		next := usecase.NewMockSensorRepository(ctrl)
+		next.EXPECT().GetSensorByID(ctx, int64(1)).Times(2).Return(&domain.Sensor{ID: 1}, nil)
+
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		r := newSensorRepository(next, 10, time.Minute, func() time.Time { return now })
+
+		_, err := r.GetSensorByID(ctx, 1)
+		assert.NoError(t, err)
+		now = now.Add(59 * time.Second)
+		_, err = r.GetSensorByID(ctx, 1)
+		assert.NoError(t, err)
+		now = now.Add(time.Second)
+		_, err = r.GetSensorByID(ctx, 1)
+		assert.NoError(t, err)
+
+		assert.Equal(t, uint64(2), r.Stats().Misses)
+	})
+
+	t.Run("ok, least recently used evicted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		next := usecase.NewMockSensorRepository(ctrl)
+		next.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, SerialNumber: "1"}, nil)
+		next.EXPECT().GetSensorByID(ctx, int64(2)).Times(2).Return(&domain.Sensor{ID: 2, SerialNumber: "2"}, nil)
+		next.EXPECT().GetSensorByID(ctx, int64(3)).Times(1).Return(&domain.Sensor{ID: 3, SerialNumber: "3"}, nil)
+
+		r := NewSensorRepository(next, 2, time.Minute)
+		for _, id := range []int64{1, 2, 1, 3, 1, 2} {
+			_, err := r.GetSensorByID(ctx, id)
+			assert.NoError(t, err)
+		}
+
+		stats := r.Stats()
+		assert.Equal(t, 2, stats.Size)
+		assert.Equal(t, uint64(3), stats.Evictions)
+	})
+
+	t.Run("fail, errors are not cached", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		next := usecase.NewMockSensorRepository(ctrl)
+		next.EXPECT().GetSensorByID(ctx, int64(1)).Times(2).Return(nil, usecase.ErrSensorNotFound)
+
+		r := NewSensorRepository(next, 10, time.Minute)
+		for range 2 {
+			_, err := r.GetSensorByID(ctx, 1)
+			assert.ErrorIs(t, err, usecase.ErrSensorNotFound)
+		}
+	})
+}
+
+func TestSensorRepository_SaveSensor(t *testing.T) {
+	t.Run("ok, save invalidates cache", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		r := NewSensorRepository(inmemory.NewSensorRepository(), 10, time.Minute)
+
+		sensor := &domain.Sensor{SerialNumber: "0123456789", Type: domain.SensorTypeADC}
+		assert.NoError(t, r.SaveSensor(ctx, sensor))
+
+		got, err := r.GetSensorBySerialNumber(ctx, "0123456789")
+		assert.NoError(t, err)
+		assert.Empty(t, got.Description)
+
+		sensor.Description = "kitchen"
+		assert.NoError(t, r.SaveSensor(ctx, sensor))
+
+		got, err = r.GetSensorBySerialNumber(ctx, "0123456789")
+		assert.NoError(t, err)
+		assert.Equal(t, "kitchen", got.Description)
+
+		got, err = r.GetSensorByID(ctx, sensor.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "kitchen", got.Description)
+		assert.Equal(t, int64(2), got.Version)
+	})
+
+	t.Run("ok, old serial number invalidated", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		r := NewSensorRepository(inmemory.NewSensorRepository(), 10, time.Minute)
+
+		sensor := &domain.Sensor{SerialNumber: "0123456789", Type: domain.SensorTypeADC}
+		assert.NoError(t, r.SaveSensor(ctx, sensor))
+		_, err := r.GetSensorByID(ctx, sensor.ID)
+		assert.NoError(t, err)
+
+		sensor.SerialNumber = "9876543210"
+		assert.NoError(t, r.SaveSensor(ctx, sensor))
+
+		_, err = r.GetSensorBySerialNumber(ctx, "0123456789")
+		assert.ErrorIs(t, err, usecase.ErrSensorNotFound)
+	})
+
+	t.Run("ok, unknown serial number visible after registration", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		r := NewSensorRepository(inmemory.NewSensorRepository(), 10, time.Minute)
+
+		_, err := r.GetSensorBySerialNumber(ctx, "0123456789")
+		assert.ErrorIs(t, err, usecase.ErrSensorNotFound)
+
+		assert.NoError(t, r.SaveSensor(ctx, &domain.Sensor{SerialNumber: "0123456789", Type: domain.SensorTypeADC}))
+
+		_, err = r.GetSensorBySerialNumber(ctx, "0123456789")
+		assert.NoError(t, err)
+	})
+
+	t.Run("fail, backend error passed through", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		r := NewSensorRepository(inmemory.NewSensorRepository(), 10, time.Minute)
+		assert.ErrorIs(t, r.SaveSensor(ctx, nil), inmemory.ErrSensorIsNil)
+	})
+}
+
+func TestSensorRepository_UpdateSensorState(t *testing.T) {
+	t.Run("ok, cached sensor refreshed without reload", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		r := NewSensorRepository(inmemory.NewSensorRepository(), 10, time.Minute)
+
+		sensor := &domain.Sensor{SerialNumber: "0123456789", Type: domain.SensorTypeCounter}
+		assert.NoError(t, r.SaveSensor(ctx, sensor))
+		_, err := r.GetSensorBySerialNumber(ctx, "0123456789")
+		assert.NoError(t, err)
+
+		for range 3 {
+			updated, err := r.UpdateSensorState(ctx, sensor.ID, domain.SensorStateUpdate{State: 1, Accumulate: true, LastActivity: time.Now()})
+			assert.NoError(t, err)
+			assert.Equal(t, sensor.SerialNumber, updated.SerialNumber)
+		}
+
+		got, err := r.GetSensorBySerialNumber(ctx, "0123456789")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), got.CurrentState)
+		got, err = r.GetSensorByID(ctx, sensor.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), got.CurrentState)
+
+		assert.Equal(t, Stats{Hits: 2, Misses: 1, Size: 1}, r.Stats())
+	})
+
+	t.Run("ok, sensor read before invalidation isn't cached", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		next := usecase.NewMockSensorRepository(ctrl)
+		r := NewSensorRepository(next, 10, time.Minute)
+
+		// пока обновление состояния выполняется, калибровка датчика меняется
+		stale := &domain.Sensor{ID: 1, SerialNumber: "0123456789", CurrentState: 1}
+		next.EXPECT().UpdateSensorState(ctx, int64(1), gomock.Any()).Times(1).DoAndReturn(
+			func(context.Context, int64, domain.SensorStateUpdate) (*domain.Sensor, error) {
+				assert.NoError(t, r.SaveSensor(ctx, &domain.Sensor{ID: 1, SerialNumber: "0123456789", Calibration: &domain.Calibration{Scale: 2}}))
+				return stale, nil
+			})
+		next.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Return(nil)
+		next.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, SerialNumber: "0123456789", Calibration: &domain.Calibration{Scale: 2}}, nil)
+
+		_, err := r.UpdateSensorState(ctx, 1, domain.SensorStateUpdate{State: 1})
+		assert.NoError(t, err)
+
+		got, err := r.GetSensorByID(ctx, 1)
+		assert.NoError(t, err)
+		assert.NotNil(t, got.Calibration)
+	})
+
+	t.Run("ok, sensor read before state update isn't cached", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		next := usecase.NewMockSensorRepository(ctrl)
+		r := NewSensorRepository(next, 10, time.Minute)
+
+		// чтение получает датчик до события, а кэширует его после того, как событие изменило состояние
+		updated := &domain.Sensor{ID: 1, SerialNumber: "0123456789", CurrentState: 2}
+		next.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).DoAndReturn(
+			func(context.Context, int64) (*domain.Sensor, error) {
+				_, err := r.UpdateSensorState(ctx, 1, domain.SensorStateUpdate{State: 2})
+				assert.NoError(t, err)
+				return &domain.Sensor{ID: 1, SerialNumber: "0123456789", CurrentState: 1}, nil
+			})
+		next.EXPECT().UpdateSensorState(ctx, int64(1), gomock.Any()).Times(1).Return(updated, nil)
+
+		_, err := r.GetSensorByID(ctx, 1)
+		assert.NoError(t, err)
+
+		got, err := r.GetSensorBySerialNumber(ctx, "0123456789")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), got.CurrentState)
+	})
+
+	t.Run("ok, overlapping state updates aren't cached", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		next := usecase.NewMockSensorRepository(ctrl)
+		r := NewSensorRepository(next, 10, time.Minute)
+
+		// первое изменение применено в хранилище раньше, а его результат кэшируется позже второго
+		first := next.EXPECT().UpdateSensorState(ctx, int64(1), domain.SensorStateUpdate{State: 1}).Times(1).DoAndReturn(
+			func(context.Context, int64, domain.SensorStateUpdate) (*domain.Sensor, error) {
+				_, err := r.UpdateSensorState(ctx, 1, domain.SensorStateUpdate{State: 2})
+				assert.NoError(t, err)
+				return &domain.Sensor{ID: 1, SerialNumber: "0123456789", CurrentState: 1}, nil
+			})
+		next.EXPECT().UpdateSensorState(ctx, int64(1), domain.SensorStateUpdate{State: 2}).Times(1).
+			Return(&domain.Sensor{ID: 1, SerialNumber: "0123456789", CurrentState: 2}, nil).After(first)
+		next.EXPECT().GetSensorBySerialNumber(ctx, "0123456789").Times(1).
+			Return(&domain.Sensor{ID: 1, SerialNumber: "0123456789", CurrentState: 2}, nil)
+
+		_, err := r.UpdateSensorState(ctx, 1, domain.SensorStateUpdate{State: 1})
+		assert.NoError(t, err)
+
+		got, err := r.GetSensorBySerialNumber(ctx, "0123456789")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), got.CurrentState)
+	})
+
+	t.Run("fail, not found", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		r := NewSensorRepository(inmemory.NewSensorRepository(), 10, time.Minute)
+		_, err := r.UpdateSensorState(ctx, 2, domain.SensorStateUpdate{})
+		assert.ErrorIs(t, err, usecase.ErrSensorNotFound)
+	})
+}
+
+func TestSensorRepository_Concurrent(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	r := NewSensorRepository(inmemory.NewSensorRepository(), 4, time.Minute)
+	sensor := &domain.Sensor{SerialNumber: "0123456789", Type: domain.SensorTypeADC}
+	assert.NoError(t, r.SaveSensor(ctx, sensor))
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				if i == 0 {
+					// единственный писатель метаданных всегда читает из кэша последнюю версию
+					s, err := r.GetSensorByID(ctx, sensor.ID)
+					assert.NoError(t, err)
+					s.Description = fmt.Sprint(j)
+					assert.NoError(t, r.SaveSensor(ctx, s))
+					continue
+				}
+				if i == 1 {
+					_, err := r.UpdateSensorState(ctx, sensor.ID, domain.SensorStateUpdate{State: int64(j), LastActivity: time.Now()})
+					assert.NoError(t, err)
+					continue
+				}
+				_, err := r.GetSensorBySerialNumber(ctx, "0123456789")
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := r.GetSensorByID(ctx, sensor.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(99), got.CurrentState)
+}
+
+func TestSensorRepository_ConcurrentStateUpdates(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	r := NewSensorRepository(inmemory.NewSensorRepository(), 4, time.Minute)
+	sensor := &domain.Sensor{SerialNumber: "0123456789", Type: domain.SensorTypeCounter}
+	assert.NoError(t, r.SaveSensor(ctx, sensor))
+
+	// чтения и изменения состояния чередуются: в кэше не должно остаться состояние до последнего изменения
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				if i%2 == 0 {
+					_, err := r.UpdateSensorState(ctx, sensor.ID, domain.SensorStateUpdate{State: 1, Accumulate: true, LastActivity: time.Now()})
+					assert.NoError(t, err)
+					continue
+				}
+				_, err := r.GetSensorBySerialNumber(ctx, "0123456789")
+				assert.NoError(t, err)
+				_, err = r.GetSensorByID(ctx, sensor.ID)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := r.GetSensorByID(ctx, sensor.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(400), got.CurrentState)
+	got, err = r.GetSensorBySerialNumber(ctx, "0123456789")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(400), got.CurrentState)
+}
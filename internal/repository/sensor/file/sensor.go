@@ -0,0 +1,201 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/repository/sensor/inmemory"
+	"homework/pkg/journal"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	opSaveSensor        = "save_sensor"
+	opUpdateSensorState = "update_sensor_state"
+)
+
+var (
+	ErrSensorNotFound        = inmemory.ErrSensorNotFound
+	ErrSensorVersionConflict = inmemory.ErrSensorVersionConflict
+	ErrSensorIsNil           = inmemory.ErrSensorIsNil
+	ErrUnknownOp             = errors.New("unknown journal operation")
+)
+
+// stateUpdate - запись журнала об изменении состояния датчика
+type stateUpdate struct {
+	ID     int64
+	Update domain.SensorStateUpdate
+}
+
+// SensorRepository - репозиторий датчиков в локальных файлах каталога dir.
+// Датчики хранятся в памяти, каждое изменение сначала записывается в журнал.
+type SensorRepository struct {
+	mu      sync.Mutex
+	lastID  int64
+	mem     *inmemory.SensorRepository
+	journal *journal.Journal
+}
+
+func NewSensorRepository(dir string, options ...func(*journal.Journal)) (*SensorRepository, error) {
+	r := &SensorRepository{mem: inmemory.NewSensorRepository()}
+
+	j, err := journal.Open(dir, "sensors", r.restore, r.apply, options...)
+	if err != nil {
+		return nil, fmt.Errorf("can't open sensors journal: %w", err)
+	}
+	r.journal = j
+
+	return r, nil
+}
+
+func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if sensor == nil {
+		return ErrSensorIsNil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	saved := *sensor
+	existing, err := r.mem.GetSensorByID(ctx, saved.ID)
+	switch {
+	case err == nil:
+		if existing.Version != saved.Version {
+			return ErrSensorVersionConflict
+		}
+		// состояние меняется только событиями через UpdateSensorState
+		saved.RegisteredAt = existing.RegisteredAt
+		saved.CurrentState = existing.CurrentState
+		saved.LastActivity = existing.LastActivity
+		saved.Version++
+	case errors.Is(err, ErrSensorNotFound):
+		saved.ID = r.lastID + 1
+		saved.RegisteredAt = time.Now().UTC()
+		saved.Version = 1
+	default:
+		return err
+	}
+	saved.LastActivity = saved.LastActivity.UTC()
+
+	if err := r.journal.Append(opSaveSensor, saved); err != nil {
+		return fmt.Errorf("can't save sensor: %w", err)
+	}
+	r.put(saved)
+	*sensor = saved
+
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+func (r *SensorRepository) UpdateSensorState(ctx context.Context, id int64, update domain.SensorStateUpdate) (*domain.Sensor, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.mem.GetSensorByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	update.LastActivity = update.LastActivity.UTC()
+	if err := r.journal.Append(opUpdateSensorState, stateUpdate{ID: id, Update: update}); err != nil {
+		return nil, fmt.Errorf("can't update sensor state: %w", err)
+	}
+	sensor, err := r.mem.UpdateSensorState(ctx, id, update)
+	if err != nil {
+		return nil, err
+	}
+
+	r.snapshotIfNeeded(ctx)
+
+	return sensor, nil
+}
+
+func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
+	return r.mem.GetSensors(ctx)
+}
+
+func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error) {
+	return r.mem.GetSensorByID(ctx, id)
+}
+
+func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error) {
+	return r.mem.GetSensorBySerialNumber(ctx, sn)
+}
+
+// Close - сохраняет снимок состояния и закрывает журнал
+func (r *SensorRepository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return errors.Join(r.snapshot(), r.journal.Close())
+}
+
+func (r *SensorRepository) put(sensors ...domain.Sensor) {
+	r.mem.PutSensors(sensors...)
+	for _, sensor := range sensors {
+		r.lastID = max(r.lastID, sensor.ID)
+	}
+}
+
+func (r *SensorRepository) restore(data json.RawMessage) error {
+	var sensors []domain.Sensor
+	if err := json.Unmarshal(data, &sensors); err != nil {
+		return fmt.Errorf("can't unmarshal sensors: %w", err)
+	}
+	r.put(sensors...)
+
+	return nil
+}
+
+func (r *SensorRepository) apply(op string, data json.RawMessage) error {
+	switch op {
+	case opSaveSensor:
+		var sensor domain.Sensor
+		if err := json.Unmarshal(data, &sensor); err != nil {
+			return fmt.Errorf("can't unmarshal sensor: %w", err)
+		}
+		r.put(sensor)
+	case opUpdateSensorState:
+		var u stateUpdate
+		if err := json.Unmarshal(data, &u); err != nil {
+			return fmt.Errorf("can't unmarshal sensor state: %w", err)
+		}
+		if _, err := r.mem.UpdateSensorState(context.Background(), u.ID, u.Update); err != nil {
+			return fmt.Errorf("can't update sensor %d state: %w", u.ID, err)
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownOp, op)
+	}
+
+	return nil
+}
+
+// snapshotIfNeeded - заменяет журнал снимком; ошибка не мешает сохранению, журнал просто продолжает расти
+func (r *SensorRepository) snapshotIfNeeded(ctx context.Context) {
+	if !r.journal.NeedsSnapshot() {
+		return
+	}
+	if err := r.snapshot(); err != nil {
+		slog.ErrorContext(ctx, "can't snapshot sensors journal", "err", err)
+	}
+}
+
+func (r *SensorRepository) snapshot() error {
+	sensors, err := r.mem.GetSensors(context.Background())
+	if err != nil {
+		return err
+	}
+
+	return r.journal.Snapshot(sensors)
+}
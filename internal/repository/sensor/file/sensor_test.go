@@ -0,0 +1,88 @@
+package file
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/repository/sensor/sensortest"
+	"homework/internal/usecase"
+	"homework/pkg/journal"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSensorRepository(t *testing.T) *SensorRepository {
+	t.Helper()
+
+	// снимок каждые несколько записей, чтобы тесты проходили и через снимки, и через журнал
+	r, err := NewSensorRepository(t.TempDir(), journal.WithSnapshotEvery(3))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, r.Close())
+	})
+
+	return r
+}
+
+func TestSensorRepository(t *testing.T) {
+	sensortest.Run(t, func(t *testing.T) usecase.SensorRepository {
+		return newSensorRepository(t)
+	})
+}
+
+func TestSensorRepository_Reopen(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	dir := t.TempDir()
+	sr, err := NewSensorRepository(dir, journal.WithSnapshotEvery(2))
+	require.NoError(t, err)
+
+	sensors := []*domain.Sensor{
+		{SerialNumber: "0000000001", Type: domain.SensorTypeADC, PayloadRange: &domain.PayloadRange{Min: 0, Max: 100}},
+		{SerialNumber: "0000000002", Type: domain.SensorTypeContactClosure},
+		{SerialNumber: "0000000003", Type: domain.SensorTypeCounter},
+	}
+	for _, sensor := range sensors {
+		assert.NoError(t, sr.SaveSensor(ctx, sensor))
+	}
+	sensors[0].SerialNumber = "0000000010"
+	sensors[0].CurrentState = 42
+	assert.NoError(t, sr.SaveSensor(ctx, sensors[0]))
+	now := time.Now().UTC()
+	_, err = sr.UpdateSensorState(ctx, sensors[2].ID, domain.SensorStateUpdate{State: 5, Accumulate: true, LastActivity: now})
+	assert.NoError(t, err)
+	_, err = sr.UpdateSensorState(ctx, sensors[2].ID, domain.SensorStateUpdate{State: 3, Accumulate: true, LastActivity: now})
+	assert.NoError(t, err)
+	sensors[2].CurrentState = 8
+	sensors[2].LastActivity = now
+
+	// без Close: часть изменений только в журнале
+	sr, err = NewSensorRepository(dir)
+	require.NoError(t, err)
+
+	actual, err := sr.GetSensors(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, actual, 3)
+	for i, sensor := range sensors {
+		assert.Equal(t, *sensor, actual[i])
+	}
+
+	_, err = sr.GetSensorBySerialNumber(ctx, "0000000001")
+	assert.ErrorIs(t, err, ErrSensorNotFound)
+
+	sensor := &domain.Sensor{SerialNumber: "0000000004", Type: domain.SensorTypeADC}
+	assert.NoError(t, sr.SaveSensor(ctx, sensor))
+	assert.Equal(t, int64(4), sensor.ID)
+	assert.NoError(t, sr.Close())
+
+	sr, err = NewSensorRepository(dir)
+	require.NoError(t, err)
+	defer sr.Close()
+
+	actualSensor, err := sr.GetSensorBySerialNumber(ctx, "0000000004")
+	assert.NoError(t, err)
+	assert.Equal(t, *sensor, *actualSensor)
+}
@@ -4,34 +4,148 @@ import (
 	"context"
 	"errors"
 	"homework/internal/domain"
+	"homework/internal/usecase"
+	"sync"
+	"time"
 )
 
-var ErrSensorNotFound = errors.New("sensor not found")
+var (
+	ErrSensorNotFound        = usecase.ErrSensorNotFound
+	ErrSensorVersionConflict = usecase.ErrSensorVersionConflict
+	ErrSensorIsNil           = errors.New("sensor is nil")
+)
 
 type SensorRepository struct {
-	// TODO добавьте реализацию
+	mu               sync.RWMutex
+	lastID           int64
+	sensors          map[int64]domain.Sensor
+	idBySerialNumber map[string]int64
 }
 
 func NewSensorRepository() *SensorRepository {
-	return &SensorRepository{}
+	return &SensorRepository{
+		sensors:          make(map[int64]domain.Sensor),
+		idBySerialNumber: make(map[string]int64),
+	}
 }
 
 func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) error {
-	// TODO добавьте реализацию
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if sensor == nil {
+		return ErrSensorIsNil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.sensors[sensor.ID]; ok {
+		if existing.Version != sensor.Version {
+			return ErrSensorVersionConflict
+		}
+		if existing.SerialNumber != sensor.SerialNumber {
+			delete(r.idBySerialNumber, existing.SerialNumber)
+		}
+		// состояние меняется только событиями через UpdateSensorState
+		sensor.RegisteredAt = existing.RegisteredAt
+		sensor.CurrentState = existing.CurrentState
+		sensor.LastActivity = existing.LastActivity
+		sensor.Version++
+	} else {
+		r.lastID++
+		sensor.ID = r.lastID
+		sensor.RegisteredAt = time.Now()
+		sensor.Version = 1
+	}
+
+	r.sensors[sensor.ID] = *sensor
+	r.idBySerialNumber[sensor.SerialNumber] = sensor.ID
+
 	return nil
 }
 
+func (r *SensorRepository) UpdateSensorState(ctx context.Context, id int64, update domain.SensorStateUpdate) (*domain.Sensor, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sensor, ok := r.sensors[id]
+	if !ok {
+		return nil, ErrSensorNotFound
+	}
+	sensor.ApplyStateUpdate(update)
+	r.sensors[id] = sensor
+
+	return &sensor, nil
+}
+
 func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
-	// TODO добавьте реализацию
-	return nil, nil
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sensors := make([]domain.Sensor, 0, len(r.sensors))
+	for id := int64(1); id <= r.lastID; id++ {
+		if sensor, ok := r.sensors[id]; ok {
+			sensors = append(sensors, sensor)
+		}
+	}
+
+	return sensors, nil
 }
 
 func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error) {
-	// TODO добавьте реализацию
-	return nil, nil
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sensor, ok := r.sensors[id]
+	if !ok {
+		return nil, ErrSensorNotFound
+	}
+
+	return &sensor, nil
 }
 
 func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error) {
-	// TODO добавьте реализацию
-	return nil, ErrSensorNotFound
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.idBySerialNumber[sn]
+	if !ok {
+		return nil, ErrSensorNotFound
+	}
+	sensor := r.sensors[id]
+
+	return &sensor, nil
+}
+
+// PutSensors - сохраняет датчики как есть, с их ID и временем регистрации.
+// Используется для восстановления состояния, проверки SaveSensor не выполняются.
+func (r *SensorRepository) PutSensors(sensors ...domain.Sensor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, sensor := range sensors {
+		if existing, ok := r.sensors[sensor.ID]; ok && existing.SerialNumber != sensor.SerialNumber {
+			delete(r.idBySerialNumber, existing.SerialNumber)
+		}
+		r.sensors[sensor.ID] = sensor
+		r.idBySerialNumber[sensor.SerialNumber] = sensor.ID
+		r.lastID = max(r.lastID, sensor.ID)
+	}
 }
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"encoding/json"
+	"fmt"
+	"homework/internal/domain"
+)
+
+// calibration - представление калибровки датчика в колонке sensors.calibration
+type calibration struct {
+	Kind      domain.CalibrationKind `json:"kind"`
+	Scale     float64                `json:"scale,omitempty"`
+	Offset    float64                `json:"offset,omitempty"`
+	Points    []calibrationPoint     `json:"points,omitempty"`
+	Unit      string                 `json:"unit,omitempty"`
+	Precision int                    `json:"precision,omitempty"`
+}
+
+type calibrationPoint struct {
+	Raw   int64   `json:"raw"`
+	Value float64 `json:"value"`
+}
+
+func marshalCalibration(c *domain.Calibration) ([]byte, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	stored := calibration{
+		Kind:      c.Kind,
+		Scale:     c.Scale,
+		Offset:    c.Offset,
+		Unit:      c.Unit,
+		Precision: c.Precision,
+	}
+	for _, p := range c.Points {
+		stored.Points = append(stored.Points, calibrationPoint(p))
+	}
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return nil, fmt.Errorf("can't marshal calibration: %w", err)
+	}
+
+	return data, nil
+}
+
+func unmarshalCalibration(data []byte) (*domain.Calibration, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	var stored calibration
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("can't unmarshal calibration: %w", err)
+	}
+
+	c := &domain.Calibration{
+		Kind:      stored.Kind,
+		Scale:     stored.Scale,
+		Offset:    stored.Offset,
+		Unit:      stored.Unit,
+		Precision: stored.Precision,
+	}
+	for _, p := range stored.Points {
+		c.Points = append(c.Points, domain.CalibrationPoint(p))
+	}
+
+	return c, nil
+}
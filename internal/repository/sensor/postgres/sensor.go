@@ -2,8 +2,47 @@ package postgres
 
 import (
 	"context"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"errors"
+	"fmt"
 	"homework/internal/domain"
+	"homework/internal/usecase"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrSensorNotFound        = usecase.ErrSensorNotFound
+	ErrSensorVersionConflict = usecase.ErrSensorVersionConflict
+	ErrSensorIsNil           = errors.New("sensor is nil")
+)
+
+const (
+	sensorColumns = `id, serial_number, type, current_state, description, is_active, registered_at, last_activity, calibration, payload_min, payload_max, version`
+
+	insertSensorQuery = `insert into sensors (serial_number, type, current_state, description, is_active, registered_at, last_activity, calibration, payload_min, payload_max)
+		values ($1, $2, $3, $4, $5, now() at time zone 'utc', $6, $7, $8, $9)
+		returning id, registered_at, version`
+	// состояние и последняя активность меняются только событиями через updateSensorStateQuery
+	updateSensorQuery = `update sensors
+		set serial_number = $2, type = $3, description = $4, is_active = $5, calibration = $6,
+			payload_min = $7, payload_max = $8, version = version + 1
+		where id = $1 and version = $9
+		returning registered_at, current_state, last_activity, version`
+	// состояние накопительного датчика увеличивается атомарно, иначе заменяется,
+	// если события не старше последней активности; greatest пропускает NULL
+	updateSensorStateQuery = `update sensors
+		set current_state = case
+				when $3 then coalesce(current_state, 0) + $2
+				when last_activity is null or last_activity <= $4 then $2
+				else current_state
+			end,
+			last_activity = greatest(last_activity, $4)
+		where id = $1
+		returning ` + sensorColumns
+	selectSensorsQuery              = `select ` + sensorColumns + ` from sensors order by id`
+	selectSensorByIDQuery           = `select ` + sensorColumns + ` from sensors where id = $1`
+	selectSensorBySerialNumberQuery = `select ` + sensorColumns + ` from sensors where serial_number = $1 order by id limit 1`
 )
 
 type SensorRepository struct {
@@ -17,21 +56,138 @@ func NewSensorRepository(pool *pgxpool.Pool) *SensorRepository {
 }
 
 func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) error {
-	// TODO добавьте реализацию
+	if sensor == nil {
+		return ErrSensorIsNil
+	}
+
+	calibration, err := marshalCalibration(sensor.Calibration)
+	if err != nil {
+		return err
+	}
+
+	payloadMin, payloadMax := payloadRangeBounds(sensor.PayloadRange)
+
+	if sensor.ID == 0 {
+		err = r.pool.QueryRow(ctx, insertSensorQuery,
+			sensor.SerialNumber, string(sensor.Type), sensor.CurrentState, sensor.Description,
+			sensor.IsActive, sensor.LastActivity.UTC(), calibration, payloadMin, payloadMax,
+		).Scan(&sensor.ID, &sensor.RegisteredAt, &sensor.Version)
+		if err != nil {
+			return fmt.Errorf("can't insert sensor: %w", err)
+		}
+
+		return nil
+	}
+
+	err = r.pool.QueryRow(ctx, updateSensorQuery,
+		sensor.ID, sensor.SerialNumber, string(sensor.Type), sensor.Description,
+		sensor.IsActive, calibration, payloadMin, payloadMax, sensor.Version,
+	).Scan(&sensor.RegisteredAt, &sensor.CurrentState, &sensor.LastActivity, &sensor.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// датчика нет или его версия уже другая
+		if _, err := r.GetSensorByID(ctx, sensor.ID); err != nil {
+			return err
+		}
+		return ErrSensorVersionConflict
+	}
+	if err != nil {
+		return fmt.Errorf("can't update sensor: %w", err)
+	}
+
 	return nil
 }
 
+func (r *SensorRepository) UpdateSensorState(ctx context.Context, id int64, update domain.SensorStateUpdate) (*domain.Sensor, error) {
+	sensor, err := scanSensor(r.pool.QueryRow(ctx, updateSensorStateQuery, id, update.State, update.Accumulate, update.LastActivity.UTC()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSensorNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't update sensor state: %w", err)
+	}
+
+	return sensor, nil
+}
+
 func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
-	// TODO добавьте реализацию
-	return nil, nil
+	rows, err := r.pool.Query(ctx, selectSensorsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("can't select sensors: %w", err)
+	}
+	defer rows.Close()
+
+	sensors := make([]domain.Sensor, 0)
+	for rows.Next() {
+		sensor, err := scanSensor(rows)
+		if err != nil {
+			return nil, err
+		}
+		sensors = append(sensors, *sensor)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't select sensors: %w", err)
+	}
+
+	return sensors, nil
 }
 
 func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error) {
-	// TODO добавьте реализацию
-	return nil, nil
+	return r.getSensor(ctx, selectSensorByIDQuery, id)
 }
 
 func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error) {
-	// TODO добавьте реализацию
-	return nil, nil
+	return r.getSensor(ctx, selectSensorBySerialNumberQuery, sn)
+}
+
+func (r *SensorRepository) getSensor(ctx context.Context, query string, arg any) (*domain.Sensor, error) {
+	sensor, err := scanSensor(r.pool.QueryRow(ctx, query, arg))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSensorNotFound
+	}
+
+	return sensor, err
+}
+
+func scanSensor(row pgx.Row) (*domain.Sensor, error) {
+	var (
+		sensor      domain.Sensor
+		sensorType  string
+		calibration []byte
+		payloadMin  *int64
+		payloadMax  *int64
+	)
+
+	err := row.Scan(
+		&sensor.ID, &sensor.SerialNumber, &sensorType, &sensor.CurrentState, &sensor.Description,
+		&sensor.IsActive, &sensor.RegisteredAt, &sensor.LastActivity, &calibration,
+		&payloadMin, &payloadMax, &sensor.Version,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("can't scan sensor: %w", err)
+	}
+	sensor.Type = domain.SensorType(sensorType)
+	sensor.PayloadRange = newPayloadRange(payloadMin, payloadMax)
+
+	if sensor.Calibration, err = unmarshalCalibration(calibration); err != nil {
+		return nil, err
+	}
+
+	return &sensor, nil
+}
+
+// payloadRangeBounds - границы диапазона для колонок payload_min и payload_max, NULL если диапазон не задан
+func payloadRangeBounds(r *domain.PayloadRange) (*int64, *int64) {
+	if r == nil {
+		return nil, nil
+	}
+
+	return &r.Min, &r.Max
+}
+
+func newPayloadRange(payloadMin, payloadMax *int64) *domain.PayloadRange {
+	if payloadMin == nil || payloadMax == nil {
+		return nil
+	}
+
+	return &domain.PayloadRange{Min: *payloadMin, Max: *payloadMax}
 }
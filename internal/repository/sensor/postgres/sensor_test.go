@@ -3,12 +3,15 @@ package postgres
 import (
 	"context"
 	"homework/internal/domain"
+	"homework/internal/repository/sensor/sensortest"
+	"homework/internal/usecase"
 	"homework/pkg/pg_test"
 	"testing"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -31,6 +34,21 @@ func (suite *SensorTestSuite) TearDownSuite() {
 	suite.testDB.TearDown()
 }
 
+// TestSensorRepository_Behaviour - общие проверки хранилищ датчиков, каждая на пустой таблице,
+// после проверок таблица остаётся пустой для остальных тестов набора
+func (suite *SensorTestSuite) TestSensorRepository_Behaviour() {
+	sensortest.Run(suite.T(), func(t *testing.T) usecase.SensorRepository {
+		truncate := func() {
+			_, err := suite.testDbInstance.Exec(context.Background(), `truncate sensors restart identity`)
+			require.NoError(t, err)
+		}
+		truncate()
+		t.Cleanup(truncate)
+
+		return suite.repo
+	})
+}
+
 func (suite *SensorTestSuite) TestSensorRepository_SaveSensor() {
 	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second) //nolint: govet // test stub
 
@@ -55,6 +73,7 @@ func (suite *SensorTestSuite) TestSensorRepository_SaveSensor() {
 
 	assert.Nil(suite.T(), err)
 	assert.NotEqual(suite.T(), sensor.RegisteredAt, sensor.LastActivity)
+	assert.Equal(suite.T(), int64(1), sensor.Version)
 
 	updatedSensor := domain.Sensor{
 		ID:           sensor.ID,
@@ -65,19 +84,35 @@ func (suite *SensorTestSuite) TestSensorRepository_SaveSensor() {
 		IsActive:     false,
 		RegisteredAt: time.Now().Truncate(time.Microsecond).In(time.UTC),
 		LastActivity: time.Now().Truncate(time.Microsecond).In(time.UTC),
+		Version:      sensor.Version,
 	}
 
-	// update old sensor
+	// update old sensor: metadata changes, state is kept
 	err = suite.repo.SaveSensor(ctx, &updatedSensor)
 
 	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), int64(2), updatedSensor.Version)
+	assert.Equal(suite.T(), sensor.CurrentState, updatedSensor.CurrentState)
+	assert.Equal(suite.T(), sensor.LastActivity, updatedSensor.LastActivity)
 
 	sensor, err = suite.repo.GetSensorBySerialNumber(ctx, sn)
 
-	updatedSensor.RegisteredAt = sensor.RegisteredAt
-
 	assert.Nil(suite.T(), err)
 	assert.Equal(suite.T(), updatedSensor, *sensor)
+
+	// stale version
+	staleSensor := *sensor
+	staleSensor.Version = 1
+	staleSensor.Description = "test_desc_stale"
+	err = suite.repo.SaveSensor(ctx, &staleSensor)
+	assert.ErrorIs(suite.T(), err, ErrSensorVersionConflict)
+
+	sensor, err = suite.repo.GetSensorByID(ctx, sensor.ID)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "test_desc_2", sensor.Description)
+
+	err = suite.repo.SaveSensor(ctx, &domain.Sensor{ID: -1, SerialNumber: sn, Type: domain.SensorTypeADC, Version: 1})
+	assert.ErrorIs(suite.T(), err, ErrSensorNotFound)
 }
 
 func (suite *SensorTestSuite) TestSensorRepository_GetSensors() {
@@ -169,6 +204,50 @@ func (suite *SensorTestSuite) TestSensorRepository_GetSensorBySerialNumber() {
 	assert.Equal(suite.T(), newSensor, *sensor)
 }
 
+func (suite *SensorTestSuite) TestSensorRepository_UpdateSensorState() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().Truncate(time.Microsecond).In(time.UTC)
+	sensor := domain.Sensor{
+		SerialNumber: "3987654321",
+		Type:         domain.SensorTypeCounter,
+		CurrentState: 1,
+		Description:  "test_desc_6",
+		IsActive:     true,
+		LastActivity: now,
+		PayloadRange: &domain.PayloadRange{Min: 0, Max: 100},
+	}
+	suite.Require().NoError(suite.repo.SaveSensor(ctx, &sensor))
+
+	// параллельные приросты не теряются, остальные поля датчика не меняются
+	done := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			_, err := suite.repo.UpdateSensorState(ctx, sensor.ID, domain.SensorStateUpdate{State: 2, Accumulate: true, LastActivity: now.Add(time.Second)})
+			done <- err
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		suite.Require().NoError(<-done)
+	}
+
+	actual, err := suite.repo.GetSensorByID(ctx, sensor.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(21), actual.CurrentState)
+	assert.Equal(suite.T(), now.Add(time.Second), actual.LastActivity)
+	assert.Equal(suite.T(), sensor.PayloadRange, actual.PayloadRange)
+
+	// событие старше последней активности не заменяет состояние
+	actual, err = suite.repo.UpdateSensorState(ctx, sensor.ID, domain.SensorStateUpdate{State: 5, LastActivity: now})
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(21), actual.CurrentState)
+	assert.Equal(suite.T(), now.Add(time.Second), actual.LastActivity)
+
+	_, err = suite.repo.UpdateSensorState(ctx, -1, domain.SensorStateUpdate{State: 1})
+	assert.ErrorIs(suite.T(), err, ErrSensorNotFound)
+}
+
 func TestSensorTestSuite(t *testing.T) {
 	suite.Run(t, new(SensorTestSuite))
 }
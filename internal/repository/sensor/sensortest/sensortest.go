@@ -0,0 +1,358 @@
+// Package sensortest - общие проверки поведения хранилищ датчиков, которые запускаются
+// в тестах каждой реализации usecase.SensorRepository.
+package sensortest
+
+import (
+	"context"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Run - функция запуска проверок, newRepository должна возвращать пустое хранилище для каждой проверки
+func Run(t *testing.T, newRepository func(t *testing.T) usecase.SensorRepository) {
+	t.Run("SaveSensor", func(t *testing.T) {
+		testSaveSensor(t, newRepository)
+	})
+	t.Run("UpdateSensorState", func(t *testing.T) {
+		testUpdateSensorState(t, newRepository)
+	})
+	t.Run("GetSensors", func(t *testing.T) {
+		testGetSensors(t, newRepository)
+	})
+	t.Run("GetSensorByID", func(t *testing.T) {
+		testGetSensorByID(t, newRepository)
+	})
+	t.Run("GetSensorBySerialNumber", func(t *testing.T) {
+		testGetSensorBySerialNumber(t, newRepository)
+	})
+}
+
+// utcNow - текущее время с точностью хранения postgres
+func utcNow() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func testSaveSensor(t *testing.T, newRepository func(t *testing.T) usecase.SensorRepository) {
+	t.Run("err, sensor is nil", func(t *testing.T) {
+		sr := newRepository(t)
+		err := sr.SaveSensor(context.Background(), nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := sr.SaveSensor(ctx, &domain.Sensor{})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("fail, ctx deadline exceeded", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		defer cancel()
+
+		err := sr.SaveSensor(ctx, &domain.Sensor{})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("ok, save and get one", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sensor := &domain.Sensor{
+			SerialNumber: "12345678",
+			Type:         domain.SensorTypeContactClosure,
+			CurrentState: 0,
+			Description:  "sensor description",
+			IsActive:     true,
+		}
+
+		err := sr.SaveSensor(ctx, sensor)
+		assert.NoError(t, err)
+
+		actualSensor, err := sr.GetSensorByID(ctx, sensor.ID)
+		assert.NoError(t, err)
+		assert.NotNil(t, actualSensor)
+		assert.Equal(t, sensor.ID, actualSensor.ID)
+		assert.Equal(t, sensor.SerialNumber, actualSensor.SerialNumber)
+		assert.Equal(t, sensor.Description, actualSensor.Description)
+		assert.Equal(t, sensor.IsActive, actualSensor.IsActive)
+		assert.NotEmpty(t, actualSensor.RegisteredAt)
+		assert.Empty(t, actualSensor.LastActivity)
+	})
+
+	t.Run("ok, update checks version and keeps state", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sensor := &domain.Sensor{SerialNumber: "0123456789", Type: domain.SensorTypeCounter}
+		assert.NoError(t, sr.SaveSensor(ctx, sensor))
+		assert.Equal(t, int64(1), sensor.Version)
+		_, err := sr.UpdateSensorState(ctx, sensor.ID, domain.SensorStateUpdate{State: 5, Accumulate: true, LastActivity: utcNow()})
+		assert.NoError(t, err)
+
+		stale := *sensor
+		sensor.Description = "kitchen"
+		sensor.CurrentState = 42
+		assert.NoError(t, sr.SaveSensor(ctx, sensor))
+		assert.Equal(t, int64(2), sensor.Version)
+		assert.Equal(t, int64(5), sensor.CurrentState)
+
+		stale.Description = "hall"
+		assert.ErrorIs(t, sr.SaveSensor(ctx, &stale), usecase.ErrSensorVersionConflict)
+
+		actualSensor, err := sr.GetSensorByID(ctx, sensor.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, sensor, actualSensor)
+	})
+
+	t.Run("ok, collision test", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		wg := sync.WaitGroup{}
+		r := rand.New(rand.NewPCG(42, 1024))
+		for i := 0; i < 1000; i++ {
+			sensor := &domain.Sensor{
+				SerialNumber: strconv.Itoa(r.IntN(1000000000)),
+				Type:         domain.SensorTypeADC,
+				CurrentState: 0,
+				Description:  fmt.Sprintf("some description %d", i),
+				IsActive:     false,
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, sr.SaveSensor(ctx, sensor))
+			}()
+		}
+
+		wg.Wait()
+
+		sensors, err := sr.GetSensors(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, sensors, 1000)
+	})
+}
+
+func testUpdateSensorState(t *testing.T, newRepository func(t *testing.T) usecase.SensorRepository) {
+	t.Run("fail, not found", func(t *testing.T) {
+		sr := newRepository(t)
+		_, err := sr.UpdateSensorState(context.Background(), 1, domain.SensorStateUpdate{State: 1})
+		assert.ErrorIs(t, err, usecase.ErrSensorNotFound)
+	})
+
+	t.Run("ok, only state is updated", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sensor := &domain.Sensor{
+			SerialNumber: "0123456789",
+			Type:         domain.SensorTypeADC,
+			PayloadRange: &domain.PayloadRange{Min: 0, Max: 100},
+		}
+		assert.NoError(t, sr.SaveSensor(ctx, sensor))
+
+		now := utcNow()
+		updated, err := sr.UpdateSensorState(ctx, sensor.ID, domain.SensorStateUpdate{State: 42, LastActivity: now})
+		assert.NoError(t, err)
+
+		sensor.CurrentState = 42
+		sensor.LastActivity = now
+		assert.Equal(t, sensor, updated)
+		actualSensor, err := sr.GetSensorByID(ctx, sensor.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, sensor, actualSensor)
+	})
+
+	t.Run("ok, older event doesn't replace state", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sensor := &domain.Sensor{SerialNumber: "0123456789", Type: domain.SensorTypeADC}
+		assert.NoError(t, sr.SaveSensor(ctx, sensor))
+
+		now := utcNow()
+		_, err := sr.UpdateSensorState(ctx, sensor.ID, domain.SensorStateUpdate{State: 42, LastActivity: now})
+		assert.NoError(t, err)
+
+		updated, err := sr.UpdateSensorState(ctx, sensor.ID, domain.SensorStateUpdate{State: 5, LastActivity: now.Add(-time.Second)})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), updated.CurrentState)
+		assert.Equal(t, now, updated.LastActivity)
+	})
+
+	t.Run("ok, concurrent increments are not lost", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sensor := &domain.Sensor{SerialNumber: "0123456789", Type: domain.SensorTypeCounter}
+		assert.NoError(t, sr.SaveSensor(ctx, sensor))
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := sr.UpdateSensorState(ctx, sensor.ID, domain.SensorStateUpdate{State: 2, Accumulate: true, LastActivity: utcNow()})
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		actualSensor, err := sr.GetSensorByID(ctx, sensor.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(200), actualSensor.CurrentState)
+	})
+}
+
+func testGetSensors(t *testing.T, newRepository func(t *testing.T) usecase.SensorRepository) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := sr.GetSensors(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("fail, ctx deadline exceeded", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		defer cancel()
+
+		_, err := sr.GetSensors(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("ok, get empty list", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sensors, err := sr.GetSensors(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, sensors, 0)
+	})
+
+	t.Run("ok, get list", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		r := rand.New(rand.NewPCG(42, 1024))
+		for i := 0; i < 10; i++ {
+			sensor := &domain.Sensor{
+				SerialNumber: strconv.Itoa(r.IntN(1000000000)),
+				Type:         domain.SensorTypeADC,
+				CurrentState: 0,
+				Description:  fmt.Sprintf("some description %d", i),
+				IsActive:     false,
+			}
+			assert.NoError(t, sr.SaveSensor(ctx, sensor))
+		}
+
+		sensors, err := sr.GetSensors(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, sensors, 10)
+	})
+}
+
+func testGetSensorByID(t *testing.T, newRepository func(t *testing.T) usecase.SensorRepository) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := sr.GetSensorByID(ctx, 0)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("fail, ctx deadline exceeded", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		defer cancel()
+
+		_, err := sr.GetSensorByID(ctx, 0)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("fail, not found", func(t *testing.T) {
+		sr := newRepository(t)
+
+		_, err := sr.GetSensorByID(context.Background(), 123)
+		assert.ErrorIs(t, err, usecase.ErrSensorNotFound)
+	})
+}
+
+func testGetSensorBySerialNumber(t *testing.T, newRepository func(t *testing.T) usecase.SensorRepository) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := sr.GetSensorBySerialNumber(ctx, "1234")
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("fail, ctx deadline exceeded", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		defer cancel()
+
+		_, err := sr.GetSensorBySerialNumber(ctx, "12345")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("fail, not found", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		_, err := sr.GetSensorBySerialNumber(ctx, "12345")
+		assert.ErrorIs(t, err, usecase.ErrSensorNotFound)
+	})
+
+	t.Run("ok, save and get one", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sensor := &domain.Sensor{
+			SerialNumber: "12345678",
+			Type:         domain.SensorTypeContactClosure,
+			CurrentState: 0,
+			Description:  "sensor description",
+			IsActive:     true,
+		}
+
+		err := sr.SaveSensor(ctx, sensor)
+		assert.NoError(t, err)
+
+		actualSensor, err := sr.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+		assert.NoError(t, err)
+		assert.NotNil(t, actualSensor)
+		assert.Equal(t, sensor.ID, actualSensor.ID)
+		assert.Equal(t, sensor.SerialNumber, actualSensor.SerialNumber)
+		assert.Equal(t, sensor.Description, actualSensor.Description)
+		assert.Equal(t, sensor.IsActive, actualSensor.IsActive)
+		assert.NotEmpty(t, actualSensor.RegisteredAt)
+		assert.Empty(t, actualSensor.LastActivity)
+	})
+}
@@ -0,0 +1,178 @@
+// Package snapshot - сохранение и восстановление состояния репозиториев inmemory в файл.
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/pkg/journal"
+	"os"
+	"time"
+
+	eventMemory "homework/internal/repository/event/inmemory"
+	sensorMemory "homework/internal/repository/sensor/inmemory"
+	userMemory "homework/internal/repository/user/inmemory"
+)
+
+// Version - версия формата снимка
+const Version = 1
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported snapshot version")
+	ErrInconsistent       = errors.New("inconsistent snapshot")
+)
+
+// State - состояние репозиториев в снимке
+type State struct {
+	Version      int                  `json:"version"`
+	CreatedAt    time.Time            `json:"created_at"`
+	Users        []domain.User        `json:"users"`
+	Sensors      []domain.Sensor      `json:"sensors"`
+	SensorOwners []domain.SensorOwner `json:"sensor_owners"`
+	Events       eventMemory.Snapshot `json:"events"`
+}
+
+// Repositories - репозитории, состояние которых сохраняется в снимок
+type Repositories struct {
+	Event       *eventMemory.EventRepository
+	Sensor      *sensorMemory.SensorRepository
+	User        *userMemory.UserRepository
+	SensorOwner *userMemory.SensorOwnerRepository
+}
+
+// Save - сохраняет состояние репозиториев в файл path. Файл заменяется целиком, поэтому
+// при сбое во время записи остаётся предыдущий снимок.
+func Save(ctx context.Context, path string, repos Repositories) error {
+	// пользователи и датчики не удаляются, поэтому они читаются последними:
+	// привязки и события, попавшие в снимок, всегда ссылаются на существующие записи
+	state := State{
+		Version:      Version,
+		CreatedAt:    time.Now().UTC(),
+		SensorOwners: repos.SensorOwner.SensorOwners(),
+		Events:       repos.Event.Snapshot(),
+		Users:        repos.User.Users(),
+	}
+
+	sensors, err := repos.Sensor.GetSensors(ctx)
+	if err != nil {
+		return fmt.Errorf("can't get sensors: %w", err)
+	}
+	state.Sensors = sensors
+
+	content, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("can't marshal snapshot: %w", err)
+	}
+
+	return journal.WriteFileAtomic(path, content)
+}
+
+// Load - загружает снимок из файла path в пустые репозитории.
+// Если файла нет, возвращается ошибка os.ErrNotExist; снимок с ошибками не загружается.
+func Load(path string, repos Repositories) (*State, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't read snapshot: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(content, &state); err != nil {
+		return nil, fmt.Errorf("%w: can't unmarshal: %w", ErrInconsistent, err)
+	}
+	if state.Version != Version {
+		return nil, fmt.Errorf("%w: %d, expected %d", ErrUnsupportedVersion, state.Version, Version)
+	}
+	if err := state.Validate(); err != nil {
+		return nil, err
+	}
+
+	repos.User.PutUsers(state.Users...)
+	repos.Sensor.PutSensors(state.Sensors...)
+	for _, owner := range state.SensorOwners {
+		if err := repos.SensorOwner.SaveSensorOwner(context.Background(), owner); err != nil {
+			return nil, fmt.Errorf("can't restore sensor owner: %w", err)
+		}
+	}
+	repos.Event.Restore(state.Events)
+
+	return &state, nil
+}
+
+// Validate - проверка согласованности снимка: уникальность ID и серийных номеров,
+// существование пользователей и датчиков, на которые ссылаются привязки, события и свёртки
+func (s *State) Validate() error {
+	var errs []error
+
+	users := make(map[int64]struct{}, len(s.Users))
+	for _, user := range s.Users {
+		if user.ID < 1 {
+			errs = append(errs, fmt.Errorf("user %q has invalid id %d", user.Name, user.ID))
+		}
+		if _, ok := users[user.ID]; ok {
+			errs = append(errs, fmt.Errorf("duplicate user id %d", user.ID))
+		}
+		users[user.ID] = struct{}{}
+	}
+
+	sensors := make(map[int64]struct{}, len(s.Sensors))
+	serialNumbers := make(map[string]int64, len(s.Sensors))
+	for _, sensor := range s.Sensors {
+		if sensor.ID < 1 {
+			errs = append(errs, fmt.Errorf("sensor %q has invalid id %d", sensor.SerialNumber, sensor.ID))
+		}
+		if _, ok := sensors[sensor.ID]; ok {
+			errs = append(errs, fmt.Errorf("duplicate sensor id %d", sensor.ID))
+		}
+		sensors[sensor.ID] = struct{}{}
+
+		if id, ok := serialNumbers[sensor.SerialNumber]; ok {
+			errs = append(errs, fmt.Errorf("sensors %d and %d have the same serial number %q", id, sensor.ID, sensor.SerialNumber))
+		}
+		serialNumbers[sensor.SerialNumber] = sensor.ID
+	}
+
+	for _, owner := range s.SensorOwners {
+		if _, ok := users[owner.UserID]; !ok {
+			errs = append(errs, fmt.Errorf("sensor owner refers to unknown user %d", owner.UserID))
+		}
+		if _, ok := sensors[owner.SensorID]; !ok {
+			errs = append(errs, fmt.Errorf("sensor owner refers to unknown sensor %d", owner.SensorID))
+		}
+	}
+
+	// о каждом неизвестном датчике сообщается один раз, событий может быть много
+	unknown := make(map[int64]struct{})
+	checkSensor := func(what string, id int64) {
+		if _, ok := sensors[id]; ok {
+			return
+		}
+		if _, ok := unknown[id]; ok {
+			return
+		}
+		unknown[id] = struct{}{}
+		errs = append(errs, fmt.Errorf("%s refers to unknown sensor %d", what, id))
+	}
+	for _, event := range s.Events.Events {
+		checkSensor("event", event.SensorID)
+	}
+	for _, event := range s.Events.Last {
+		checkSensor("last event", event.SensorID)
+	}
+	for resolution, rollups := range s.Events.Rollups {
+		if resolution != domain.RollupResolutionHour && resolution != domain.RollupResolutionDay {
+			errs = append(errs, fmt.Errorf("unknown rollup resolution %q", resolution))
+			continue
+		}
+		for _, rollup := range rollups {
+			checkSensor("rollup", rollup.SensorID)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInconsistent, errors.Join(errs...))
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package snapshot
+
+import (
+	"context"
+	"homework/internal/domain"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	eventMemory "homework/internal/repository/event/inmemory"
+	sensorMemory "homework/internal/repository/sensor/inmemory"
+	userMemory "homework/internal/repository/user/inmemory"
+)
+
+func newRepositories() Repositories {
+	return Repositories{
+		Event:       eventMemory.NewEventRepository(),
+		Sensor:      sensorMemory.NewSensorRepository(),
+		User:        userMemory.NewUserRepository(),
+		SensorOwner: userMemory.NewSensorOwnerRepository(),
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	repos := newRepositories()
+
+	user := &domain.User{Name: "Alice"}
+	require.NoError(t, repos.User.SaveUser(ctx, user))
+	sensor := &domain.Sensor{
+		SerialNumber: "0123456789",
+		Type:         domain.SensorTypeADC,
+		Calibration:  &domain.Calibration{Kind: domain.CalibrationKindLinear, Scale: 0.1, Unit: "°C"},
+	}
+	require.NoError(t, repos.Sensor.SaveSensor(ctx, sensor))
+	require.NoError(t, repos.SensorOwner.SaveSensorOwner(ctx, domain.SensorOwner{UserID: user.ID, SensorID: sensor.ID}))
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		require.NoError(t, repos.Event.SaveEvent(ctx, &domain.Event{
+			Timestamp:          day.Add(time.Duration(i) * time.Hour),
+			SensorSerialNumber: sensor.SerialNumber,
+			SensorID:           sensor.ID,
+			Payload:            int64(i),
+		}))
+	}
+	_, err := repos.Event.DeleteEventsBefore(ctx, sensor.ID, day.Add(time.Hour), 10)
+	require.NoError(t, err)
+
+	require.NoError(t, Save(ctx, path, repos))
+
+	loaded := newRepositories()
+	state, err := Load(path, loaded)
+	require.NoError(t, err)
+	assert.Equal(t, Version, state.Version)
+
+	actualUser, err := loaded.User.GetUserByID(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, *user, *actualUser)
+
+	actualSensor, err := loaded.Sensor.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, sensor.ID, actualSensor.ID)
+	assert.Equal(t, sensor.Calibration, actualSensor.Calibration)
+	assert.True(t, sensor.RegisteredAt.Equal(actualSensor.RegisteredAt))
+
+	owners, err := loaded.SensorOwner.GetSensorsByUserID(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.SensorOwner{{UserID: user.ID, SensorID: sensor.ID}}, owners)
+
+	events, err := loaded.Event.GetEvents(ctx, sensor.ID, day, day.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	rollups, err := loaded.Event.GetRollups(ctx, sensor.ID, domain.RollupResolutionDay, day, day.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Rollup{{SensorID: sensor.ID, Bucket: day, Min: 0, Max: 2, Sum: 3, Count: 3, Last: 2, LastAt: day.Add(2 * time.Hour)}}, rollups)
+
+	// новые записи получают следующие ID
+	newSensor := &domain.Sensor{SerialNumber: "9876543210", Type: domain.SensorTypeADC}
+	assert.NoError(t, loaded.Sensor.SaveSensor(ctx, newSensor))
+	assert.Equal(t, sensor.ID+1, newSensor.ID)
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("fail, no snapshot", func(t *testing.T) {
+		_, err := Load(filepath.Join(t.TempDir(), "state.json"), newRepositories())
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("fail, unsupported version", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "state.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"version":2}`), 0o644))
+
+		_, err := Load(path, newRepositories())
+		assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	})
+
+	t.Run("fail, corrupted snapshot", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "state.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"version":1,`), 0o644))
+
+		_, err := Load(path, newRepositories())
+		assert.ErrorIs(t, err, ErrInconsistent)
+	})
+
+	t.Run("fail, inconsistent snapshot is not loaded", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "state.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{
+			"version": 1,
+			"users": [{"ID": 1, "Name": "Alice"}],
+			"sensor_owners": [{"UserID": 1, "SensorID": 5}]
+		}`), 0o644))
+
+		repos := newRepositories()
+		_, err := Load(path, repos)
+		assert.ErrorIs(t, err, ErrInconsistent)
+		assert.ErrorContains(t, err, "unknown sensor 5")
+		assert.Empty(t, repos.User.Users())
+	})
+}
+
+func TestState_Validate(t *testing.T) {
+	sensors := []domain.Sensor{{ID: 1, SerialNumber: "0000000001"}, {ID: 2, SerialNumber: "0000000002"}}
+
+	tests := []struct {
+		name  string
+		state State
+		want  string
+	}{
+		{
+			name:  "ok, empty",
+			state: State{},
+		},
+		{
+			name: "ok, consistent",
+			state: State{
+				Users:        []domain.User{{ID: 1}},
+				Sensors:      sensors,
+				SensorOwners: []domain.SensorOwner{{UserID: 1, SensorID: 2}},
+				Events: eventMemory.Snapshot{
+					Events:  []domain.Event{{SensorID: 1}},
+					Last:    []domain.Event{{SensorID: 2}},
+					Rollups: map[domain.RollupResolution][]domain.Rollup{domain.RollupResolutionHour: {{SensorID: 1}}},
+				},
+			},
+		},
+		{
+			name:  "fail, duplicate serial number",
+			state: State{Sensors: []domain.Sensor{{ID: 1, SerialNumber: "0000000001"}, {ID: 2, SerialNumber: "0000000001"}}},
+			want:  "same serial number",
+		},
+		{
+			name:  "fail, duplicate user",
+			state: State{Users: []domain.User{{ID: 1}, {ID: 1}}},
+			want:  "duplicate user id 1",
+		},
+		{
+			name:  "fail, owner of unknown user",
+			state: State{Sensors: sensors, SensorOwners: []domain.SensorOwner{{UserID: 3, SensorID: 1}}},
+			want:  "unknown user 3",
+		},
+		{
+			name:  "fail, event of unknown sensor",
+			state: State{Sensors: sensors, Events: eventMemory.Snapshot{Events: []domain.Event{{SensorID: 3}, {SensorID: 3}}}},
+			want:  "event refers to unknown sensor 3",
+		},
+		{
+			name: "fail, unknown rollup resolution",
+			state: State{Events: eventMemory.Snapshot{
+				Rollups: map[domain.RollupResolution][]domain.Rollup{"week": {}},
+			}},
+			want: `unknown rollup resolution "week"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.state.Validate()
+			if tt.want == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInconsistent)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
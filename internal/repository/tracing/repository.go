@@ -0,0 +1,208 @@
+// Package tracing - обёртки над репозиториями, открывающие span на каждый вызов
+package tracing
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"time"
+
+	"go.opentelemetry.io/otel"
+
+	appTracing "homework/internal/tracing"
+)
+
+var tracer = otel.Tracer("homework/internal/repository")
+
+// SensorRepository - обёртка над usecase.SensorRepository, открывающая span на каждую операцию
+type SensorRepository struct {
+	next usecase.SensorRepository
+}
+
+var _ usecase.SensorRepository = (*SensorRepository)(nil)
+
+func NewSensorRepository(next usecase.SensorRepository) *SensorRepository {
+	return &SensorRepository{next: next}
+}
+
+func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) (err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "SensorRepository.SaveSensor")
+	defer appTracing.End(span, &err)
+	return r.next.SaveSensor(ctx, sensor)
+}
+
+func (r *SensorRepository) UpdateSensorState(ctx context.Context, id int64, update domain.SensorStateUpdate) (_ *domain.Sensor, err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "SensorRepository.UpdateSensorState")
+	defer appTracing.End(span, &err)
+	return r.next.UpdateSensorState(ctx, id, update)
+}
+
+func (r *SensorRepository) GetSensors(ctx context.Context) (_ []domain.Sensor, err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "SensorRepository.GetSensors")
+	defer appTracing.End(span, &err)
+	return r.next.GetSensors(ctx)
+}
+
+func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (_ *domain.Sensor, err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "SensorRepository.GetSensorByID")
+	defer appTracing.End(span, &err)
+	return r.next.GetSensorByID(ctx, id)
+}
+
+func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (_ *domain.Sensor, err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "SensorRepository.GetSensorBySerialNumber")
+	defer appTracing.End(span, &err)
+	return r.next.GetSensorBySerialNumber(ctx, sn)
+}
+
+// EventRepository - обёртка над usecase.EventRepository, открывающая span на каждую операцию
+type EventRepository struct {
+	next usecase.EventRepository
+}
+
+var _ usecase.EventRepository = (*EventRepository)(nil)
+
+func NewEventRepository(next usecase.EventRepository) *EventRepository {
+	return &EventRepository{next: next}
+}
+
+func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) (err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "EventRepository.SaveEvent")
+	defer appTracing.End(span, &err)
+	return r.next.SaveEvent(ctx, event)
+}
+
+func (r *EventRepository) SaveEvents(ctx context.Context, events []domain.Event) (err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "EventRepository.SaveEvents")
+	defer appTracing.End(span, &err)
+	return r.next.SaveEvents(ctx, events)
+}
+
+func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (_ *domain.Event, err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "EventRepository.GetLastEventBySensorID")
+	defer appTracing.End(span, &err)
+	return r.next.GetLastEventBySensorID(ctx, id)
+}
+
+func (r *EventRepository) GetStateIntervals(ctx context.Context, id int64, from, to time.Time) (_ []domain.StateInterval, err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "EventRepository.GetStateIntervals")
+	defer appTracing.End(span, &err)
+	return r.next.GetStateIntervals(ctx, id, from, to)
+}
+
+func (r *EventRepository) GetEvents(ctx context.Context, id int64, from, to time.Time) (_ []domain.Event, err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "EventRepository.GetEvents")
+	defer appTracing.End(span, &err)
+	return r.next.GetEvents(ctx, id, from, to)
+}
+
+func (r *EventRepository) GetRollups(ctx context.Context, id int64, resolution domain.RollupResolution, from, to time.Time) (_ []domain.Rollup, err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "EventRepository.GetRollups")
+	defer appTracing.End(span, &err)
+	return r.next.GetRollups(ctx, id, resolution, from, to)
+}
+
+func (r *EventRepository) DeleteEventsBefore(ctx context.Context, id int64, before time.Time, limit int) (_ int64, err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "EventRepository.DeleteEventsBefore")
+	defer appTracing.End(span, &err)
+	return r.next.DeleteEventsBefore(ctx, id, before, limit)
+}
+
+// UserRepository - обёртка над usecase.UserRepository, открывающая span на каждую операцию
+type UserRepository struct {
+	next usecase.UserRepository
+}
+
+var _ usecase.UserRepository = (*UserRepository)(nil)
+
+func NewUserRepository(next usecase.UserRepository) *UserRepository {
+	return &UserRepository{next: next}
+}
+
+func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) (err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "UserRepository.SaveUser")
+	defer appTracing.End(span, &err)
+	return r.next.SaveUser(ctx, user)
+}
+
+func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (_ *domain.User, err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "UserRepository.GetUserByID")
+	defer appTracing.End(span, &err)
+	return r.next.GetUserByID(ctx, id)
+}
+
+func (r *UserRepository) GetUsers(ctx context.Context) (_ []domain.User, err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "UserRepository.GetUsers")
+	defer appTracing.End(span, &err)
+	return r.next.GetUsers(ctx)
+}
+
+// SensorOwnerRepository - обёртка над usecase.SensorOwnerRepository, открывающая span на каждую операцию
+type SensorOwnerRepository struct {
+	next usecase.SensorOwnerRepository
+}
+
+var _ usecase.SensorOwnerRepository = (*SensorOwnerRepository)(nil)
+
+func NewSensorOwnerRepository(next usecase.SensorOwnerRepository) *SensorOwnerRepository {
+	return &SensorOwnerRepository{next: next}
+}
+
+func (r *SensorOwnerRepository) SaveSensorOwner(ctx context.Context, sensorOwner domain.SensorOwner) (err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "SensorOwnerRepository.SaveSensorOwner")
+	defer appTracing.End(span, &err)
+	return r.next.SaveSensorOwner(ctx, sensorOwner)
+}
+
+func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID int64) (_ []domain.SensorOwner, err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "SensorOwnerRepository.GetSensorsByUserID")
+	defer appTracing.End(span, &err)
+	return r.next.GetSensorsByUserID(ctx, userID)
+}
+
+func (r *SensorOwnerRepository) GetSensorOwners(ctx context.Context) (_ []domain.SensorOwner, err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "SensorOwnerRepository.GetSensorOwners")
+	defer appTracing.End(span, &err)
+	return r.next.GetSensorOwners(ctx)
+}
+
+// QuarantineRepository - обёртка над usecase.QuarantineRepository, открывающая span на каждую операцию
+type QuarantineRepository struct {
+	next usecase.QuarantineRepository
+}
+
+var _ usecase.QuarantineRepository = (*QuarantineRepository)(nil)
+
+func NewQuarantineRepository(next usecase.QuarantineRepository) *QuarantineRepository {
+	return &QuarantineRepository{next: next}
+}
+
+func (r *QuarantineRepository) SaveQuarantinedEvent(ctx context.Context, event *domain.QuarantinedEvent) (err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "QuarantineRepository.SaveQuarantinedEvent")
+	defer appTracing.End(span, &err)
+	return r.next.SaveQuarantinedEvent(ctx, event)
+}
+
+func (r *QuarantineRepository) GetQuarantinedEvents(ctx context.Context, reason domain.QuarantineReason) (_ []domain.QuarantinedEvent, err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "QuarantineRepository.GetQuarantinedEvents")
+	defer appTracing.End(span, &err)
+	return r.next.GetQuarantinedEvents(ctx, reason)
+}
+
+func (r *QuarantineRepository) GetUnknownDevices(ctx context.Context, sampleSize int) (_ []domain.UnknownDevice, err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "QuarantineRepository.GetUnknownDevices")
+	defer appTracing.End(span, &err)
+	return r.next.GetUnknownDevices(ctx, sampleSize)
+}
+
+func (r *QuarantineRepository) TakeQuarantinedEvents(ctx context.Context, sn string, reason domain.QuarantineReason) (_ []domain.QuarantinedEvent, err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "QuarantineRepository.TakeQuarantinedEvents")
+	defer appTracing.End(span, &err)
+	return r.next.TakeQuarantinedEvents(ctx, sn, reason)
+}
+
+func (r *QuarantineRepository) DeleteQuarantinedEventsBefore(ctx context.Context, before time.Time) (_ int64, err error) {
+	ctx, span := appTracing.Start(ctx, tracer, "QuarantineRepository.DeleteQuarantinedEventsBefore")
+	defer appTracing.End(span, &err)
+	return r.next.DeleteQuarantinedEventsBefore(ctx, before)
+}
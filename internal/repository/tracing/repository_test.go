@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// setupTracer - подменяет tracer пакета на записывающий span в память до конца теста
+func setupTracer(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	prev := tracer
+	tracer = tp.Tracer("test")
+	t.Cleanup(func() {
+		tracer = prev
+		_ = tp.Shutdown(context.Background())
+	})
+
+	return exporter
+}
+
+// inSpanMatcher - совпадает с контекстом, в котором открыт span
+type inSpanMatcher struct{}
+
+func (inSpanMatcher) Matches(x any) bool {
+	ctx, ok := x.(context.Context)
+	return ok && trace.SpanContextFromContext(ctx).IsValid()
+}
+
+func (inSpanMatcher) String() string {
+	return "is a context with span"
+}
+
+func TestSensorRepository(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	exporter := setupTracer(t)
+
+	// репозиторию передаётся контекст со span операции
+	inSpan := inSpanMatcher{}
+	next := usecase.NewMockSensorRepository(ctrl)
+	next.EXPECT().GetSensorByID(inSpan, int64(1)).Times(1).Return(&domain.Sensor{ID: 1}, nil)
+	next.EXPECT().SaveSensor(inSpan, gomock.Any()).Times(1).Return(errors.New("connection refused"))
+
+	r := NewSensorRepository(next)
+
+	sensor, err := r.GetSensorByID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), sensor.ID)
+
+	assert.Error(t, r.SaveSensor(ctx, &domain.Sensor{}))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "SensorRepository.GetSensorByID", spans[0].Name)
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	assert.Equal(t, "SensorRepository.SaveSensor", spans[1].Name)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+}
+
+func TestEventRepository(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	exporter := setupTracer(t)
+
+	next := usecase.NewMockEventRepository(ctrl)
+	next.EXPECT().SaveEvents(gomock.Any(), gomock.Len(2)).Times(1).Return(nil)
+	next.EXPECT().GetLastEventBySensorID(gomock.Any(), int64(1)).Times(1).Return(nil, usecase.ErrEventNotFound)
+
+	r := NewEventRepository(next)
+
+	assert.NoError(t, r.SaveEvents(ctx, make([]domain.Event, 2)))
+	_, err := r.GetLastEventBySensorID(ctx, 1)
+	assert.ErrorIs(t, err, usecase.ErrEventNotFound)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "EventRepository.SaveEvents", spans[0].Name)
+	assert.Equal(t, "EventRepository.GetLastEventBySensorID", spans[1].Name)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+}
@@ -0,0 +1,111 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/repository/user/inmemory"
+	"homework/pkg/journal"
+	"log/slog"
+	"sync"
+)
+
+const opSaveSensorOwner = "save_sensor_owner"
+
+// SensorOwnerRepository - репозиторий привязок датчиков к пользователям в локальных файлах каталога dir
+type SensorOwnerRepository struct {
+	mu      sync.Mutex
+	mem     *inmemory.SensorOwnerRepository
+	journal *journal.Journal
+}
+
+func NewSensorOwnerRepository(dir string, options ...func(*journal.Journal)) (*SensorOwnerRepository, error) {
+	r := &SensorOwnerRepository{mem: inmemory.NewSensorOwnerRepository()}
+
+	j, err := journal.Open(dir, "sensor_owners", r.restore, r.apply, options...)
+	if err != nil {
+		return nil, fmt.Errorf("can't open sensor owners journal: %w", err)
+	}
+	r.journal = j
+
+	return r, nil
+}
+
+func (r *SensorOwnerRepository) SaveSensorOwner(ctx context.Context, sensorOwner domain.SensorOwner) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.journal.Append(opSaveSensorOwner, sensorOwner); err != nil {
+		return fmt.Errorf("can't save sensor owner: %w", err)
+	}
+	if err := r.mem.SaveSensorOwner(ctx, sensorOwner); err != nil {
+		return err
+	}
+
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID int64) ([]domain.SensorOwner, error) {
+	return r.mem.GetSensorsByUserID(ctx, userID)
+}
+
+func (r *SensorOwnerRepository) GetSensorOwners(ctx context.Context) ([]domain.SensorOwner, error) {
+	return r.mem.GetSensorOwners(ctx)
+}
+
+// Close - сохраняет снимок состояния и закрывает журнал
+func (r *SensorOwnerRepository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return errors.Join(r.journal.Snapshot(r.mem.SensorOwners()), r.journal.Close())
+}
+
+func (r *SensorOwnerRepository) restore(data json.RawMessage) error {
+	var owners []domain.SensorOwner
+	if err := json.Unmarshal(data, &owners); err != nil {
+		return fmt.Errorf("can't unmarshal sensor owners: %w", err)
+	}
+
+	return r.save(owners...)
+}
+
+func (r *SensorOwnerRepository) apply(op string, data json.RawMessage) error {
+	if op != opSaveSensorOwner {
+		return fmt.Errorf("%w: %q", ErrUnknownOp, op)
+	}
+
+	var owner domain.SensorOwner
+	if err := json.Unmarshal(data, &owner); err != nil {
+		return fmt.Errorf("can't unmarshal sensor owner: %w", err)
+	}
+
+	return r.save(owner)
+}
+
+func (r *SensorOwnerRepository) save(owners ...domain.SensorOwner) error {
+	for _, owner := range owners {
+		if err := r.mem.SaveSensorOwner(context.Background(), owner); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *SensorOwnerRepository) snapshotIfNeeded(ctx context.Context) {
+	if !r.journal.NeedsSnapshot() {
+		return
+	}
+	if err := r.journal.Snapshot(r.mem.SensorOwners()); err != nil {
+		slog.ErrorContext(ctx, "can't snapshot sensor owners journal", "err", err)
+	}
+}
@@ -0,0 +1,54 @@
+package file
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/repository/user/usertest"
+	"homework/internal/usecase"
+	"homework/pkg/journal"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSensorOwnerRepository(t *testing.T) *SensorOwnerRepository {
+	t.Helper()
+
+	// снимок каждые несколько записей, чтобы тесты проходили и через снимки, и через журнал
+	r, err := NewSensorOwnerRepository(t.TempDir(), journal.WithSnapshotEvery(3))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, r.Close())
+	})
+
+	return r
+}
+
+func TestSensorOwnerRepository(t *testing.T) {
+	usertest.RunSensorOwnerRepository(t, func(t *testing.T) usecase.SensorOwnerRepository {
+		return newSensorOwnerRepository(t)
+	})
+}
+
+func TestSensorOwnerRepository_Reopen(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	dir := t.TempDir()
+	sor, err := NewSensorOwnerRepository(dir, journal.WithSnapshotEvery(2))
+	require.NoError(t, err)
+
+	for _, owner := range []domain.SensorOwner{{UserID: 1, SensorID: 2}, {UserID: 1, SensorID: 1}, {UserID: 2, SensorID: 1}} {
+		assert.NoError(t, sor.SaveSensorOwner(ctx, owner))
+	}
+
+	sor, err = NewSensorOwnerRepository(dir)
+	require.NoError(t, err)
+	defer sor.Close()
+
+	owners, err := sor.GetSensorsByUserID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.SensorOwner{{UserID: 1, SensorID: 1}, {UserID: 1, SensorID: 2}}, owners)
+}
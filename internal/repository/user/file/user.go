@@ -0,0 +1,127 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/repository/user/inmemory"
+	"homework/pkg/journal"
+	"log/slog"
+	"sync"
+)
+
+const opSaveUser = "save_user"
+
+var (
+	ErrUserNotFound = inmemory.ErrUserNotFound
+	ErrUserIsNil    = inmemory.ErrUserIsNil
+	ErrUnknownOp    = errors.New("unknown journal operation")
+)
+
+// UserRepository - репозиторий пользователей в локальных файлах каталога dir
+type UserRepository struct {
+	mu      sync.Mutex
+	lastID  int64
+	mem     *inmemory.UserRepository
+	journal *journal.Journal
+}
+
+func NewUserRepository(dir string, options ...func(*journal.Journal)) (*UserRepository, error) {
+	r := &UserRepository{mem: inmemory.NewUserRepository()}
+
+	j, err := journal.Open(dir, "users", r.restore, r.apply, options...)
+	if err != nil {
+		return nil, fmt.Errorf("can't open users journal: %w", err)
+	}
+	r.journal = j
+
+	return r, nil
+}
+
+func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserIsNil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	saved := *user
+	_, err := r.mem.GetUserByID(ctx, saved.ID)
+	if errors.Is(err, ErrUserNotFound) {
+		saved.ID = r.lastID + 1
+	} else if err != nil {
+		return err
+	}
+
+	if err := r.journal.Append(opSaveUser, saved); err != nil {
+		return fmt.Errorf("can't save user: %w", err)
+	}
+	r.put(saved)
+	*user = saved
+
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
+	return r.mem.GetUserByID(ctx, id)
+}
+
+func (r *UserRepository) GetUsers(ctx context.Context) ([]domain.User, error) {
+	return r.mem.GetUsers(ctx)
+}
+
+// Close - сохраняет снимок состояния и закрывает журнал
+func (r *UserRepository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return errors.Join(r.journal.Snapshot(r.mem.Users()), r.journal.Close())
+}
+
+func (r *UserRepository) put(users ...domain.User) {
+	r.mem.PutUsers(users...)
+	for _, user := range users {
+		r.lastID = max(r.lastID, user.ID)
+	}
+}
+
+func (r *UserRepository) restore(data json.RawMessage) error {
+	var users []domain.User
+	if err := json.Unmarshal(data, &users); err != nil {
+		return fmt.Errorf("can't unmarshal users: %w", err)
+	}
+	r.put(users...)
+
+	return nil
+}
+
+func (r *UserRepository) apply(op string, data json.RawMessage) error {
+	if op != opSaveUser {
+		return fmt.Errorf("%w: %q", ErrUnknownOp, op)
+	}
+
+	var user domain.User
+	if err := json.Unmarshal(data, &user); err != nil {
+		return fmt.Errorf("can't unmarshal user: %w", err)
+	}
+	r.put(user)
+
+	return nil
+}
+
+func (r *UserRepository) snapshotIfNeeded(ctx context.Context) {
+	if !r.journal.NeedsSnapshot() {
+		return
+	}
+	if err := r.journal.Snapshot(r.mem.Users()); err != nil {
+		slog.ErrorContext(ctx, "can't snapshot users journal", "err", err)
+	}
+}
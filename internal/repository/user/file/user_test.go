@@ -0,0 +1,60 @@
+package file
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/repository/user/usertest"
+	"homework/internal/usecase"
+	"homework/pkg/journal"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newUserRepository(t *testing.T) *UserRepository {
+	t.Helper()
+
+	// снимок каждые несколько записей, чтобы тесты проходили и через снимки, и через журнал
+	r, err := NewUserRepository(t.TempDir(), journal.WithSnapshotEvery(3))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, r.Close())
+	})
+
+	return r
+}
+
+func TestUserRepository(t *testing.T) {
+	usertest.RunUserRepository(t, func(t *testing.T) usecase.UserRepository {
+		return newUserRepository(t)
+	})
+}
+
+func TestUserRepository_Reopen(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	dir := t.TempDir()
+	ur, err := NewUserRepository(dir)
+	require.NoError(t, err)
+
+	user := &domain.User{Name: "Alice"}
+	assert.NoError(t, ur.SaveUser(ctx, user))
+	user.Name = "Bob"
+	assert.NoError(t, ur.SaveUser(ctx, user))
+	assert.NoError(t, ur.Close())
+
+	ur, err = NewUserRepository(dir)
+	require.NoError(t, err)
+	defer ur.Close()
+
+	actualUser, err := ur.GetUserByID(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, *user, *actualUser)
+
+	newUser := &domain.User{Name: "Carol"}
+	assert.NoError(t, ur.SaveUser(ctx, newUser))
+	assert.Equal(t, user.ID+1, newUser.ID)
+}
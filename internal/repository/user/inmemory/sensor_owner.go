@@ -3,22 +3,91 @@ package inmemory
 import (
 	"context"
 	"homework/internal/domain"
+	"sort"
+	"sync"
 )
 
 type SensorOwnerRepository struct {
-	// TODO добавьте реализацию
+	mu     sync.RWMutex
+	owners map[int64]map[int64]struct{}
 }
 
 func NewSensorOwnerRepository() *SensorOwnerRepository {
-	return &SensorOwnerRepository{}
+	return &SensorOwnerRepository{
+		owners: make(map[int64]map[int64]struct{}),
+	}
 }
 
 func (r *SensorOwnerRepository) SaveSensorOwner(ctx context.Context, sensorOwner domain.SensorOwner) error {
-	// TODO добавьте реализацию
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sensors, ok := r.owners[sensorOwner.UserID]
+	if !ok {
+		sensors = make(map[int64]struct{})
+		r.owners[sensorOwner.UserID] = sensors
+	}
+	sensors[sensorOwner.SensorID] = struct{}{}
+
 	return nil
 }
 
 func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID int64) ([]domain.SensorOwner, error) {
-	// TODO добавьте реализацию
-	return nil, nil
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	owners := make([]domain.SensorOwner, 0, len(r.owners[userID]))
+	for sensorID := range r.owners[userID] {
+		owners = append(owners, domain.SensorOwner{
+			UserID:   userID,
+			SensorID: sensorID,
+		})
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		return owners[i].SensorID < owners[j].SensorID
+	})
+
+	return owners, nil
+}
+
+func (r *SensorOwnerRepository) GetSensorOwners(ctx context.Context) ([]domain.SensorOwner, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	owners := r.SensorOwners()
+	if owners == nil {
+		owners = make([]domain.SensorOwner, 0)
+	}
+
+	return owners, nil
+}
+
+// SensorOwners - функция получения всех привязок датчиков по возрастанию ID пользователя и датчика
+func (r *SensorOwnerRepository) SensorOwners() []domain.SensorOwner {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var owners []domain.SensorOwner
+	for userID, sensors := range r.owners {
+		for sensorID := range sensors {
+			owners = append(owners, domain.SensorOwner{UserID: userID, SensorID: sensorID})
+		}
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		if owners[i].UserID != owners[j].UserID {
+			return owners[i].UserID < owners[j].UserID
+		}
+		return owners[i].SensorID < owners[j].SensorID
+	})
+
+	return owners
 }
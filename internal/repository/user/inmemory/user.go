@@ -4,24 +4,95 @@ import (
 	"context"
 	"errors"
 	"homework/internal/domain"
+	"homework/internal/usecase"
+	"sort"
+	"sync"
 )
 
-var ErrUserNotFound = errors.New("user not found")
+var (
+	ErrUserNotFound = usecase.ErrUserNotFound
+	ErrUserIsNil    = errors.New("user is nil")
+)
 
 type UserRepository struct {
-	// TODO добавьте реализацию
+	mu     sync.RWMutex
+	lastID int64
+	users  map[int64]domain.User
 }
 
 func NewUserRepository() *UserRepository {
-	return &UserRepository{}
+	return &UserRepository{
+		users: make(map[int64]domain.User),
+	}
 }
 
 func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) error {
-	// TODO добавьте реализацию
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserIsNil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.ID]; !ok {
+		r.lastID++
+		user.ID = r.lastID
+	}
+	r.users[user.ID] = *user
+
 	return nil
 }
 
 func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
-	// TODO добавьте реализацию
-	return nil, nil
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) GetUsers(ctx context.Context) ([]domain.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return r.Users(), nil
+}
+
+// Users - функция получения всех пользователей по возрастанию ID
+func (r *UserRepository) Users() []domain.User {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]domain.User, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	return users
+}
+
+// PutUsers - сохраняет пользователей как есть, с их ID. Используется для восстановления состояния.
+func (r *UserRepository) PutUsers(users ...domain.User) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range users {
+		r.users[user.ID] = user
+		r.lastID = max(r.lastID, user.ID)
+	}
 }
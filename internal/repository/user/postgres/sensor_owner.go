@@ -2,11 +2,18 @@ package postgres
 
 import (
 	"context"
+	"fmt"
 	"homework/internal/domain"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const (
+	insertSensorOwnerQuery          = `insert into sensors_users (sensor_id, user_id) values ($1, $2)`
+	selectSensorOwnersByUserIDQuery = `select user_id, sensor_id from sensors_users where user_id = $1 order by sensor_id`
+	selectSensorOwnersQuery         = `select user_id, sensor_id from sensors_users order by user_id, sensor_id`
+)
+
 type SensorOwnerRepository struct {
 	pool *pgxpool.Pool
 }
@@ -18,11 +25,40 @@ func NewSensorOwnerRepository(pool *pgxpool.Pool) *SensorOwnerRepository {
 }
 
 func (r *SensorOwnerRepository) SaveSensorOwner(ctx context.Context, sensorOwner domain.SensorOwner) error {
-	// TODO добавьте реализацию
+	_, err := r.pool.Exec(ctx, insertSensorOwnerQuery, sensorOwner.SensorID, sensorOwner.UserID)
+	if err != nil {
+		return fmt.Errorf("can't insert sensor owner: %w", err)
+	}
+
 	return nil
 }
 
 func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID int64) ([]domain.SensorOwner, error) {
-	// TODO добавьте реализацию
-	return nil, nil
+	return r.selectSensorOwners(ctx, selectSensorOwnersByUserIDQuery, userID)
+}
+
+func (r *SensorOwnerRepository) GetSensorOwners(ctx context.Context) ([]domain.SensorOwner, error) {
+	return r.selectSensorOwners(ctx, selectSensorOwnersQuery)
+}
+
+func (r *SensorOwnerRepository) selectSensorOwners(ctx context.Context, query string, args ...any) ([]domain.SensorOwner, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can't select sensor owners: %w", err)
+	}
+	defer rows.Close()
+
+	owners := make([]domain.SensorOwner, 0)
+	for rows.Next() {
+		var owner domain.SensorOwner
+		if err := rows.Scan(&owner.UserID, &owner.SensorID); err != nil {
+			return nil, fmt.Errorf("can't scan sensor owner: %w", err)
+		}
+		owners = append(owners, owner)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't select sensor owners: %w", err)
+	}
+
+	return owners, nil
 }
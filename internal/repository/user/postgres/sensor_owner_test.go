@@ -3,12 +3,15 @@ package postgres
 import (
 	"context"
 	"homework/internal/domain"
+	"homework/internal/repository/user/usertest"
+	"homework/internal/usecase"
 	"homework/pkg/pg_test"
 	"testing"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -31,6 +34,21 @@ func (suite *SensorOwnerTestSuite) TearDownSuite() {
 	suite.testDB.TearDown()
 }
 
+// TestSensorOwnerRepository_Behaviour - общие проверки хранилищ привязок датчиков, каждая на пустой таблице,
+// после проверок таблица остаётся пустой для остальных тестов набора
+func (suite *SensorOwnerTestSuite) TestSensorOwnerRepository_Behaviour() {
+	usertest.RunSensorOwnerRepository(suite.T(), func(t *testing.T) usecase.SensorOwnerRepository {
+		truncate := func() {
+			_, err := suite.testDbInstance.Exec(context.Background(), `truncate sensors_users`)
+			require.NoError(t, err)
+		}
+		truncate()
+		t.Cleanup(truncate)
+
+		return suite.repo
+	})
+}
+
 func (suite *SensorOwnerTestSuite) TestSensorOwnerRepository_SaveSensorOwner() {
 	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second) //nolint: govet // test stub
 
@@ -69,6 +87,31 @@ func (suite *SensorOwnerTestSuite) TestSensorOwnerRepository_GetSensorsByUserID(
 	}, sensors)
 }
 
+func (suite *SensorOwnerTestSuite) TestSensorOwnerRepository_GetSensorOwners() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, owner := range []domain.SensorOwner{{UserID: 5, SensorID: 7}, {UserID: 4, SensorID: 8}, {UserID: 4, SensorID: 6}} {
+		assert.NoError(suite.T(), suite.repo.SaveSensorOwner(ctx, owner))
+	}
+
+	owners, err := suite.repo.GetSensorOwners(ctx)
+	assert.NoError(suite.T(), err)
+
+	// другие тесты набора тоже сохраняют привязки, проверяется порядок добавленных
+	var added []domain.SensorOwner
+	for _, owner := range owners {
+		if owner.UserID == 4 || owner.UserID == 5 {
+			added = append(added, owner)
+		}
+	}
+	assert.Equal(suite.T(), []domain.SensorOwner{
+		{UserID: 4, SensorID: 6},
+		{UserID: 4, SensorID: 8},
+		{UserID: 5, SensorID: 7},
+	}, added)
+}
+
 func TestSensorOwnerTestSuite(t *testing.T) {
 	suite.Run(t, new(SensorOwnerTestSuite))
 }
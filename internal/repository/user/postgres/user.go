@@ -2,8 +2,24 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"homework/internal/domain"
+	"homework/internal/usecase"
+)
+
+var (
+	ErrUserNotFound = usecase.ErrUserNotFound
+	ErrUserIsNil    = errors.New("user is nil")
+)
+
+const (
+	insertUserQuery     = `insert into users (name) values ($1) returning id`
+	updateUserQuery     = `update users set name = $2 where id = $1`
+	selectUserByIDQuery = `select id, name from users where id = $1`
+	selectUsersQuery    = `select id, name from users order by id`
 )
 
 type UserRepository struct {
@@ -17,11 +33,61 @@ func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
 }
 
 func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) error {
-	// TODO добавьте реализацию
+	if user == nil {
+		return ErrUserIsNil
+	}
+
+	if user.ID == 0 {
+		if err := r.pool.QueryRow(ctx, insertUserQuery, user.Name).Scan(&user.ID); err != nil {
+			return fmt.Errorf("can't insert user: %w", err)
+		}
+
+		return nil
+	}
+
+	tag, err := r.pool.Exec(ctx, updateUserQuery, user.ID, user.Name)
+	if err != nil {
+		return fmt.Errorf("can't update user: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
 	return nil
 }
 
 func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
-	// TODO добавьте реализацию
-	return nil, nil
+	var user domain.User
+
+	err := r.pool.QueryRow(ctx, selectUserByIDQuery, id).Scan(&user.ID, &user.Name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't select user: %w", err)
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) GetUsers(ctx context.Context) ([]domain.User, error) {
+	rows, err := r.pool.Query(ctx, selectUsersQuery)
+	if err != nil {
+		return nil, fmt.Errorf("can't select users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]domain.User, 0)
+	for rows.Next() {
+		var user domain.User
+		if err := rows.Scan(&user.ID, &user.Name); err != nil {
+			return nil, fmt.Errorf("can't scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't select users: %w", err)
+	}
+
+	return users, nil
 }
@@ -3,12 +3,15 @@ package postgres
 import (
 	"context"
 	"homework/internal/domain"
+	"homework/internal/repository/user/usertest"
+	"homework/internal/usecase"
 	"homework/pkg/pg_test"
 	"testing"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -31,6 +34,21 @@ func (suite *UserTestSuite) TearDownSuite() {
 	suite.testDB.TearDown()
 }
 
+// TestUserRepository_Behaviour - общие проверки хранилищ пользователей, каждая на пустой таблице,
+// после проверок таблица остаётся пустой для остальных тестов набора
+func (suite *UserTestSuite) TestUserRepository_Behaviour() {
+	usertest.RunUserRepository(suite.T(), func(t *testing.T) usecase.UserRepository {
+		truncate := func() {
+			_, err := suite.testDbInstance.Exec(context.Background(), `truncate users restart identity`)
+			require.NoError(t, err)
+		}
+		truncate()
+		t.Cleanup(truncate)
+
+		return suite.repo
+	})
+}
+
 func (suite *UserTestSuite) TestUserRepository_SaveUser() {
 	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second) //nolint: govet // test stub
 
@@ -48,19 +66,39 @@ func (suite *UserTestSuite) TestUserRepository_GetUserByID() {
 
 	name := "vasya pupkin"
 
-	err := suite.repo.SaveUser(ctx, &domain.User{
+	saved := &domain.User{
 		Name: name,
-	})
+	}
+	err := suite.repo.SaveUser(ctx, saved)
 
 	assert.Nil(suite.T(), err)
 
-	user, err := suite.repo.GetUserByID(ctx, 1)
+	user, err := suite.repo.GetUserByID(ctx, saved.ID)
 
 	assert.Nil(suite.T(), err)
 
 	assert.Equal(suite.T(), name, user.Name)
 }
 
+func (suite *UserTestSuite) TestUserRepository_GetUsers() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := &domain.User{Name: "ivan petrov"}
+	assert.NoError(suite.T(), suite.repo.SaveUser(ctx, user))
+
+	users, err := suite.repo.GetUsers(ctx)
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), users, *user)
+	assert.IsIncreasing(suite.T(), func() []int64 {
+		ids := make([]int64, 0, len(users))
+		for _, u := range users {
+			ids = append(ids, u.ID)
+		}
+		return ids
+	}())
+}
+
 func TestUserTestSuite(t *testing.T) {
 	suite.Run(t, new(UserTestSuite))
 }
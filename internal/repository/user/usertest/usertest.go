@@ -0,0 +1,279 @@
+// Package usertest - общие проверки поведения хранилищ пользователей и привязок датчиков, которые запускаются
+// в тестах каждой реализации usecase.UserRepository и usecase.SensorOwnerRepository.
+package usertest
+
+import (
+	"context"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RunUserRepository - функция запуска проверок хранилища пользователей,
+// newRepository должна возвращать пустое хранилище для каждой проверки
+func RunUserRepository(t *testing.T, newRepository func(t *testing.T) usecase.UserRepository) {
+	t.Run("SaveUser", func(t *testing.T) {
+		testSaveUser(t, newRepository)
+	})
+	t.Run("GetUsers", func(t *testing.T) {
+		testGetUsers(t, newRepository)
+	})
+}
+
+// RunSensorOwnerRepository - функция запуска проверок хранилища привязок датчиков,
+// newRepository должна возвращать пустое хранилище для каждой проверки
+func RunSensorOwnerRepository(t *testing.T, newRepository func(t *testing.T) usecase.SensorOwnerRepository) {
+	t.Run("SaveSensorOwner", func(t *testing.T) {
+		testSaveSensorOwner(t, newRepository)
+	})
+	t.Run("GetSensorsByUserID", func(t *testing.T) {
+		testGetSensorsByUserID(t, newRepository)
+	})
+	t.Run("GetSensorOwners", func(t *testing.T) {
+		testGetSensorOwners(t, newRepository)
+	})
+}
+
+func testSaveUser(t *testing.T, newRepository func(t *testing.T) usecase.UserRepository) {
+	t.Run("err, user is nil", func(t *testing.T) {
+		sr := newRepository(t)
+		err := sr.SaveUser(context.Background(), nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := sr.SaveUser(ctx, &domain.User{})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("fail, ctx deadline exceeded", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		defer cancel()
+
+		err := sr.SaveUser(ctx, &domain.User{})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("ok, save", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		user := domain.User{
+			Name: "User Name",
+		}
+
+		err := sr.SaveUser(ctx, &user)
+		assert.NoError(t, err)
+
+		actualUser, err := sr.GetUserByID(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, user, *actualUser)
+	})
+
+	t.Run("ok, collision test", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 1000; i++ {
+			user := &domain.User{
+				Name: fmt.Sprintf("use name #%d", i),
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, sr.SaveUser(ctx, user))
+			}()
+		}
+
+		wg.Wait()
+	})
+}
+
+func testGetUsers(t *testing.T, newRepository func(t *testing.T) usecase.UserRepository) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		ur := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := ur.GetUsers(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("ok, get sorted list", func(t *testing.T) {
+		ur := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		for _, name := range []string{"Homer", "Marge", "Bart"} {
+			assert.NoError(t, ur.SaveUser(ctx, &domain.User{Name: name}))
+		}
+
+		users, err := ur.GetUsers(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.User{{ID: 1, Name: "Homer"}, {ID: 2, Name: "Marge"}, {ID: 3, Name: "Bart"}}, users)
+	})
+}
+
+func testSaveSensorOwner(t *testing.T, newRepository func(t *testing.T) usecase.SensorOwnerRepository) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		sor := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := sor.SaveSensorOwner(ctx, domain.SensorOwner{})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("fail, ctx deadline exceeded", func(t *testing.T) {
+		sor := newRepository(t)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		defer cancel()
+
+		err := sor.SaveSensorOwner(ctx, domain.SensorOwner{})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("ok, save and get one", func(t *testing.T) {
+		sor := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sensorOwner := domain.SensorOwner{
+			UserID:   1234,
+			SensorID: 5678,
+		}
+
+		err := sor.SaveSensorOwner(ctx, sensorOwner)
+		assert.NoError(t, err)
+
+		list, err := sor.GetSensorsByUserID(ctx, 1234)
+		assert.NoError(t, err)
+
+		assert.Len(t, list, 1)
+		assert.Equal(t, list[0].SensorID, int64(5678))
+	})
+
+	t.Run("ok, collision test", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		wg := sync.WaitGroup{}
+		for i := int64(0); i < 1000; i++ {
+			sensorOwner := domain.SensorOwner{
+				UserID:   1234 + i,
+				SensorID: 5678 + i,
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, sr.SaveSensorOwner(ctx, sensorOwner))
+			}()
+		}
+
+		wg.Wait()
+	})
+}
+
+func testGetSensorsByUserID(t *testing.T, newRepository func(t *testing.T) usecase.SensorOwnerRepository) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		sor := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := sor.GetSensorsByUserID(ctx, 1)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("fail, ctx deadline exceeded", func(t *testing.T) {
+		sor := newRepository(t)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		defer cancel()
+
+		_, err := sor.GetSensorsByUserID(ctx, 1)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("ok, get empty list", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sensors, err := sr.GetSensorsByUserID(ctx, 1)
+		assert.NoError(t, err)
+		assert.Len(t, sensors, 0)
+	})
+
+	t.Run("ok, get list", func(t *testing.T) {
+		sr := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		assert.NoError(t, sr.SaveSensorOwner(ctx, domain.SensorOwner{UserID: 1, SensorID: 1}))
+		assert.NoError(t, sr.SaveSensorOwner(ctx, domain.SensorOwner{UserID: 1, SensorID: 2}))
+		assert.NoError(t, sr.SaveSensorOwner(ctx, domain.SensorOwner{UserID: 1, SensorID: 3}))
+		assert.NoError(t, sr.SaveSensorOwner(ctx, domain.SensorOwner{UserID: 2, SensorID: 4}))
+		assert.NoError(t, sr.SaveSensorOwner(ctx, domain.SensorOwner{UserID: 3, SensorID: 5}))
+
+		sensors, err := sr.GetSensorsByUserID(ctx, 1)
+		assert.NoError(t, err)
+		assert.Len(t, sensors, 3)
+
+		sensors, err = sr.GetSensorsByUserID(ctx, 3)
+		assert.NoError(t, err)
+		assert.Len(t, sensors, 1)
+	})
+}
+
+func testGetSensorOwners(t *testing.T, newRepository func(t *testing.T) usecase.SensorOwnerRepository) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		sor := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := sor.GetSensorOwners(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("ok, get empty list", func(t *testing.T) {
+		sor := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		owners, err := sor.GetSensorOwners(ctx)
+		assert.NoError(t, err)
+		assert.NotNil(t, owners)
+		assert.Len(t, owners, 0)
+	})
+
+	t.Run("ok, get sorted list", func(t *testing.T) {
+		sor := newRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		assert.NoError(t, sor.SaveSensorOwner(ctx, domain.SensorOwner{UserID: 2, SensorID: 1}))
+		assert.NoError(t, sor.SaveSensorOwner(ctx, domain.SensorOwner{UserID: 1, SensorID: 3}))
+		assert.NoError(t, sor.SaveSensorOwner(ctx, domain.SensorOwner{UserID: 1, SensorID: 2}))
+
+		owners, err := sor.GetSensorOwners(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.SensorOwner{
+			{UserID: 1, SensorID: 2},
+			{UserID: 1, SensorID: 3},
+			{UserID: 2, SensorID: 1},
+		}, owners)
+	})
+}
@@ -0,0 +1,109 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/buildinfo"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Экспортёры трасс
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+var ErrUnknownExporter = errors.New("unknown trace exporter")
+
+// Config - настройки экспорта трасс
+type Config struct {
+	// Exporter - куда отправляются трассы: none, stdout или otlp
+	Exporter string
+	// Endpoint - URL OTLP/HTTP коллектора, например http://localhost:4318.
+	// Пустой URL берётся из переменных OTEL_EXPORTER_OTLP_*.
+	Endpoint string
+	// SampleRatio - доля записываемых трасс без родителя, решение родителя из traceparent соблюдается
+	SampleRatio float64
+	// ServiceName - имя сервиса в трассах
+	ServiceName string
+}
+
+// Setup - настраивает глобальные TracerProvider и W3C propagator по c и возвращает функцию,
+// которая отправляет накопленные трассы и останавливает экспорт. При ExporterNone трассы не записываются,
+// но контекст трассы из входящих запросов всё равно передаётся дальше.
+func Setup(ctx context.Context, c Config, output io.Writer) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	switch c.Exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		e, err := stdouttrace.New(stdouttrace.WithWriter(output))
+		if err != nil {
+			return nil, fmt.Errorf("can't create stdout exporter: %w", err)
+		}
+		exporter = e
+	case ExporterOTLP:
+		var options []otlptracehttp.Option
+		if c.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(c.Endpoint))
+		}
+		e, err := otlptracehttp.New(ctx, options...)
+		if err != nil {
+			return nil, fmt.Errorf("can't create otlp exporter: %w", err)
+		}
+		exporter = e
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownExporter, c.Exporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(c.ServiceName),
+		semconv.ServiceVersion(buildinfo.Get().Version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("can't create resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+
+	return tp.Shutdown, nil
+}
+
+// Start - открывает span name. Если трассировка не настроена и в ctx нет родительского span,
+// ctx возвращается без изменений, чтобы вызовы без трассировки не создавали новых контекстов.
+func Start(ctx context.Context, tracer trace.Tracer, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	spanCtx, span := tracer.Start(ctx, name, options...)
+	if !span.SpanContext().IsValid() {
+		return ctx, span
+	}
+
+	return spanCtx, span
+}
+
+// End - завершает span, отмечая его ошибкой, если *err не nil. Вызывается через defer с именованной ошибкой.
+func End(span trace.Span, err *error) {
+	if *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
@@ -0,0 +1,145 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func newTestProvider(t *testing.T) (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() {
+		_ = tp.Shutdown(context.Background())
+	})
+
+	return tp, exporter
+}
+
+func TestSetup(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	t.Run("ok, none", func(t *testing.T) {
+		shutdown, err := Setup(ctx, Config{Exporter: ExporterNone}, nil)
+		require.NoError(t, err)
+		assert.NoError(t, shutdown(ctx))
+	})
+
+	t.Run("ok, stdout", func(t *testing.T) {
+		var output bytes.Buffer
+		shutdown, err := Setup(ctx, Config{Exporter: ExporterStdout, SampleRatio: 1, ServiceName: "test"}, &output)
+		require.NoError(t, err)
+
+		_, span := otel.Tracer("test").Start(ctx, "test span")
+		span.End()
+		require.NoError(t, shutdown(ctx))
+
+		assert.Contains(t, output.String(), `"Name":"test span"`)
+		assert.Contains(t, output.String(), `"Value":"test"`)
+	})
+
+	t.Run("err, unknown exporter", func(t *testing.T) {
+		_, err := Setup(ctx, Config{Exporter: "jaeger"}, nil)
+		assert.ErrorIs(t, err, ErrUnknownExporter)
+	})
+}
+
+func TestStart(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	t.Run("ok, context is kept without tracing", func(t *testing.T) {
+		spanCtx, span := Start(ctx, noop.NewTracerProvider().Tracer("test"), "test")
+		defer span.End()
+
+		assert.Equal(t, ctx, spanCtx)
+	})
+
+	t.Run("ok, span is a child of the span from context", func(t *testing.T) {
+		tp, exporter := newTestProvider(t)
+		tracer := tp.Tracer("test")
+
+		parentCtx, parent := Start(ctx, tracer, "parent")
+		_, child := Start(parentCtx, tracer, "child")
+		child.End()
+		parent.End()
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 2)
+		assert.Equal(t, "child", spans[0].Name)
+		assert.Equal(t, spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID())
+	})
+}
+
+func TestEnd(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	tp, exporter := newTestProvider(t)
+	tracer := tp.Tracer("test")
+
+	var err error
+	_, span := Start(ctx, tracer, "ok")
+	End(span, &err)
+
+	err = errors.New("connection refused")
+	_, span = Start(ctx, tracer, "fail")
+	End(span, &err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	assert.Equal(t, "connection refused", spans[1].Status.Description)
+	assert.Len(t, spans[1].Events, 1)
+}
+
+func TestQueryTracer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	tp, exporter := newTestProvider(t)
+	qt := &QueryTracer{tracer: tp.Tracer("test")}
+
+	queryCtx := qt.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	qt.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+
+	queryCtx = qt.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT id FROM sensors"})
+	qt.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{Err: pgx.ErrNoRows})
+
+	queryCtx = qt.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT"})
+	qt.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{Err: errors.New("syntax error")})
+
+	batch := &pgx.Batch{}
+	batch.Queue("INSERT INTO events VALUES (1)")
+	batchCtx := qt.TraceBatchStart(ctx, nil, pgx.TraceBatchStartData{Batch: batch})
+	qt.TraceBatchQuery(batchCtx, nil, pgx.TraceBatchQueryData{SQL: "INSERT INTO events VALUES (1)"})
+	qt.TraceBatchEnd(batchCtx, nil, pgx.TraceBatchEndData{})
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 4)
+
+	assert.Equal(t, "postgres.query", spans[0].Name)
+	assert.Contains(t, spans[0].Attributes, semconv.DBStatement("SELECT 1"))
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	assert.Equal(t, codes.Unset, spans[1].Status.Code, "no rows is not an error")
+	assert.Equal(t, codes.Error, spans[2].Status.Code)
+
+	assert.Equal(t, "postgres.batch", spans[3].Name)
+	require.Len(t, spans[3].Events, 1)
+	assert.Contains(t, spans[3].Events[0].Attributes, semconv.DBStatement("INSERT INTO events VALUES (1)"))
+}
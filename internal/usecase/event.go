@@ -2,23 +2,345 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"homework/internal/domain"
+	"homework/internal/tracing"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// maxHistoryPoints - максимальное количество интервалов в ответе GetHistory
+const maxHistoryPoints = 10000
+
 type Event struct {
-	// TODO добавьте реализацию
+	er EventRepository
+	sr SensorRepository
+	qr QuarantineRepository
+
+	ingestion *Ingestion
+	observer  EventObserver
+	limiter   EventRateLimiter
+}
+
+// EventObserver - получатель результатов приёма событий, например для метрик.
+// Тип датчика пуст, если датчик не найден.
+type EventObserver interface {
+	EventReceived(sensorType domain.SensorType)
+	EventRejected(sensorType domain.SensorType, reason string)
+	// EventThrottled - событие датчика отклонено ограничением частоты, вызывается вместе с EventRejected
+	EventThrottled(serialNumber string, sensorType domain.SensorType)
+}
+
+// EventRateLimiter - ограничение частоты событий датчика
+type EventRateLimiter interface {
+	// Allow - можно ли принять событие датчика, иначе через сколько его повторить.
+	// Тип датчика пуст, если датчик не зарегистрирован.
+	Allow(serialNumber string, sensorType domain.SensorType) (bool, time.Duration)
+}
+
+// Причины отклонения события для EventObserver, кроме причин карантина
+const (
+	RejectReasonInvalidTimestamp = "invalid_timestamp"
+	RejectReasonQueueFull        = "queue_full"
+	RejectReasonStopped          = "ingestion_stopped"
+	RejectReasonInternal         = "internal_error"
+	RejectReasonRateLimited      = "rate_limited"
+)
+
+// WithQuarantine - опция, включающая сохранение отклонённых событий для последующего разбора
+func WithQuarantine(qr QuarantineRepository) func(*Event) {
+	return func(e *Event) {
+		e.qr = qr
+	}
+}
+
+// WithIngestion - опция, включающая отложенную запись: ReceiveEvent только проверяет событие и ставит его в очередь
+func WithIngestion(ingestion *Ingestion) func(*Event) {
+	return func(e *Event) {
+		e.ingestion = ingestion
+	}
+}
+
+// WithObserver - опция, включающая уведомление observer о каждом принятом и отклонённом событии
+func WithObserver(observer EventObserver) func(*Event) {
+	return func(e *Event) {
+		e.observer = observer
+	}
+}
+
+// WithRateLimiter - опция, включающая ограничение частоты событий каждого датчика.
+// Сверх ограничения события отклоняются с RateLimitError до проверки значения и не попадают в карантин.
+func WithRateLimiter(limiter EventRateLimiter) func(*Event) {
+	return func(e *Event) {
+		e.limiter = limiter
+	}
+}
+
+func NewEvent(er EventRepository, sr SensorRepository, options ...func(*Event)) *Event {
+	e := &Event{
+		er: er,
+		sr: sr,
+	}
+	for _, option := range options {
+		option(e)
+	}
+
+	return e
+}
+
+func (e *Event) ReceiveEvent(ctx context.Context, event *domain.Event) (err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Event.ReceiveEvent",
+		trace.WithAttributes(attribute.String("sensor.serial_number", event.SensorSerialNumber)))
+	defer tracing.End(span, &err)
+
+	var sensorType domain.SensorType
+	err = e.receiveEvent(ctx, event, &sensorType)
+	if e.observer == nil {
+		return err
+	}
+
+	if errors.Is(err, ErrRateLimited) {
+		e.observer.EventThrottled(event.SensorSerialNumber, sensorType)
+	}
+	if err != nil {
+		e.observer.EventRejected(sensorType, rejectReason(err))
+	} else {
+		e.observer.EventReceived(sensorType)
+	}
+
+	return err
+}
+
+func (e *Event) receiveEvent(ctx context.Context, event *domain.Event, sensorType *domain.SensorType) error {
+	if event.Timestamp.IsZero() {
+		return ErrInvalidEventTimestamp
+	}
+
+	sensor, err := e.sr.GetSensorBySerialNumber(ctx, event.SensorSerialNumber)
+	if errors.Is(err, ErrSensorNotFound) {
+		if err := e.allow(event.SensorSerialNumber, ""); err != nil {
+			return err
+		}
+		// события ещё не зарегистрированного датчика ждут его регистрации в карантине, см. Sensor.RegisterSensor
+		return e.reject(ctx, event, domain.QuarantineReasonUnknownSensor, fmt.Errorf("can't get sensor by serial number: %w", err))
+	}
+	if err != nil {
+		return fmt.Errorf("can't get sensor by serial number: %w", err)
+	}
+	*sensorType = sensor.Type
+
+	if err := e.allow(event.SensorSerialNumber, sensor.Type); err != nil {
+		return err
+	}
+
+	event.SensorID = sensor.ID
+
+	behavior := sensorTypeBehavior(sensor.Type)
+	if err := validatePayload(sensor, behavior, event.Payload); err != nil {
+		return e.reject(ctx, event, domain.QuarantineReasonInvalidPayload, err)
+	}
+
+	if e.ingestion != nil {
+		if err := e.ingestion.Enqueue(*event); err != nil {
+			return fmt.Errorf("can't enqueue event: %w", err)
+		}
+		return nil
+	}
+
+	if err := e.er.SaveEvent(ctx, event); err != nil {
+		return fmt.Errorf("can't save event: %w", err)
+	}
+
+	// меняется только состояние: параллельная смена калибровки или диапазона не перезаписывается
+	if _, err := e.sr.UpdateSensorState(ctx, sensor.ID, behavior.stateUpdate(*event)); err != nil {
+		return fmt.Errorf("can't update sensor state: %w", err)
+	}
+
+	return nil
 }
 
-func NewEvent(er EventRepository, sr SensorRepository) *Event {
-	return &Event{}
+// Deferred - включена ли отложенная запись, см. WithIngestion
+func (e *Event) Deferred() bool {
+	return e.ingestion != nil
 }
 
-func (e *Event) ReceiveEvent(ctx context.Context, event *domain.Event) error {
-	// TODO добавьте реализацию
+// OnFlush - регистрирует функцию, которая вызывается для датчика после сохранения его событий из очереди.
+// Без отложенной записи события сохраняются в ReceiveEvent, и функция не вызывается.
+func (e *Event) OnFlush(fn func(ctx context.Context, sensorID int64)) {
+	if e.ingestion != nil {
+		e.ingestion.OnFlush(fn)
+	}
+}
+
+func (e *Event) GetLastEventBySensorID(ctx context.Context, id int64) (_ *domain.Event, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Event.GetLastEventBySensorID")
+	defer tracing.End(span, &err)
+
+	event, err := e.er.GetLastEventBySensorID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("can't get last event: %w", err)
+	}
+
+	return event, nil
+}
+
+// GetStateReport - функция построения отчёта о состояниях датчика за период [from, to):
+// время в каждом состоянии, количество переключений и самый длинный интервал.
+// Отчёт строится только для датчиков состояния - типов со свёрткой по последнему значению.
+func (e *Event) GetStateReport(ctx context.Context, id int64, from, to time.Time) (_ *domain.StateReport, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Event.GetStateReport")
+	defer tracing.End(span, &err)
+
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from should be before to", ErrInvalidTimeRange)
+	}
+
+	sensor, err := e.sr.GetSensorByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("can't get sensor by id: %w", err)
+	}
+	if sensorTypeBehavior(sensor.Type).Aggregation != domain.AggregationLast {
+		return nil, fmt.Errorf("%w: state report is not supported for %q", ErrWrongSensorType, sensor.Type)
+	}
+
+	intervals, err := e.er.GetStateIntervals(ctx, id, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("can't get state intervals: %w", err)
+	}
+
+	return domain.NewStateReport(id, from, to, intervals), nil
+}
+
+// GetHistory - функция получения истории значений датчика: min/max/avg/count и значение по способу свёртки
+// типа датчика по интервалам длиной step, начинающимся в [from, to). Интервалы выравниваются по UTC.
+// Если step кратен суткам или часу, история строится по свёрткам, которые хранятся дольше событий,
+// иначе - по сохранённым событиям.
+func (e *Event) GetHistory(ctx context.Context, id int64, from, to time.Time, step time.Duration) (_ *domain.History, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Event.GetHistory")
+	defer tracing.End(span, &err)
+
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from should be before to", ErrInvalidTimeRange)
+	}
+	if step <= 0 || to.Sub(from)/step > maxHistoryPoints {
+		return nil, fmt.Errorf("%w: step should be positive and give at most %d points", ErrInvalidTimeRange, maxHistoryPoints)
+	}
+
+	sensor, err := e.sr.GetSensorByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("can't get sensor by id: %w", err)
+	}
+	history := &domain.History{Aggregation: sensorTypeBehavior(sensor.Type).Aggregation}
+
+	from = from.UTC().Truncate(step)
+	for _, resolution := range []domain.RollupResolution{domain.RollupResolutionDay, domain.RollupResolutionHour} {
+		if step%resolution.Duration() != 0 {
+			continue
+		}
+
+		rollups, err := e.er.GetRollups(ctx, id, resolution, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("can't get rollups: %w", err)
+		}
+
+		history.Points = domain.Downsample(rollups, step)
+		return history, nil
+	}
+
+	events, err := e.er.GetEvents(ctx, id, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("can't get events: %w", err)
+	}
+
+	history.Points = domain.RollupEvents(events, step)
+	return history, nil
+}
+
+// GetQuarantinedEvents - функция получения отклонённых событий, без карантина список всегда пуст
+func (e *Event) GetQuarantinedEvents(ctx context.Context, reason domain.QuarantineReason) (_ []domain.QuarantinedEvent, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Event.GetQuarantinedEvents")
+	defer tracing.End(span, &err)
+
+	if e.qr == nil {
+		return []domain.QuarantinedEvent{}, nil
+	}
+
+	events, err := e.qr.GetQuarantinedEvents(ctx, reason)
+	if err != nil {
+		return nil, fmt.Errorf("can't get quarantined events: %w", err)
+	}
+
+	return events, nil
+}
+
+// rejectReason - причина отклонения события для EventObserver
+func rejectReason(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidEventTimestamp):
+		return RejectReasonInvalidTimestamp
+	case errors.Is(err, ErrSensorNotFound):
+		return string(domain.QuarantineReasonUnknownSensor)
+	case errors.Is(err, ErrInvalidEventPayload):
+		return string(domain.QuarantineReasonInvalidPayload)
+	case errors.Is(err, ErrIngestionQueueFull):
+		return RejectReasonQueueFull
+	case errors.Is(err, ErrIngestionStopped):
+		return RejectReasonStopped
+	case errors.Is(err, ErrRateLimited):
+		return RejectReasonRateLimited
+	default:
+		return RejectReasonInternal
+	}
+}
+
+// allow - проверка ограничения частоты событий датчика, без ограничения всегда nil
+func (e *Event) allow(serialNumber string, sensorType domain.SensorType) error {
+	if e.limiter == nil {
+		return nil
+	}
+	if ok, retryAfter := e.limiter.Allow(serialNumber, sensorType); !ok {
+		return &RateLimitError{RetryAfter: retryAfter}
+	}
+
 	return nil
 }
 
-func (e *Event) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error) {
-	// TODO добавьте реализацию
-	return nil, nil
+// reject - сохраняет отклонённое событие в карантин, если он включён, и возвращает исходную ошибку проверки
+func (e *Event) reject(ctx context.Context, event *domain.Event, reason domain.QuarantineReason, cause error) error {
+	if e.qr == nil {
+		return cause
+	}
+
+	err := e.qr.SaveQuarantinedEvent(ctx, &domain.QuarantinedEvent{
+		Event:   *event,
+		Reason:  reason,
+		Details: cause.Error(),
+	})
+	if err != nil {
+		return fmt.Errorf("can't quarantine event: %w", err)
+	}
+	slog.DebugContext(ctx, "event quarantined", "serial_number", event.SensorSerialNumber, "reason", reason, "details", cause.Error())
+
+	return cause
+}
+
+// validatePayload - проверка значения события по типу датчика и по диапазону, заданному для датчика,
+// если тип датчика поддерживает диапазон
+func validatePayload(sensor *domain.Sensor, behavior SensorTypeBehavior, payload int64) error {
+	if behavior.ValidatePayload != nil {
+		if err := behavior.ValidatePayload(payload); err != nil {
+			return err
+		}
+	}
+
+	if behavior.PayloadRange && sensor.PayloadRange != nil && !sensor.PayloadRange.Contains(payload) {
+		return fmt.Errorf("%w: %d is out of range [%d, %d]",
+			ErrInvalidEventPayload, payload, sensor.PayloadRange.Min, sensor.PayloadRange.Max)
+	}
+
+	return nil
 }
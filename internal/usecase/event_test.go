@@ -9,6 +9,12 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func Test_event_ReceiveEvent(t *testing.T) {
@@ -41,6 +47,32 @@ func Test_event_ReceiveEvent(t *testing.T) {
 		assert.ErrorIs(t, err, ErrSensorNotFound)
 	})
 
+	t.Run("err, unknown sensor event is quarantined", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "1234567890").Times(1).Return(nil, ErrSensorNotFound)
+
+		qr := NewMockQuarantineRepository(ctrl)
+		qr.EXPECT().SaveQuarantinedEvent(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, event *domain.QuarantinedEvent) error {
+			assert.Equal(t, "1234567890", event.SensorSerialNumber)
+			assert.Equal(t, int64(7), event.Payload)
+			assert.Equal(t, domain.QuarantineReasonUnknownSensor, event.Reason)
+
+			return nil
+		})
+
+		e := NewEvent(nil, sr, WithQuarantine(qr))
+
+		err := e.ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "1234567890",
+			Payload:            7,
+		})
+		assert.ErrorIs(t, err, ErrSensorNotFound)
+	})
+
 	t.Run("err, event save error", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
@@ -74,7 +106,7 @@ func Test_event_ReceiveEvent(t *testing.T) {
 			ID: 1,
 		}, nil)
 		expectedError := errors.New("some error")
-		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Times(1).Return(expectedError)
+		sr.EXPECT().UpdateSensorState(ctx, int64(1), gomock.Any()).Times(1).Return(nil, expectedError)
 
 		er := NewMockEventRepository(ctrl)
 		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(1).Return(nil)
@@ -97,9 +129,11 @@ func Test_event_ReceiveEvent(t *testing.T) {
 		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(1).Return(&domain.Sensor{
 			ID: 1,
 		}, nil)
-		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Do(func(_ context.Context, s *domain.Sensor) {
-			assert.Equal(t, int64(8), s.CurrentState)
-			assert.NotEmpty(t, s.LastActivity)
+		sr.EXPECT().UpdateSensorState(ctx, int64(1), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, _ int64, u domain.SensorStateUpdate) (*domain.Sensor, error) {
+			assert.Equal(t, int64(8), u.State)
+			assert.NotEmpty(t, u.LastActivity)
+
+			return nil, nil
 		})
 
 		er := NewMockEventRepository(ctrl)
@@ -118,4 +152,514 @@ func Test_event_ReceiveEvent(t *testing.T) {
 		})
 		assert.NoError(t, err)
 	})
+
+	t.Run("err, payload is not valid for sensor type", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(1).Return(&domain.Sensor{
+			ID:   1,
+			Type: domain.SensorTypeCounter,
+		}, nil)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(0)
+
+		e := NewEvent(er, sr)
+		err := e.ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "123",
+			Payload:            -1,
+		})
+		assert.ErrorIs(t, err, ErrInvalidEventPayload)
+	})
+
+	t.Run("err, contact closure payload is not 0 or 1", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(1).Return(&domain.Sensor{
+			ID:   1,
+			Type: domain.SensorTypeContactClosure,
+		}, nil)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(0)
+
+		e := NewEvent(er, sr)
+		err := e.ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "123",
+			Payload:            10,
+		})
+		assert.ErrorIs(t, err, ErrInvalidEventPayload)
+	})
+
+	t.Run("ok, contact closure payload is 1", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(1).Return(&domain.Sensor{
+			ID:   1,
+			Type: domain.SensorTypeContactClosure,
+		}, nil)
+		sr.EXPECT().UpdateSensorState(ctx, int64(1), gomock.Any()).Times(1).Do(func(_ context.Context, _ int64, u domain.SensorStateUpdate) {
+			assert.Equal(t, int64(1), u.State)
+			assert.False(t, u.Accumulate)
+		})
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(1).Return(nil)
+
+		e := NewEvent(er, sr)
+		err := e.ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "123",
+			Payload:            1,
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("err, payload is out of sensor range", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(2).Return(&domain.Sensor{
+			ID:           1,
+			Type:         domain.SensorTypeADC,
+			PayloadRange: &domain.PayloadRange{Min: 0, Max: 1023},
+		}, nil)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(0)
+
+		e := NewEvent(er, sr)
+		err := e.ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "123",
+			Payload:            1024,
+		})
+		assert.ErrorIs(t, err, ErrInvalidEventPayload)
+
+		err = e.ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "123",
+			Payload:            -1,
+		})
+		assert.ErrorIs(t, err, ErrInvalidEventPayload)
+	})
+
+	t.Run("ok, rejected event is quarantined", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(1).Return(&domain.Sensor{
+			ID:   1,
+			Type: domain.SensorTypeContactClosure,
+		}, nil)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(0)
+
+		qr := NewMockQuarantineRepository(ctrl)
+		qr.EXPECT().SaveQuarantinedEvent(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, event *domain.QuarantinedEvent) error {
+			assert.Equal(t, int64(1), event.SensorID)
+			assert.Equal(t, int64(2), event.Payload)
+			assert.Equal(t, domain.QuarantineReasonInvalidPayload, event.Reason)
+			assert.NotEmpty(t, event.Details)
+
+			return nil
+		})
+
+		e := NewEvent(er, sr, WithQuarantine(qr))
+		err := e.ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "123",
+			Payload:            2,
+		})
+		assert.ErrorIs(t, err, ErrInvalidEventPayload)
+	})
+
+	t.Run("ok, counter accumulates payload", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(1).Return(&domain.Sensor{
+			ID:           1,
+			Type:         domain.SensorTypeCounter,
+			CurrentState: 100,
+		}, nil)
+		// прирост прибавляется в хранилище, чтобы параллельные события не терялись
+		sr.EXPECT().UpdateSensorState(ctx, int64(1), gomock.Any()).Times(1).Do(func(_ context.Context, _ int64, u domain.SensorStateUpdate) {
+			assert.Equal(t, int64(5), u.State)
+			assert.True(t, u.Accumulate)
+		})
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(1).Return(nil)
+
+		e := NewEvent(er, sr)
+		err := e.ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "123",
+			Payload:            5,
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("ok, deferred event is enqueued", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(1).Return(&domain.Sensor{
+			ID:   1,
+			Type: domain.SensorTypeADC,
+		}, nil)
+
+		// событие сохраняется обработчиком очереди, а не в ReceiveEvent
+		er := NewMockEventRepository(ctrl)
+		ingestion := NewIngestion(er, sr, IngestionConfig{QueueSize: 1, Workers: 1})
+
+		e := NewEvent(er, sr, WithIngestion(ingestion))
+		assert.True(t, e.Deferred())
+
+		err := e.ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "123",
+			Payload:            5,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, ingestion.Stats().Depth)
+	})
+
+	t.Run("err, deferred event queue is full", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(1).Return(&domain.Sensor{
+			ID:   1,
+			Type: domain.SensorTypeADC,
+		}, nil)
+
+		ingestion := NewIngestion(nil, nil, IngestionConfig{QueueSize: 1, Workers: 1})
+		assert.NoError(t, ingestion.Enqueue(domain.Event{SensorID: 1}))
+
+		e := NewEvent(nil, sr, WithIngestion(ingestion))
+		err := e.ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "123",
+			Payload:            5,
+		})
+		assert.ErrorIs(t, err, ErrIngestionQueueFull)
+	})
+
+	t.Run("ok, observer is notified", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(2).Return(&domain.Sensor{
+			ID:   1,
+			Type: domain.SensorTypeContactClosure,
+		}, nil)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "456").Times(1).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().UpdateSensorState(ctx, int64(1), gomock.Any()).Times(1).Return(nil, nil)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(1).Return(nil)
+
+		o := &eventObserver{}
+		e := NewEvent(er, sr, WithObserver(o))
+
+		assert.NoError(t, e.ReceiveEvent(ctx, &domain.Event{Timestamp: time.Now(), SensorSerialNumber: "123", Payload: 1}))
+		assert.Error(t, e.ReceiveEvent(ctx, &domain.Event{Timestamp: time.Now(), SensorSerialNumber: "123", Payload: 2}))
+		assert.Error(t, e.ReceiveEvent(ctx, &domain.Event{Timestamp: time.Now(), SensorSerialNumber: "456", Payload: 1}))
+		assert.Error(t, e.ReceiveEvent(ctx, &domain.Event{SensorSerialNumber: "123"}))
+
+		assert.Equal(t, []string{"cc"}, o.received)
+		assert.Equal(t, []string{
+			"cc " + string(domain.QuarantineReasonInvalidPayload),
+			" " + string(domain.QuarantineReasonUnknownSensor),
+			" " + RejectReasonInvalidTimestamp,
+		}, o.rejected)
+	})
+
+	t.Run("err, rate limited", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "123").Times(2).Return(&domain.Sensor{
+			ID:           1,
+			SerialNumber: "123",
+			Type:         domain.SensorTypeADC,
+		}, nil)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "456").Times(2).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().UpdateSensorState(ctx, int64(1), gomock.Any()).Times(1).Return(nil, nil)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(1).Return(nil)
+
+		// сверх ограничения события незарегистрированного датчика не попадают в карантин
+		qr := NewMockQuarantineRepository(ctrl)
+		qr.EXPECT().SaveQuarantinedEvent(ctx, gomock.Any()).Times(1).Return(nil)
+
+		o := &eventObserver{}
+		e := NewEvent(er, sr, WithRateLimiter(&eventLimiter{allowed: 1}), WithQuarantine(qr), WithObserver(o))
+
+		assert.NoError(t, e.ReceiveEvent(ctx, &domain.Event{Timestamp: time.Now(), SensorSerialNumber: "123", Payload: 1}))
+		err := e.ReceiveEvent(ctx, &domain.Event{Timestamp: time.Now(), SensorSerialNumber: "123", Payload: 2})
+		assert.ErrorIs(t, err, ErrRateLimited)
+		var rateLimitErr *RateLimitError
+		if assert.ErrorAs(t, err, &rateLimitErr) {
+			assert.Equal(t, 2*time.Second, rateLimitErr.RetryAfter)
+		}
+
+		assert.ErrorIs(t, e.ReceiveEvent(ctx, &domain.Event{Timestamp: time.Now(), SensorSerialNumber: "456", Payload: 1}), ErrSensorNotFound)
+		assert.ErrorIs(t, e.ReceiveEvent(ctx, &domain.Event{Timestamp: time.Now(), SensorSerialNumber: "456", Payload: 1}), ErrRateLimited)
+
+		assert.Equal(t, []string{"123 adc", "456 "}, o.throttled)
+		assert.Equal(t, []string{
+			"adc " + RejectReasonRateLimited,
+			" " + string(domain.QuarantineReasonUnknownSensor),
+			" " + RejectReasonRateLimited,
+		}, o.rejected)
+	})
+
+	t.Run("ok, span is recorded", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		exporter := setupTracer(t)
+
+		// репозиторий получает контекст span сценария
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "123").Times(1).DoAndReturn(func(ctx context.Context, _ string) (*domain.Sensor, error) {
+			assert.True(t, trace.SpanContextFromContext(ctx).IsValid())
+			return nil, errors.New("connection refused")
+		})
+
+		e := NewEvent(nil, sr)
+		assert.Error(t, e.ReceiveEvent(ctx, &domain.Event{Timestamp: time.Now(), SensorSerialNumber: "123"}))
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "Event.ReceiveEvent", spans[0].Name)
+		assert.Contains(t, spans[0].Attributes, attribute.String("sensor.serial_number", "123"))
+		assert.Equal(t, codes.Error, spans[0].Status.Code)
+	})
+}
+
+// setupTracer - подменяет tracer пакета на записывающий span в память до конца теста
+func setupTracer(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	prev := tracer
+	tracer = tp.Tracer("test")
+	t.Cleanup(func() {
+		tracer = prev
+		_ = tp.Shutdown(context.Background())
+	})
+
+	return exporter
+}
+
+// eventObserver - EventObserver, запоминающий тип датчика и причину отклонения каждого события
+type eventObserver struct {
+	received  []string
+	rejected  []string
+	throttled []string
+}
+
+func (o *eventObserver) EventReceived(sensorType domain.SensorType) {
+	o.received = append(o.received, string(sensorType))
+}
+
+func (o *eventObserver) EventRejected(sensorType domain.SensorType, reason string) {
+	o.rejected = append(o.rejected, string(sensorType)+" "+reason)
+}
+
+func (o *eventObserver) EventThrottled(serialNumber string, sensorType domain.SensorType) {
+	o.throttled = append(o.throttled, serialNumber+" "+string(sensorType))
+}
+
+// eventLimiter - EventRateLimiter, пропускающий allowed событий каждого датчика
+type eventLimiter struct {
+	allowed int
+	seen    map[string]int
+}
+
+func (l *eventLimiter) Allow(serialNumber string, _ domain.SensorType) (bool, time.Duration) {
+	if l.seen == nil {
+		l.seen = make(map[string]int)
+	}
+	l.seen[serialNumber]++
+	if l.seen[serialNumber] > l.allowed {
+		return false, 2 * time.Second
+	}
+
+	return true, 0
+}
+
+func Test_event_GetStateReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	to := time.Now()
+	from := to.Add(-24 * time.Hour)
+
+	t.Run("err, invalid time range", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		e := NewEvent(nil, nil)
+
+		_, err := e.GetStateReport(ctx, 1, to, from)
+		assert.ErrorIs(t, err, ErrInvalidTimeRange)
+	})
+
+	t.Run("err, sensor is not a state sensor", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeADC}, nil)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().GetStateIntervals(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		e := NewEvent(er, sr)
+
+		_, err := e.GetStateReport(ctx, 1, from, to)
+		assert.ErrorIs(t, err, ErrWrongSensorType)
+	})
+
+	t.Run("ok, report built", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeContactClosure}, nil)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().GetStateIntervals(ctx, int64(1), from, to).Times(1).Return([]domain.StateInterval{
+			{State: 0, From: from, To: from.Add(time.Hour)},
+			{State: 1, From: from.Add(time.Hour), To: to},
+		}, nil)
+
+		e := NewEvent(er, sr)
+
+		report, err := e.GetStateReport(ctx, 1, from, to)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), report.Transitions)
+		assert.Equal(t, []domain.StateDuration{
+			{State: 0, Duration: time.Hour},
+			{State: 1, Duration: 23 * time.Hour},
+		}, report.Durations)
+		assert.Equal(t, int64(1), report.Longest.State)
+	})
+}
+
+func Test_event_GetHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(48 * time.Hour)
+
+	t.Run("err, too many points", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		e := NewEvent(nil, nil)
+
+		_, err := e.GetHistory(ctx, 1, from, to, time.Millisecond)
+		assert.ErrorIs(t, err, ErrInvalidTimeRange)
+
+		_, err = e.GetHistory(ctx, 1, from, to, 0)
+		assert.ErrorIs(t, err, ErrInvalidTimeRange)
+	})
+
+	t.Run("ok, daily rollups for daily step", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeADC}, nil)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().GetRollups(ctx, int64(1), domain.RollupResolutionDay, from, to).Times(1).Return([]domain.Rollup{
+			{SensorID: 1, Bucket: from, Min: 1, Max: 5, Sum: 6, Count: 2},
+			{SensorID: 1, Bucket: from.Add(24 * time.Hour), Min: 0, Max: 3, Sum: 3, Count: 1},
+		}, nil)
+
+		e := NewEvent(er, sr)
+
+		history, err := e.GetHistory(ctx, 1, from, to, 24*time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, domain.AggregationAverage, history.Aggregation)
+		assert.Len(t, history.Points, 2)
+		assert.Equal(t, float64(3), history.Points[0].Value(history.Aggregation))
+	})
+
+	t.Run("ok, hourly rollups for hourly step", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeCounter}, nil)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().GetRollups(ctx, int64(1), domain.RollupResolutionHour, from, to).Times(1).Return([]domain.Rollup{
+			{SensorID: 1, Bucket: from.Add(time.Hour), Min: 1, Max: 5, Sum: 6, Count: 2, Last: 5, LastAt: from.Add(90 * time.Minute)},
+			{SensorID: 1, Bucket: from.Add(2 * time.Hour), Min: 0, Max: 3, Sum: 3, Count: 1, Last: 3, LastAt: from.Add(2 * time.Hour)},
+		}, nil)
+
+		e := NewEvent(er, sr)
+
+		history, err := e.GetHistory(ctx, 1, from.Add(30*time.Minute), to, 6*time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, &domain.History{
+			Aggregation: domain.AggregationDelta,
+			Points: []domain.Rollup{
+				{SensorID: 1, Bucket: from, Min: 0, Max: 5, Sum: 9, Count: 3, Last: 3, LastAt: from.Add(2 * time.Hour)},
+			},
+		}, history)
+		assert.Equal(t, float64(9), history.Points[0].Value(history.Aggregation))
+	})
+
+	t.Run("ok, raw events for minute step", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1}, nil)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().GetEvents(ctx, int64(1), from, from.Add(time.Hour)).Times(1).Return([]domain.Event{
+			{SensorID: 1, Timestamp: from.Add(time.Minute), Payload: 4},
+			{SensorID: 1, Timestamp: from.Add(90 * time.Second), Payload: 2},
+		}, nil)
+
+		e := NewEvent(er, sr)
+
+		history, err := e.GetHistory(ctx, 1, from, from.Add(time.Hour), 15*time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, &domain.History{
+			Aggregation: domain.AggregationLast,
+			Points: []domain.Rollup{
+				{SensorID: 1, Bucket: from, Min: 2, Max: 4, Sum: 6, Count: 2, Last: 2, LastAt: from.Add(90 * time.Second)},
+			},
+		}, history)
+		assert.Equal(t, float64(2), history.Points[0].Value(history.Aggregation))
+	})
 }
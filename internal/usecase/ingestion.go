@@ -0,0 +1,276 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/tracing"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultIngestionQueueSize     = 10000
+	defaultIngestionBatchSize     = 500
+	defaultIngestionWorkers       = 4
+	defaultIngestionFlushInterval = 100 * time.Millisecond
+	// ingestionFlushTimeout - ограничение времени сохранения одного пакета
+	ingestionFlushTimeout = 10 * time.Second
+)
+
+// IngestionConfig - параметры очереди приёма событий, нулевые значения заменяются значениями по умолчанию
+type IngestionConfig struct {
+	// QueueSize - общая ёмкость очереди, делится поровну между обработчиками
+	QueueSize int
+	// BatchSize - размер пакета, при наборе которого события сохраняются, не дожидаясь FlushInterval
+	BatchSize int
+	// Workers - количество обработчиков, события одного датчика всегда попадают к одному обработчику
+	Workers int
+	// FlushInterval - максимальное время ожидания события в очереди
+	FlushInterval time.Duration
+}
+
+// IngestionStats - счётчики очереди приёма событий
+type IngestionStats struct {
+	Depth    int
+	Capacity int
+	Enqueued uint64
+	Rejected uint64
+	Flushed  uint64
+	Failed   uint64
+}
+
+// Ingestion - очередь отложенной записи событий. Проверенные события складываются в ограниченную очередь,
+// обработчики сохраняют их пакетами и обновляют состояние датчиков. Если очередь заполнена,
+// событие не принимается с ErrIngestionQueueFull.
+type Ingestion struct {
+	er     EventRepository
+	sr     SensorRepository
+	config IngestionConfig
+
+	mu      sync.RWMutex
+	running bool
+	stopped bool
+	queues  []chan domain.Event
+	onFlush func(ctx context.Context, sensorID int64)
+
+	enqueued atomic.Uint64
+	rejected atomic.Uint64
+	flushed  atomic.Uint64
+	failed   atomic.Uint64
+}
+
+func NewIngestion(er EventRepository, sr SensorRepository, config IngestionConfig) *Ingestion {
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultIngestionQueueSize
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultIngestionBatchSize
+	}
+	if config.Workers <= 0 {
+		config.Workers = defaultIngestionWorkers
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultIngestionFlushInterval
+	}
+
+	queues := make([]chan domain.Event, config.Workers)
+	for i := range queues {
+		queues[i] = make(chan domain.Event, max(config.QueueSize/config.Workers, 1))
+	}
+
+	return &Ingestion{
+		er:     er,
+		sr:     sr,
+		config: config,
+		queues: queues,
+	}
+}
+
+// OnFlush - регистрирует функцию, которая вызывается для каждого датчика после сохранения его событий
+func (i *Ingestion) OnFlush(fn func(ctx context.Context, sensorID int64)) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.onFlush = fn
+}
+
+// Enqueue - добавляет проверенное событие в очередь, не блокируясь
+func (i *Ingestion) Enqueue(event domain.Event) error {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if i.stopped {
+		return ErrIngestionStopped
+	}
+
+	select {
+	case i.queues[event.SensorID%int64(len(i.queues))] <- event:
+		i.enqueued.Add(1)
+		return nil
+	default:
+		i.rejected.Add(1)
+		return ErrIngestionQueueFull
+	}
+}
+
+// Run - запускает обработчики и блокируется до отмены ctx.
+// После отмены новые события не принимаются, а уже принятые сохраняются до возврата из Run.
+func (i *Ingestion) Run(ctx context.Context) {
+	i.mu.Lock()
+	i.running = true
+	i.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, queue := range i.queues {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			i.work(context.WithoutCancel(ctx), queue)
+		}()
+	}
+
+	<-ctx.Done()
+
+	i.mu.Lock()
+	i.stopped = true
+	for _, queue := range i.queues {
+		close(queue)
+	}
+	i.mu.Unlock()
+
+	wg.Wait()
+}
+
+// Stats - функция получения счётчиков очереди
+func (i *Ingestion) Stats() IngestionStats {
+	stats := IngestionStats{
+		Enqueued: i.enqueued.Load(),
+		Rejected: i.rejected.Load(),
+		Flushed:  i.flushed.Load(),
+		Failed:   i.failed.Load(),
+	}
+	for _, queue := range i.queues {
+		stats.Depth += len(queue)
+		stats.Capacity += cap(queue)
+	}
+
+	return stats
+}
+
+// Check - проверка готовности очереди: обработчики запущены и ни одна из очередей обработчиков не заполнена
+func (i *Ingestion) Check() error {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if !i.running || i.stopped {
+		return ErrIngestionStopped
+	}
+	for n, queue := range i.queues {
+		if len(queue) == cap(queue) {
+			return fmt.Errorf("%w: worker %d has %d events", ErrIngestionQueueFull, n, len(queue))
+		}
+	}
+
+	return nil
+}
+
+// work - цикл обработчика: пакет сохраняется при наборе BatchSize событий, по таймеру и при закрытии очереди
+func (i *Ingestion) work(ctx context.Context, queue <-chan domain.Event) {
+	ticker := time.NewTicker(i.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]domain.Event, 0, i.config.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := i.flush(ctx, batch); err != nil {
+			slog.ErrorContext(ctx, "can't flush ingestion batch", "err", err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case event, ok := <-queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= i.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// flush - сохраняет пакет событий и обновляет состояние каждого датчика одним изменением по всем его событиям.
+// Пакет, который не удалось сохранить, теряется и учитывается в IngestionStats.Failed.
+func (i *Ingestion) flush(ctx context.Context, batch []domain.Event) (err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Ingestion.flush",
+		trace.WithAttributes(attribute.Int("ingestion.batch_size", len(batch))))
+	defer tracing.End(span, &err)
+
+	ctx, cancel := context.WithTimeout(ctx, ingestionFlushTimeout)
+	defer cancel()
+
+	if err := i.er.SaveEvents(ctx, batch); err != nil {
+		i.failed.Add(uint64(len(batch)))
+		return fmt.Errorf("can't save %d events: %w", len(batch), err)
+	}
+	i.flushed.Add(uint64(len(batch)))
+
+	var sensorIDs []int64
+	bySensor := make(map[int64][]domain.Event)
+	for _, event := range batch {
+		if _, ok := bySensor[event.SensorID]; !ok {
+			sensorIDs = append(sensorIDs, event.SensorID)
+		}
+		bySensor[event.SensorID] = append(bySensor[event.SensorID], event)
+	}
+
+	i.mu.RLock()
+	onFlush := i.onFlush
+	i.mu.RUnlock()
+
+	var errs []error
+	for _, id := range sensorIDs {
+		if err := i.applyEvents(ctx, id, bySensor[id]); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if onFlush != nil {
+			onFlush(ctx, id)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("can't update sensors state: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+func (i *Ingestion) applyEvents(ctx context.Context, id int64, events []domain.Event) error {
+	sensor, err := i.sr.GetSensorByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("can't get sensor by id: %w", err)
+	}
+
+	// датчик читается только ради типа: меняется одно состояние, и смена калибровки или диапазона,
+	// сделанная пока пакет ждал в очереди, не перезаписывается
+	if _, err := i.sr.UpdateSensorState(ctx, id, sensorTypeBehavior(sensor.Type).stateUpdate(events...)); err != nil {
+		return fmt.Errorf("can't update sensor state: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIngestion(t *testing.T) {
+	t.Run("ok, batch flushed by size", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		events := []domain.Event{
+			{SensorID: 1, Payload: 2, Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			{SensorID: 1, Payload: 3, Timestamp: time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC)},
+		}
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvents(gomock.Any(), events).Times(1).Return(nil)
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).Times(1).Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeCounter, CurrentState: 10}, nil)
+		sr.EXPECT().UpdateSensorState(gomock.Any(), int64(1), domain.SensorStateUpdate{
+			State:        5,
+			Accumulate:   true,
+			LastActivity: events[1].Timestamp,
+		}).Times(1).Return(nil, nil)
+
+		i := NewIngestion(er, sr, IngestionConfig{BatchSize: 2, Workers: 1, FlushInterval: time.Hour})
+		flushed := make(chan int64, 1)
+		i.OnFlush(func(_ context.Context, sensorID int64) {
+			flushed <- sensorID
+		})
+
+		runCtx, stop := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			i.Run(runCtx)
+		}()
+
+		for _, event := range events {
+			assert.NoError(t, i.Enqueue(event))
+		}
+
+		select {
+		case id := <-flushed:
+			assert.Equal(t, int64(1), id)
+		case <-ctx.Done():
+			t.Fatal("batch was not flushed")
+		}
+
+		stop()
+		<-done
+		assert.Equal(t, uint64(2), i.Stats().Flushed)
+	})
+
+	t.Run("ok, queued events flushed on shutdown", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvents(gomock.Any(), gomock.Len(1)).Times(2).Return(nil)
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(gomock.Any(), gomock.Any()).Times(2).Return(&domain.Sensor{}, nil)
+		sr.EXPECT().UpdateSensorState(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).Return(nil, nil)
+
+		i := NewIngestion(er, sr, IngestionConfig{BatchSize: 100, Workers: 2, FlushInterval: time.Hour})
+		assert.NoError(t, i.Enqueue(domain.Event{SensorID: 1}))
+		assert.NoError(t, i.Enqueue(domain.Event{SensorID: 2}))
+
+		runCtx, stop := context.WithCancel(ctx)
+		stop()
+		i.Run(runCtx)
+
+		assert.ErrorIs(t, i.Enqueue(domain.Event{SensorID: 1}), ErrIngestionStopped)
+		stats := i.Stats()
+		assert.Equal(t, 0, stats.Depth)
+		assert.Equal(t, uint64(2), stats.Flushed)
+	})
+
+	t.Run("fail, queue is full", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		i := NewIngestion(NewMockEventRepository(ctrl), NewMockSensorRepository(ctrl), IngestionConfig{QueueSize: 1, Workers: 1})
+		assert.NoError(t, i.Enqueue(domain.Event{SensorID: 1}))
+		assert.ErrorIs(t, i.Enqueue(domain.Event{SensorID: 1}), ErrIngestionQueueFull)
+
+		assert.Equal(t, IngestionStats{Depth: 1, Capacity: 1, Enqueued: 1, Rejected: 1}, i.Stats())
+	})
+
+	t.Run("ok, check", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		// первый пакет сохраняется, пока не будет освобождён release, второе событие остаётся в очереди
+		saving, release := make(chan struct{}), make(chan struct{})
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvents(gomock.Any(), gomock.Len(1)).Times(2).DoAndReturn(func(context.Context, []domain.Event) error {
+			select {
+			case saving <- struct{}{}:
+			default:
+			}
+			<-release
+			return nil
+		})
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(gomock.Any(), int64(1)).Times(2).Return(&domain.Sensor{ID: 1}, nil)
+		sr.EXPECT().UpdateSensorState(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).Return(nil, nil)
+
+		i := NewIngestion(er, sr, IngestionConfig{QueueSize: 1, BatchSize: 1, Workers: 1})
+		assert.ErrorIs(t, i.Check(), ErrIngestionStopped)
+
+		runCtx, stop := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			i.Run(runCtx)
+		}()
+		assert.Eventually(t, func() bool { return i.Check() == nil }, time.Second, time.Millisecond)
+
+		assert.NoError(t, i.Enqueue(domain.Event{SensorID: 1}))
+		<-saving
+		assert.NoError(t, i.Enqueue(domain.Event{SensorID: 1}))
+		assert.ErrorIs(t, i.Check(), ErrIngestionQueueFull)
+
+		close(release)
+		stop()
+		<-done
+		assert.ErrorIs(t, i.Check(), ErrIngestionStopped)
+	})
+
+	t.Run("fail, batch not saved", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvents(gomock.Any(), gomock.Any()).Times(1).Return(errors.New("some error"))
+
+		i := NewIngestion(er, NewMockSensorRepository(ctrl), IngestionConfig{Workers: 1})
+		assert.NoError(t, i.Enqueue(domain.Event{SensorID: 1}))
+
+		runCtx, stop := context.WithCancel(ctx)
+		stop()
+		i.Run(runCtx)
+
+		assert.Equal(t, uint64(1), i.Stats().Failed)
+	})
+}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/tracing"
+	"time"
+)
+
+// defaultPruneBatchSize - количество событий, удаляемых одним запросом, если размер пачки не задан
+const defaultPruneBatchSize = 1000
+
+type Retention struct {
+	er        EventRepository
+	sr        SensorRepository
+	policy    domain.RetentionPolicy
+	batchSize int
+}
+
+func NewRetention(er EventRepository, sr SensorRepository, policy domain.RetentionPolicy, batchSize int) *Retention {
+	if batchSize <= 0 {
+		batchSize = defaultPruneBatchSize
+	}
+
+	return &Retention{
+		er:        er,
+		sr:        sr,
+		policy:    policy,
+		batchSize: batchSize,
+	}
+}
+
+// Prune - функция удаления событий старше срока хранения датчика.
+// События удаляются пачками по batchSize, чтобы не блокировать таблицу надолго.
+// При ошибке возвращается отчёт об уже удалённых событиях.
+func (r *Retention) Prune(ctx context.Context) (_ *domain.PruneReport, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Retention.Prune")
+	defer tracing.End(span, &err)
+
+	report := &domain.PruneReport{
+		StartedAt: time.Now(),
+		BySensor:  make(map[int64]int64),
+	}
+	defer func() {
+		report.Duration = time.Since(report.StartedAt)
+	}()
+
+	sensors, err := r.sr.GetSensors(ctx)
+	if err != nil {
+		return report, fmt.Errorf("can't get sensors: %w", err)
+	}
+
+	for i := range sensors {
+		retention := r.policy.For(&sensors[i])
+		if retention <= 0 {
+			continue
+		}
+
+		removed, err := r.prune(ctx, sensors[i].ID, report.StartedAt.Add(-retention))
+		if removed > 0 {
+			report.Removed += removed
+			report.BySensor[sensors[i].ID] = removed
+		}
+		if err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+func (r *Retention) prune(ctx context.Context, id int64, before time.Time) (int64, error) {
+	var removed int64
+	for {
+		n, err := r.er.DeleteEventsBefore(ctx, id, before, r.batchSize)
+		removed += n
+		if err != nil {
+			return removed, fmt.Errorf("can't delete events of sensor %d: %w", id, err)
+		}
+		if n < int64(r.batchSize) {
+			return removed, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_retention_Prune(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	policy := domain.RetentionPolicy{
+		Default:  24 * time.Hour,
+		ByType:   map[domain.SensorType]time.Duration{domain.SensorTypeADC: time.Hour},
+		BySensor: map[int64]time.Duration{3: 0},
+	}
+
+	t.Run("ok, pruned in batches by policy", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensors(ctx).Times(1).Return([]domain.Sensor{
+			{ID: 1, Type: domain.SensorTypeContactClosure},
+			{ID: 2, Type: domain.SensorTypeADC},
+			{ID: 3, Type: domain.SensorTypeADC},
+		}, nil)
+
+		var adcBefore time.Time
+		er := NewMockEventRepository(ctrl)
+		gomock.InOrder(
+			er.EXPECT().DeleteEventsBefore(ctx, int64(1), gomock.Any(), 2).Return(int64(1), nil),
+			er.EXPECT().DeleteEventsBefore(ctx, int64(2), gomock.Any(), 2).DoAndReturn(
+				func(_ context.Context, _ int64, before time.Time, _ int) (int64, error) {
+					adcBefore = before
+					return 2, nil
+				}),
+			er.EXPECT().DeleteEventsBefore(ctx, int64(2), gomock.Any(), 2).Return(int64(2), nil),
+			er.EXPECT().DeleteEventsBefore(ctx, int64(2), gomock.Any(), 2).Return(int64(0), nil),
+		)
+
+		r := NewRetention(er, sr, policy, 2)
+
+		report, err := r.Prune(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), report.Removed)
+		assert.Equal(t, map[int64]int64{1: 1, 2: 4}, report.BySensor)
+		assert.WithinDuration(t, report.StartedAt.Add(-time.Hour), adcBefore, time.Millisecond)
+	})
+
+	t.Run("fail, repository error returns partial report", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensors(ctx).Times(1).Return([]domain.Sensor{
+			{ID: 1, Type: domain.SensorTypeContactClosure},
+		}, nil)
+
+		expectedError := errors.New("some error")
+		er := NewMockEventRepository(ctrl)
+		gomock.InOrder(
+			er.EXPECT().DeleteEventsBefore(ctx, int64(1), gomock.Any(), 2).Return(int64(2), nil),
+			er.EXPECT().DeleteEventsBefore(ctx, int64(1), gomock.Any(), 2).Return(int64(0), expectedError),
+		)
+
+		r := NewRetention(er, sr, policy, 2)
+
+		report, err := r.Prune(ctx)
+		assert.ErrorIs(t, err, expectedError)
+		assert.Equal(t, int64(2), report.Removed)
+	})
+}
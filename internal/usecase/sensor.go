@@ -2,28 +2,320 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"homework/internal/domain"
+	"homework/internal/tracing"
+	"log/slog"
+	"regexp"
 )
 
+const (
+	maxCalibrationPrecision = 10
+	// unknownDeviceSampleSize - количество последних значений в сводке по незарегистрированному датчику
+	unknownDeviceSampleSize = 5
+	// maxSensorUpdateAttempts - сколько раз изменение без ожидаемой версии повторяется при одновременных изменениях датчика
+	maxSensorUpdateAttempts = 3
+)
+
+var serialNumberRegexp = regexp.MustCompile(`^\d{10}$`)
+
+// SensorUpdate - изменение метаданных датчика. Description не меняется, если nil;
+// калибровка и диапазон значений заменяются, только если задан соответствующий Set*, nil сбрасывает их.
+type SensorUpdate struct {
+	Description     *string
+	SetCalibration  bool
+	Calibration     *domain.Calibration
+	SetPayloadRange bool
+	PayloadRange    *domain.PayloadRange
+}
+
 type Sensor struct {
-	// TODO добавьте реализацию
+	sr SensorRepository
+	er EventRepository
+	qr QuarantineRepository
+}
+
+// WithAdoption - опция, включающая перенос событий из карантина в историю датчика при его регистрации
+func WithAdoption(qr QuarantineRepository, er EventRepository) func(*Sensor) {
+	return func(s *Sensor) {
+		s.qr = qr
+		s.er = er
+	}
+}
+
+func NewSensor(sr SensorRepository, options ...func(*Sensor)) *Sensor {
+	s := &Sensor{
+		sr: sr,
+	}
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+func (s *Sensor) RegisterSensor(ctx context.Context, sensor *domain.Sensor) (_ *domain.Sensor, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Sensor.RegisterSensor")
+	defer tracing.End(span, &err)
+
+	if err := validateSensor(sensor); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.sr.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+	switch {
+	case err == nil:
+		// повторная регистрация завершает перенос событий, если он не удался при первой
+		sensor = existing
+	case errors.Is(err, ErrSensorNotFound):
+		if err := s.sr.SaveSensor(ctx, sensor); err != nil {
+			return nil, fmt.Errorf("can't save sensor: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("can't get sensor by serial number: %w", err)
+	}
+
+	return s.adoptQuarantinedEvents(ctx, sensor)
+}
+
+// GetUnknownDevices - функция получения незарегистрированных датчиков, от которых приходили события
+func (s *Sensor) GetUnknownDevices(ctx context.Context) (_ []domain.UnknownDevice, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Sensor.GetUnknownDevices")
+	defer tracing.End(span, &err)
+
+	if s.qr == nil {
+		return []domain.UnknownDevice{}, nil
+	}
+
+	devices, err := s.qr.GetUnknownDevices(ctx, unknownDeviceSampleSize)
+	if err != nil {
+		return nil, fmt.Errorf("can't get unknown devices: %w", err)
+	}
+
+	return devices, nil
+}
+
+func (s *Sensor) GetSensors(ctx context.Context) (_ []domain.Sensor, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Sensor.GetSensors")
+	defer tracing.End(span, &err)
+
+	sensors, err := s.sr.GetSensors(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can't get sensors: %w", err)
+	}
+
+	return sensors, nil
+}
+
+func (s *Sensor) GetSensorByID(ctx context.Context, id int64) (_ *domain.Sensor, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Sensor.GetSensorByID")
+	defer tracing.End(span, &err)
+
+	sensor, err := s.sr.GetSensorByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("can't get sensor by id: %w", err)
+	}
+
+	return sensor, nil
+}
+
+// UpdateSensor - функция изменения метаданных датчика. Если version не 0, изменение применяется, только пока
+// версия датчика равна version, иначе возвращается ErrSensorVersionConflict. Без версии изменение применяется
+// к текущему состоянию датчика и повторяется, если датчик изменили одновременно.
+// Сохранённые события не меняются: перевод в единицы измерения выполняется при отдаче данных,
+// а новый диапазон значений проверяется только для новых событий.
+func (s *Sensor) UpdateSensor(ctx context.Context, id int64, update SensorUpdate, version int64) (_ *domain.Sensor, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "Sensor.UpdateSensor")
+	defer tracing.End(span, &err)
+
+	if update.SetCalibration && update.Calibration != nil {
+		if err := validateCalibration(update.Calibration); err != nil {
+			return nil, err
+		}
+	}
+	if update.SetPayloadRange && update.PayloadRange != nil {
+		if err := validatePayloadRange(update.PayloadRange); err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		sensor, err := s.sr.GetSensorByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("can't get sensor by id: %w", err)
+		}
+		if version != 0 && sensor.Version != version {
+			return nil, ErrSensorVersionConflict
+		}
+		if update.SetPayloadRange && update.PayloadRange != nil {
+			behavior, _ := LookupSensorType(sensor.Type)
+			if err := checkPayloadRangeSupported(sensor.Type, behavior); err != nil {
+				return nil, err
+			}
+		}
+
+		if update.Description != nil {
+			sensor.Description = *update.Description
+		}
+		if update.SetCalibration {
+			sensor.Calibration = update.Calibration
+		}
+		if update.SetPayloadRange {
+			sensor.PayloadRange = update.PayloadRange
+		}
+
+		err = s.sr.SaveSensor(ctx, sensor)
+		if errors.Is(err, ErrSensorVersionConflict) && version == 0 && attempt < maxSensorUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("can't save sensor: %w", err)
+		}
+
+		return sensor, nil
+	}
 }
 
-func NewSensor(sr SensorRepository) *Sensor {
-	return &Sensor{}
+// SetCalibration - функция замены калибровки датчика, nil сбрасывает калибровку, version - как в UpdateSensor
+func (s *Sensor) SetCalibration(ctx context.Context, id int64, calibration *domain.Calibration, version int64) (*domain.Sensor, error) {
+	return s.UpdateSensor(ctx, id, SensorUpdate{SetCalibration: true, Calibration: calibration}, version)
 }
 
-func (s *Sensor) RegisterSensor(ctx context.Context, sensor *domain.Sensor) (*domain.Sensor, error) {
-	// TODO добавьте реализацию
-	return nil, nil
+// SetPayloadRange - функция замены допустимого диапазона значений событий датчика, nil снимает ограничение,
+// version - как в UpdateSensor
+func (s *Sensor) SetPayloadRange(ctx context.Context, id int64, payloadRange *domain.PayloadRange, version int64) (*domain.Sensor, error) {
+	return s.UpdateSensor(ctx, id, SensorUpdate{SetPayloadRange: true, PayloadRange: payloadRange}, version)
 }
 
-func (s *Sensor) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
-	// TODO добавьте реализацию
-	return nil, nil
+// adoptQuarantinedEvents - переносит события, пришедшие до регистрации датчика, в его историю, и возвращает датчик.
+// Значения проверяются так же, как в Event.ReceiveEvent: непрошедшие проверку остаются в карантине с причиной invalid_payload.
+// При ошибке сохранения неперенесённые события возвращаются в карантин.
+// Состояние меняется так же, как событиями, поэтому перенесённые события не заменяют более новое состояние датчика.
+func (s *Sensor) adoptQuarantinedEvents(ctx context.Context, sensor *domain.Sensor) (*domain.Sensor, error) {
+	if s.qr == nil {
+		return sensor, nil
+	}
+
+	events, err := s.qr.TakeQuarantinedEvents(ctx, sensor.SerialNumber, domain.QuarantineReasonUnknownSensor)
+	if err != nil {
+		return nil, fmt.Errorf("can't take quarantined events: %w", err)
+	}
+	if len(events) == 0 {
+		return sensor, nil
+	}
+
+	behavior := sensorTypeBehavior(sensor.Type)
+	adopted := make([]domain.Event, 0, len(events))
+	for i := range events {
+		event := events[i].Event
+		event.SensorID = sensor.ID
+
+		if err := validatePayload(sensor, behavior, event.Payload); err != nil {
+			err = s.qr.SaveQuarantinedEvent(ctx, &domain.QuarantinedEvent{
+				Event:   event,
+				Reason:  domain.QuarantineReasonInvalidPayload,
+				Details: err.Error(),
+			})
+			if err != nil {
+				return nil, s.restoreQuarantinedEvents(ctx, events[i:], fmt.Errorf("can't quarantine event: %w", err))
+			}
+			continue
+		}
+
+		if err := s.er.SaveEvent(ctx, &event); err != nil {
+			return nil, s.restoreQuarantinedEvents(ctx, events[i:], fmt.Errorf("can't save event: %w", err))
+		}
+		adopted = append(adopted, event)
+	}
+
+	if len(adopted) > 0 {
+		sensor, err = s.sr.UpdateSensorState(ctx, sensor.ID, behavior.stateUpdate(adopted...))
+		if err != nil {
+			return nil, fmt.Errorf("can't update sensor state: %w", err)
+		}
+	}
+	slog.InfoContext(ctx, "quarantined events adopted", "sensor_id", sensor.ID, "adopted", len(adopted), "rejected", len(events)-len(adopted))
+
+	return sensor, nil
+}
+
+func (s *Sensor) restoreQuarantinedEvents(ctx context.Context, events []domain.QuarantinedEvent, cause error) error {
+	errs := []error{cause}
+	for i := range events {
+		if err := s.qr.SaveQuarantinedEvent(ctx, &events[i]); err != nil {
+			errs = append(errs, fmt.Errorf("can't restore quarantined event: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
-func (s *Sensor) GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error) {
-	// TODO добавьте реализацию
-	return nil, nil
+func validateSensor(sensor *domain.Sensor) error {
+	behavior, ok := LookupSensorType(sensor.Type)
+	if !ok {
+		return ErrWrongSensorType
+	}
+
+	if !serialNumberRegexp.MatchString(sensor.SerialNumber) {
+		return ErrWrongSensorSerialNumber
+	}
+
+	if sensor.PayloadRange != nil {
+		if err := checkPayloadRangeSupported(sensor.Type, behavior); err != nil {
+			return err
+		}
+		if err := validatePayloadRange(sensor.PayloadRange); err != nil {
+			return err
+		}
+	}
+
+	if sensor.Calibration != nil {
+		return validateCalibration(sensor.Calibration)
+	}
+
+	return nil
+}
+
+// checkPayloadRangeSupported - проверка, что датчику типа sensorType можно задать диапазон значений
+func checkPayloadRangeSupported(sensorType domain.SensorType, behavior SensorTypeBehavior) error {
+	if !behavior.PayloadRange {
+		return fmt.Errorf("%w: sensor type %s doesn't support payload range", ErrInvalidPayloadRange, sensorType)
+	}
+
+	return nil
+}
+
+func validatePayloadRange(payloadRange *domain.PayloadRange) error {
+	if payloadRange.Min > payloadRange.Max {
+		return fmt.Errorf("%w: min %d is greater than max %d", ErrInvalidPayloadRange, payloadRange.Min, payloadRange.Max)
+	}
+
+	return nil
+}
+
+func validateCalibration(calibration *domain.Calibration) error {
+	if calibration.Precision < 0 || calibration.Precision > maxCalibrationPrecision {
+		return fmt.Errorf("%w: precision should be between 0 and %d", ErrInvalidCalibration, maxCalibrationPrecision)
+	}
+
+	switch calibration.Kind {
+	case domain.CalibrationKindLinear:
+		if calibration.Scale == 0 {
+			return fmt.Errorf("%w: scale should not be zero", ErrInvalidCalibration)
+		}
+	case domain.CalibrationKindTable:
+		if len(calibration.Points) < 2 {
+			return fmt.Errorf("%w: table should contain at least two points", ErrInvalidCalibration)
+		}
+		for i := 1; i < len(calibration.Points); i++ {
+			if calibration.Points[i].Raw <= calibration.Points[i-1].Raw {
+				return fmt.Errorf("%w: table points should be sorted by raw value", ErrInvalidCalibration)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidCalibration, calibration.Kind)
+	}
+
+	return nil
 }
@@ -41,6 +41,13 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 			SerialNumber: "123456789011", // wrong, should be 10 digits
 		})
 		assert.ErrorIs(t, err, ErrWrongSensorSerialNumber)
+
+		_, err = s.RegisterSensor(ctx, &domain.Sensor{
+			Type:         domain.SensorTypeContactClosure,
+			SerialNumber: "1234567890",
+			PayloadRange: &domain.PayloadRange{Min: 0, Max: 1}, // wrong, only adc has a range
+		})
+		assert.ErrorIs(t, err, ErrInvalidPayloadRange)
 	})
 
 	t.Run("fail, repository return an error", func(t *testing.T) {
@@ -164,6 +171,135 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 	})
 }
 
+func Test_sensor_RegisterSensor_Adoption(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("ok, quarantined events adopted", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		first := time.Now().Add(-time.Hour)
+		last := first.Add(time.Minute)
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "1234567890").Return(nil, ErrSensorNotFound)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, ss *domain.Sensor) error {
+			ss.ID = 1
+
+			return nil
+		})
+		// состояние меняется так же, как событиями, а не перезаписывается целиком
+		sr.EXPECT().UpdateSensorState(ctx, int64(1), domain.SensorStateUpdate{State: 1, LastActivity: last}).Times(1).Return(&domain.Sensor{
+			ID:           1,
+			SerialNumber: "1234567890",
+			Type:         domain.SensorTypeContactClosure,
+			CurrentState: 1,
+			LastActivity: last,
+		}, nil)
+
+		qr := NewMockQuarantineRepository(ctrl)
+		qr.EXPECT().TakeQuarantinedEvents(ctx, "1234567890", domain.QuarantineReasonUnknownSensor).Return([]domain.QuarantinedEvent{
+			{Event: domain.Event{Timestamp: first, SensorSerialNumber: "1234567890", Payload: 0}, Reason: domain.QuarantineReasonUnknownSensor},
+			{Event: domain.Event{Timestamp: first, SensorSerialNumber: "1234567890", Payload: 5}, Reason: domain.QuarantineReasonUnknownSensor},
+			{Event: domain.Event{Timestamp: last, SensorSerialNumber: "1234567890", Payload: 1}, Reason: domain.QuarantineReasonUnknownSensor},
+		}, nil)
+		qr.EXPECT().SaveQuarantinedEvent(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, event *domain.QuarantinedEvent) error {
+			assert.Equal(t, int64(5), event.Payload)
+			assert.Equal(t, int64(1), event.SensorID)
+			assert.Equal(t, domain.QuarantineReasonInvalidPayload, event.Reason)
+
+			return nil
+		})
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(2).DoAndReturn(func(_ context.Context, event *domain.Event) error {
+			assert.Equal(t, int64(1), event.SensorID)
+
+			return nil
+		})
+
+		s := NewSensor(sr, WithAdoption(qr, er))
+
+		sensor, err := s.RegisterSensor(ctx, &domain.Sensor{
+			Type:         domain.SensorTypeContactClosure,
+			SerialNumber: "1234567890",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), sensor.ID)
+		assert.Equal(t, int64(1), sensor.CurrentState)
+	})
+
+	t.Run("ok, repeated registration adopts events left in quarantine", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		now := time.Now()
+		existing := &domain.Sensor{ID: 1, SerialNumber: "1234567890", Type: domain.SensorTypeCounter, CurrentState: 10}
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "1234567890").Return(existing, nil)
+		sr.EXPECT().UpdateSensorState(ctx, int64(1), domain.SensorStateUpdate{State: 2, Accumulate: true, LastActivity: now}).Times(1).Return(&domain.Sensor{
+			ID:           1,
+			SerialNumber: "1234567890",
+			Type:         domain.SensorTypeCounter,
+			CurrentState: 12,
+			LastActivity: now,
+		}, nil)
+
+		qr := NewMockQuarantineRepository(ctrl)
+		qr.EXPECT().TakeQuarantinedEvents(ctx, "1234567890", domain.QuarantineReasonUnknownSensor).Return([]domain.QuarantinedEvent{
+			{Event: domain.Event{Timestamp: now, SensorSerialNumber: "1234567890", Payload: 2}, Reason: domain.QuarantineReasonUnknownSensor},
+		}, nil)
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(1).Return(nil)
+
+		s := NewSensor(sr, WithAdoption(qr, er))
+
+		sensor, err := s.RegisterSensor(ctx, &domain.Sensor{
+			Type:         domain.SensorTypeCounter,
+			SerialNumber: "1234567890",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(12), sensor.CurrentState)
+	})
+
+	t.Run("fail, event save error returns events to quarantine", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "1234567890").Return(nil, ErrSensorNotFound)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Return(nil)
+
+		events := []domain.QuarantinedEvent{
+			{Event: domain.Event{Timestamp: time.Now(), SensorSerialNumber: "1234567890", Payload: 1}, Reason: domain.QuarantineReasonUnknownSensor},
+			{Event: domain.Event{Timestamp: time.Now(), SensorSerialNumber: "1234567890", Payload: 0}, Reason: domain.QuarantineReasonUnknownSensor},
+		}
+
+		qr := NewMockQuarantineRepository(ctrl)
+		qr.EXPECT().TakeQuarantinedEvents(ctx, "1234567890", domain.QuarantineReasonUnknownSensor).Return(events, nil)
+		qr.EXPECT().SaveQuarantinedEvent(ctx, gomock.Any()).Times(2).DoAndReturn(func(_ context.Context, event *domain.QuarantinedEvent) error {
+			assert.Equal(t, domain.QuarantineReasonUnknownSensor, event.Reason)
+
+			return nil
+		})
+
+		expectedError := errors.New("some error")
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(1).Return(expectedError)
+
+		s := NewSensor(sr, WithAdoption(qr, er))
+
+		_, err := s.RegisterSensor(ctx, &domain.Sensor{
+			Type:         domain.SensorTypeContactClosure,
+			SerialNumber: "1234567890",
+		})
+		assert.ErrorIs(t, err, expectedError)
+	})
+}
+
 func Test_sensor_GetSensors(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -253,3 +389,251 @@ func Test_sensor_GetSensorByID(t *testing.T) {
 		assert.NotNil(t, sensor)
 	})
 }
+
+func Test_sensor_SetCalibration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("fail, calibration not valid", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(0)
+
+		s := NewSensor(sr)
+
+		_, err := s.SetCalibration(ctx, 1, &domain.Calibration{Kind: "some"}, 0)
+		assert.ErrorIs(t, err, ErrInvalidCalibration)
+
+		_, err = s.SetCalibration(ctx, 1, &domain.Calibration{Kind: domain.CalibrationKindLinear}, 0)
+		assert.ErrorIs(t, err, ErrInvalidCalibration)
+
+		_, err = s.SetCalibration(ctx, 1, &domain.Calibration{Kind: domain.CalibrationKindTable, Points: []domain.CalibrationPoint{
+			{Raw: 10, Value: 1},
+			{Raw: 5, Value: 2},
+		}}, 0)
+		assert.ErrorIs(t, err, ErrInvalidCalibration)
+
+		_, err = s.SetCalibration(ctx, 1, &domain.Calibration{Kind: domain.CalibrationKindLinear, Scale: 1, Precision: -1}, 0)
+		assert.ErrorIs(t, err, ErrInvalidCalibration)
+	})
+
+	t.Run("fail, sensor not found", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(nil, ErrSensorNotFound)
+
+		s := NewSensor(sr)
+
+		_, err := s.SetCalibration(ctx, 1, nil, 0)
+		assert.ErrorIs(t, err, ErrSensorNotFound)
+	})
+
+	t.Run("fail, version changed", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, CurrentState: 625, Version: 3}, nil)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(0)
+
+		s := NewSensor(sr)
+
+		_, err := s.SetCalibration(ctx, 1, nil, 2)
+		assert.ErrorIs(t, err, ErrSensorVersionConflict)
+	})
+
+	t.Run("fail, version changed before save", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, Version: 2}, nil)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Return(ErrSensorVersionConflict)
+
+		s := NewSensor(sr)
+
+		_, err := s.SetCalibration(ctx, 1, nil, 2)
+		assert.ErrorIs(t, err, ErrSensorVersionConflict)
+	})
+
+	t.Run("ok, retried without version", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		gomock.InOrder(
+			sr.EXPECT().GetSensorByID(ctx, int64(1)).Return(&domain.Sensor{ID: 1, Version: 1}, nil),
+			sr.EXPECT().SaveSensor(ctx, gomock.Any()).Return(ErrSensorVersionConflict),
+			sr.EXPECT().GetSensorByID(ctx, int64(1)).Return(&domain.Sensor{ID: 1, Description: "kitchen", Version: 2}, nil),
+			sr.EXPECT().SaveSensor(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, ss *domain.Sensor) error {
+				assert.Equal(t, "kitchen", ss.Description)
+				assert.Equal(t, int64(2), ss.Version)
+				ss.Version++
+
+				return nil
+			}),
+		)
+
+		s := NewSensor(sr)
+
+		sensor, err := s.SetCalibration(ctx, 1, nil, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), sensor.Version)
+	})
+
+	t.Run("ok, calibration saved", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		calibration := &domain.Calibration{Kind: domain.CalibrationKindLinear, Scale: 0.1, Offset: -40, Unit: "°C", Precision: 1}
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, CurrentState: 625}, nil)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, ss *domain.Sensor) error {
+			assert.Equal(t, calibration, ss.Calibration)
+			assert.Equal(t, int64(625), ss.CurrentState)
+
+			return nil
+		})
+
+		s := NewSensor(sr)
+
+		sensor, err := s.SetCalibration(ctx, 1, calibration, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, calibration, sensor.Calibration)
+	})
+}
+
+func Test_sensor_SetPayloadRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("fail, range not valid", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(0)
+
+		s := NewSensor(sr)
+
+		_, err := s.SetPayloadRange(ctx, 1, &domain.PayloadRange{Min: 10, Max: 0}, 0)
+		assert.ErrorIs(t, err, ErrInvalidPayloadRange)
+	})
+
+	t.Run("fail, retries exhausted", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(maxSensorUpdateAttempts).Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeADC}, nil)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(maxSensorUpdateAttempts).Return(ErrSensorVersionConflict)
+
+		s := NewSensor(sr)
+
+		_, err := s.SetPayloadRange(ctx, 1, &domain.PayloadRange{Min: 0, Max: 10}, 0)
+		assert.ErrorIs(t, err, ErrSensorVersionConflict)
+	})
+
+	t.Run("ok, range saved", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		payloadRange := &domain.PayloadRange{Min: 0, Max: 1023}
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeADC}, nil)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, ss *domain.Sensor) error {
+			assert.Equal(t, payloadRange, ss.PayloadRange)
+
+			return nil
+		})
+
+		s := NewSensor(sr)
+
+		sensor, err := s.SetPayloadRange(ctx, 1, payloadRange, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, payloadRange, sensor.PayloadRange)
+	})
+
+	t.Run("fail, sensor type without range", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeContactClosure}, nil)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(0)
+
+		s := NewSensor(sr)
+
+		_, err := s.SetPayloadRange(ctx, 1, &domain.PayloadRange{Min: 0, Max: 1}, 0)
+		assert.ErrorIs(t, err, ErrInvalidPayloadRange)
+	})
+
+	t.Run("ok, range removed from sensor type without range", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{
+			ID:           1,
+			Type:         domain.SensorTypeContactClosure,
+			PayloadRange: &domain.PayloadRange{Min: 0, Max: 1},
+		}, nil)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Return(nil)
+
+		s := NewSensor(sr)
+
+		sensor, err := s.SetPayloadRange(ctx, 1, nil, 0)
+		assert.NoError(t, err)
+		assert.Nil(t, sensor.PayloadRange)
+	})
+}
+
+func Test_sensor_UpdateSensor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("ok, only given fields changed", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		description := "kitchen"
+		calibration := &domain.Calibration{Kind: domain.CalibrationKindLinear, Scale: 1}
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{
+			ID:           1,
+			Calibration:  calibration,
+			PayloadRange: &domain.PayloadRange{Min: 0, Max: 10},
+			Version:      4,
+		}, nil)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, ss *domain.Sensor) error {
+			assert.Equal(t, description, ss.Description)
+			assert.Equal(t, calibration, ss.Calibration)
+			assert.Nil(t, ss.PayloadRange)
+			assert.Equal(t, int64(4), ss.Version)
+
+			return nil
+		})
+
+		s := NewSensor(sr)
+
+		_, err := s.UpdateSensor(ctx, 1, SensorUpdate{Description: &description, SetPayloadRange: true}, 4)
+		assert.NoError(t, err)
+	})
+
+	t.Run("fail, calibration not valid", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		s := NewSensor(NewMockSensorRepository(ctrl))
+
+		_, err := s.UpdateSensor(ctx, 1, SensorUpdate{SetCalibration: true, Calibration: &domain.Calibration{Kind: "some"}}, 0)
+		assert.ErrorIs(t, err, ErrInvalidCalibration)
+	})
+}
@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"fmt"
+	"homework/internal/domain"
+	"sort"
+	"sync"
+)
+
+// SensorTypeBehavior - поведение типа датчика
+type SensorTypeBehavior struct {
+	// ValidatePayload - проверка значения события, nil - допустимо любое значение
+	ValidatePayload func(payload int64) error
+	// Accumulate - значения событий прибавляются к состоянию датчика, иначе состояние равно значению последнего события.
+	// Состояние меняется в хранилище атомарно, не перезаписывая параллельные изменения.
+	Accumulate bool
+	// Aggregation - способ свёртки значений за период
+	Aggregation domain.Aggregation
+	// PayloadRange - датчику можно задать допустимый диапазон значений событий
+	PayloadRange bool
+}
+
+// defaultSensorTypeBehavior используется для датчиков, тип которых не зарегистрирован:
+// состояние равно последнему значению, проверки нет
+var defaultSensorTypeBehavior = SensorTypeBehavior{
+	Aggregation: domain.AggregationLast,
+}
+
+var sensorTypes = struct {
+	mu    sync.RWMutex
+	types map[domain.SensorType]SensorTypeBehavior
+}{
+	types: map[domain.SensorType]SensorTypeBehavior{
+		domain.SensorTypeContactClosure: {
+			ValidatePayload: contactPayload,
+			Aggregation:     domain.AggregationLast,
+		},
+		domain.SensorTypeADC: {
+			Aggregation:  domain.AggregationAverage,
+			PayloadRange: true,
+		},
+		domain.SensorTypeCounter: {
+			ValidatePayload: nonNegativePayload,
+			Accumulate:      true,
+			Aggregation:     domain.AggregationDelta,
+		},
+		domain.SensorTypeSelector: {
+			ValidatePayload: nonNegativePayload,
+			Aggregation:     domain.AggregationLast,
+		},
+	},
+}
+
+// RegisterSensorType - функция регистрации нового типа датчика или замены поведения существующего
+func RegisterSensorType(t domain.SensorType, behavior SensorTypeBehavior) error {
+	if t == "" {
+		return fmt.Errorf("%w: empty type", ErrWrongSensorType)
+	}
+	if behavior.Aggregation == "" {
+		behavior.Aggregation = domain.AggregationLast
+	}
+
+	sensorTypes.mu.Lock()
+	defer sensorTypes.mu.Unlock()
+
+	sensorTypes.types[t] = behavior
+
+	return nil
+}
+
+// LookupSensorType - функция получения поведения зарегистрированного типа датчика
+func LookupSensorType(t domain.SensorType) (SensorTypeBehavior, bool) {
+	sensorTypes.mu.RLock()
+	defer sensorTypes.mu.RUnlock()
+
+	behavior, ok := sensorTypes.types[t]
+
+	return behavior, ok
+}
+
+// SensorTypes - функция получения списка зарегистрированных типов датчиков
+func SensorTypes() []domain.SensorType {
+	sensorTypes.mu.RLock()
+	defer sensorTypes.mu.RUnlock()
+
+	types := make([]domain.SensorType, 0, len(sensorTypes.types))
+	for t := range sensorTypes.types {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i] < types[j]
+	})
+
+	return types
+}
+
+func sensorTypeBehavior(t domain.SensorType) SensorTypeBehavior {
+	if behavior, ok := LookupSensorType(t); ok {
+		return behavior
+	}
+
+	return defaultSensorTypeBehavior
+}
+
+// stateUpdate - изменение состояния датчика событиями events: сумма значений или значение последнего по времени события,
+// из событий с одинаковым временем последним считается стоящее в events позже
+func (b SensorTypeBehavior) stateUpdate(events ...domain.Event) domain.SensorStateUpdate {
+	update := domain.SensorStateUpdate{Accumulate: b.Accumulate}
+	for i, event := range events {
+		if b.Accumulate {
+			update.State += event.Payload
+		} else if i == 0 || !event.Timestamp.Before(update.LastActivity) {
+			update.State = event.Payload
+		}
+		if i == 0 || event.Timestamp.After(update.LastActivity) {
+			update.LastActivity = event.Timestamp
+		}
+	}
+
+	return update
+}
+
+// contactPayload - значение сухого контакта: 0 - разомкнут, 1 - замкнут
+func contactPayload(payload int64) error {
+	if payload != 0 && payload != 1 {
+		return fmt.Errorf("%w: contact closure payload should be 0 or 1, got %d", ErrInvalidEventPayload, payload)
+	}
+
+	return nil
+}
+
+func nonNegativePayload(payload int64) error {
+	if payload < 0 {
+		return fmt.Errorf("%w: %d is negative", ErrInvalidEventPayload, payload)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RegisterSensorType(t *testing.T) {
+	t.Run("fail, empty type", func(t *testing.T) {
+		err := RegisterSensorType("", SensorTypeBehavior{})
+		assert.ErrorIs(t, err, ErrWrongSensorType)
+	})
+
+	t.Run("ok, builtin types", func(t *testing.T) {
+		types := SensorTypes()
+		assert.Contains(t, types, domain.SensorTypeContactClosure)
+		assert.Contains(t, types, domain.SensorTypeADC)
+		assert.Contains(t, types, domain.SensorTypeCounter)
+		assert.Contains(t, types, domain.SensorTypeSelector)
+	})
+
+	t.Run("ok, register custom type", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sensorType := domain.SensorType("pulse")
+		err := RegisterSensorType(sensorType, SensorTypeBehavior{
+			Accumulate:  true,
+			Aggregation: domain.AggregationLast,
+		})
+		assert.NoError(t, err)
+
+		behavior, ok := LookupSensorType(sensorType)
+		assert.True(t, ok)
+		assert.Equal(t, domain.AggregationLast, behavior.Aggregation)
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "1234567890").Times(1).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Return(nil)
+
+		_, err = NewSensor(sr).RegisterSensor(ctx, &domain.Sensor{
+			SerialNumber: "1234567890",
+			Type:         sensorType,
+		})
+		assert.NoError(t, err)
+
+		sr.EXPECT().GetSensorBySerialNumber(ctx, "1234567890").Times(1).Return(&domain.Sensor{
+			ID:           1,
+			Type:         sensorType,
+			CurrentState: 10,
+		}, nil)
+		now := time.Now()
+		sr.EXPECT().UpdateSensorState(ctx, int64(1), domain.SensorStateUpdate{State: 3, Accumulate: true, LastActivity: now}).Times(1)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(1).Return(nil)
+
+		err = NewEvent(er, sr).ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          now,
+			SensorSerialNumber: "1234567890",
+			Payload:            3,
+		})
+		assert.NoError(t, err)
+	})
+}
+
+func TestSensorTypeBehavior_stateUpdate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	events := []domain.Event{
+		{Timestamp: now.Add(time.Minute), Payload: 3},
+		{Timestamp: now, Payload: 1},
+		{Timestamp: now.Add(time.Minute), Payload: 4},
+		{Timestamp: now.Add(-time.Minute), Payload: 2},
+	}
+
+	t.Run("ok, last event by time", func(t *testing.T) {
+		update := SensorTypeBehavior{}.stateUpdate(events...)
+		assert.Equal(t, domain.SensorStateUpdate{State: 4, LastActivity: now.Add(time.Minute)}, update)
+	})
+
+	t.Run("ok, accumulated", func(t *testing.T) {
+		update := SensorTypeBehavior{Accumulate: true}.stateUpdate(events...)
+		assert.Equal(t, domain.SensorStateUpdate{State: 10, Accumulate: true, LastActivity: now.Add(time.Minute)}, update)
+	})
+}
@@ -3,23 +3,56 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 	"homework/internal/domain"
+	"time"
+
+	"go.opentelemetry.io/otel"
 )
 
+var tracer = otel.Tracer("homework/internal/usecase")
+
 var (
 	ErrWrongSensorSerialNumber = errors.New("wrong sensor serial number")
 	ErrWrongSensorType         = errors.New("wrong sensor type")
 	ErrInvalidEventTimestamp   = errors.New("invalid event timestamp")
+	ErrInvalidEventPayload     = errors.New("invalid event payload")
 	ErrInvalidUserName         = errors.New("invalid user name")
 	ErrSensorNotFound          = errors.New("sensor not found")
+	ErrSensorVersionConflict   = errors.New("sensor version conflict")
 	ErrUserNotFound            = errors.New("user not found")
 	ErrEventNotFound           = errors.New("event not found")
+	ErrInvalidCalibration      = errors.New("invalid sensor calibration")
+	ErrInvalidPayloadRange     = errors.New("invalid sensor payload range")
+	ErrInvalidTimeRange        = errors.New("invalid time range")
+	ErrIngestionQueueFull      = errors.New("ingestion queue is full")
+	ErrIngestionStopped        = errors.New("ingestion is stopped")
+	ErrRateLimited             = errors.New("too many events")
 )
 
+// RateLimitError - событие не принято из-за ограничения частоты, повторить можно через RetryAfter
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrRateLimited, e.RetryAfter)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
 //go:generate mockgen -source usecase.go -package usecase -destination usecase_mock.go
 type SensorRepository interface {
-	// SaveSensor - функция сохранения датчика
+	// SaveSensor - функция сохранения датчика. Новый датчик получает ID и версию 1.
+	// У существующего датчика меняются только метаданные и только если его версия равна sensor.Version,
+	// иначе возвращается ErrSensorVersionConflict. После сохранения sensor содержит новую версию и текущее состояние.
 	SaveSensor(ctx context.Context, sensor *domain.Sensor) error
+	// UpdateSensorState - функция изменения состояния и времени последней активности датчика событиями,
+	// см. domain.Sensor.ApplyStateUpdate. Остальные поля датчика не меняются, изменение атомарно.
+	// Возвращает датчик после изменения.
+	UpdateSensorState(ctx context.Context, id int64, update domain.SensorStateUpdate) (*domain.Sensor, error)
 	// GetSensors - функция получения списка датчиков
 	GetSensors(ctx context.Context) ([]domain.Sensor, error)
 	// GetSensorByID - функция получения датчика по ID
@@ -31,8 +64,19 @@ type SensorRepository interface {
 type EventRepository interface {
 	// SaveEvent - функция сохранения события по датчику
 	SaveEvent(ctx context.Context, event *domain.Event) error
+	// SaveEvents - функция сохранения пакета событий, события сохраняются все или ни одного
+	SaveEvents(ctx context.Context, events []domain.Event) error
 	// GetLastEventBySensorID - функция получения последнего события по ID датчика
 	GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error)
+	// GetStateIntervals - функция получения интервалов состояний датчика за период [from, to), см. domain.NewStateIntervals
+	GetStateIntervals(ctx context.Context, id int64, from, to time.Time) ([]domain.StateInterval, error)
+	// GetEvents - функция получения событий датчика за период [from, to) по возрастанию времени
+	GetEvents(ctx context.Context, id int64, from, to time.Time) ([]domain.Event, error)
+	// GetRollups - функция получения свёрток событий датчика с интервалами в [from, to) по возрастанию времени.
+	// Свёртки ведутся при сохранении событий и не удаляются вместе с ними.
+	GetRollups(ctx context.Context, id int64, resolution domain.RollupResolution, from, to time.Time) ([]domain.Rollup, error)
+	// DeleteEventsBefore - функция удаления не более limit событий датчика старше before, возвращает количество удалённых
+	DeleteEventsBefore(ctx context.Context, id int64, before time.Time, limit int) (int64, error)
 }
 
 type UserRepository interface {
@@ -40,6 +84,8 @@ type UserRepository interface {
 	SaveUser(ctx context.Context, user *domain.User) error
 	// GetUserByID - функция получения пользователя по id
 	GetUserByID(ctx context.Context, id int64) (*domain.User, error)
+	// GetUsers - функция получения всех пользователей по возрастанию id
+	GetUsers(ctx context.Context) ([]domain.User, error)
 }
 
 type SensorOwnerRepository interface {
@@ -47,4 +93,19 @@ type SensorOwnerRepository interface {
 	SaveSensorOwner(ctx context.Context, sensorOwner domain.SensorOwner) error
 	// GetSensorsByUserID -функция, возвращающая список привязок для пользователя
 	GetSensorsByUserID(ctx context.Context, userID int64) ([]domain.SensorOwner, error)
+	// GetSensorOwners - функция получения всех привязок по возрастанию id пользователя и датчика
+	GetSensorOwners(ctx context.Context) ([]domain.SensorOwner, error)
+}
+
+type QuarantineRepository interface {
+	// SaveQuarantinedEvent - функция сохранения отклонённого события
+	SaveQuarantinedEvent(ctx context.Context, event *domain.QuarantinedEvent) error
+	// GetQuarantinedEvents - функция получения отклонённых событий по причине, пустая причина - все события
+	GetQuarantinedEvents(ctx context.Context, reason domain.QuarantineReason) ([]domain.QuarantinedEvent, error)
+	// GetUnknownDevices - функция получения сводки по незарегистрированным датчикам с sampleSize последними значениями
+	GetUnknownDevices(ctx context.Context, sampleSize int) ([]domain.UnknownDevice, error)
+	// TakeQuarantinedEvents - функция извлечения событий датчика из карантина по причине, события возвращаются по возрастанию времени
+	TakeQuarantinedEvents(ctx context.Context, sn string, reason domain.QuarantineReason) ([]domain.QuarantinedEvent, error)
+	// DeleteQuarantinedEventsBefore - функция удаления событий карантина старше before, возвращает количество удалённых
+	DeleteQuarantinedEventsBefore(ctx context.Context, before time.Time) (int64, error)
 }
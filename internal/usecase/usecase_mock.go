@@ -8,6 +8,7 @@ import (
 	context "context"
 	domain "homework/internal/domain"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -94,6 +95,21 @@ func (mr *MockSensorRepositoryMockRecorder) SaveSensor(ctx, sensor interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSensor", reflect.TypeOf((*MockSensorRepository)(nil).SaveSensor), ctx, sensor)
 }
 
+// UpdateSensorState mocks base method.
+func (m *MockSensorRepository) UpdateSensorState(ctx context.Context, id int64, update domain.SensorStateUpdate) (*domain.Sensor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSensorState", ctx, id, update)
+	ret0, _ := ret[0].(*domain.Sensor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateSensorState indicates an expected call of UpdateSensorState.
+func (mr *MockSensorRepositoryMockRecorder) UpdateSensorState(ctx, id, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSensorState", reflect.TypeOf((*MockSensorRepository)(nil).UpdateSensorState), ctx, id, update)
+}
+
 // MockEventRepository is a mock of EventRepository interface.
 type MockEventRepository struct {
 	ctrl     *gomock.Controller
@@ -117,6 +133,36 @@ func (m *MockEventRepository) EXPECT() *MockEventRepositoryMockRecorder {
 	return m.recorder
 }
 
+// DeleteEventsBefore mocks base method.
+func (m *MockEventRepository) DeleteEventsBefore(ctx context.Context, id int64, before time.Time, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEventsBefore", ctx, id, before, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteEventsBefore indicates an expected call of DeleteEventsBefore.
+func (mr *MockEventRepositoryMockRecorder) DeleteEventsBefore(ctx, id, before, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEventsBefore", reflect.TypeOf((*MockEventRepository)(nil).DeleteEventsBefore), ctx, id, before, limit)
+}
+
+// GetEvents mocks base method.
+func (m *MockEventRepository) GetEvents(ctx context.Context, id int64, from, to time.Time) ([]domain.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEvents", ctx, id, from, to)
+	ret0, _ := ret[0].([]domain.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEvents indicates an expected call of GetEvents.
+func (mr *MockEventRepositoryMockRecorder) GetEvents(ctx, id, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEvents", reflect.TypeOf((*MockEventRepository)(nil).GetEvents), ctx, id, from, to)
+}
+
 // GetLastEventBySensorID mocks base method.
 func (m *MockEventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error) {
 	m.ctrl.T.Helper()
@@ -132,6 +178,36 @@ func (mr *MockEventRepositoryMockRecorder) GetLastEventBySensorID(ctx, id interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastEventBySensorID", reflect.TypeOf((*MockEventRepository)(nil).GetLastEventBySensorID), ctx, id)
 }
 
+// GetRollups mocks base method.
+func (m *MockEventRepository) GetRollups(ctx context.Context, id int64, resolution domain.RollupResolution, from, to time.Time) ([]domain.Rollup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRollups", ctx, id, resolution, from, to)
+	ret0, _ := ret[0].([]domain.Rollup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRollups indicates an expected call of GetRollups.
+func (mr *MockEventRepositoryMockRecorder) GetRollups(ctx, id, resolution, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRollups", reflect.TypeOf((*MockEventRepository)(nil).GetRollups), ctx, id, resolution, from, to)
+}
+
+// GetStateIntervals mocks base method.
+func (m *MockEventRepository) GetStateIntervals(ctx context.Context, id int64, from, to time.Time) ([]domain.StateInterval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStateIntervals", ctx, id, from, to)
+	ret0, _ := ret[0].([]domain.StateInterval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStateIntervals indicates an expected call of GetStateIntervals.
+func (mr *MockEventRepositoryMockRecorder) GetStateIntervals(ctx, id, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStateIntervals", reflect.TypeOf((*MockEventRepository)(nil).GetStateIntervals), ctx, id, from, to)
+}
+
 // SaveEvent mocks base method.
 func (m *MockEventRepository) SaveEvent(ctx context.Context, event *domain.Event) error {
 	m.ctrl.T.Helper()
@@ -146,6 +222,20 @@ func (mr *MockEventRepositoryMockRecorder) SaveEvent(ctx, event interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEvent", reflect.TypeOf((*MockEventRepository)(nil).SaveEvent), ctx, event)
 }
 
+// SaveEvents mocks base method.
+func (m *MockEventRepository) SaveEvents(ctx context.Context, events []domain.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveEvents", ctx, events)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveEvents indicates an expected call of SaveEvents.
+func (mr *MockEventRepositoryMockRecorder) SaveEvents(ctx, events interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEvents", reflect.TypeOf((*MockEventRepository)(nil).SaveEvents), ctx, events)
+}
+
 // MockUserRepository is a mock of UserRepository interface.
 type MockUserRepository struct {
 	ctrl     *gomock.Controller
@@ -184,6 +274,21 @@ func (mr *MockUserRepositoryMockRecorder) GetUserByID(ctx, id interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockUserRepository)(nil).GetUserByID), ctx, id)
 }
 
+// GetUsers mocks base method.
+func (m *MockUserRepository) GetUsers(ctx context.Context) ([]domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsers", ctx)
+	ret0, _ := ret[0].([]domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsers indicates an expected call of GetUsers.
+func (mr *MockUserRepositoryMockRecorder) GetUsers(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUserRepository)(nil).GetUsers), ctx)
+}
+
 // SaveUser mocks base method.
 func (m *MockUserRepository) SaveUser(ctx context.Context, user *domain.User) error {
 	m.ctrl.T.Helper()
@@ -221,6 +326,21 @@ func (m *MockSensorOwnerRepository) EXPECT() *MockSensorOwnerRepositoryMockRecor
 	return m.recorder
 }
 
+// GetSensorOwners mocks base method.
+func (m *MockSensorOwnerRepository) GetSensorOwners(ctx context.Context) ([]domain.SensorOwner, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSensorOwners", ctx)
+	ret0, _ := ret[0].([]domain.SensorOwner)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSensorOwners indicates an expected call of GetSensorOwners.
+func (mr *MockSensorOwnerRepositoryMockRecorder) GetSensorOwners(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSensorOwners", reflect.TypeOf((*MockSensorOwnerRepository)(nil).GetSensorOwners), ctx)
+}
+
 // GetSensorsByUserID mocks base method.
 func (m *MockSensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID int64) ([]domain.SensorOwner, error) {
 	m.ctrl.T.Helper()
@@ -249,3 +369,100 @@ func (mr *MockSensorOwnerRepositoryMockRecorder) SaveSensorOwner(ctx, sensorOwne
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSensorOwner", reflect.TypeOf((*MockSensorOwnerRepository)(nil).SaveSensorOwner), ctx, sensorOwner)
 }
+
+// MockQuarantineRepository is a mock of QuarantineRepository interface.
+type MockQuarantineRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockQuarantineRepositoryMockRecorder
+}
+
+// MockQuarantineRepositoryMockRecorder is the mock recorder for MockQuarantineRepository.
+type MockQuarantineRepositoryMockRecorder struct {
+	mock *MockQuarantineRepository
+}
+
+// NewMockQuarantineRepository creates a new mock instance.
+func NewMockQuarantineRepository(ctrl *gomock.Controller) *MockQuarantineRepository {
+	mock := &MockQuarantineRepository{ctrl: ctrl}
+	mock.recorder = &MockQuarantineRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQuarantineRepository) EXPECT() *MockQuarantineRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteQuarantinedEventsBefore mocks base method.
+func (m *MockQuarantineRepository) DeleteQuarantinedEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteQuarantinedEventsBefore", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteQuarantinedEventsBefore indicates an expected call of DeleteQuarantinedEventsBefore.
+func (mr *MockQuarantineRepositoryMockRecorder) DeleteQuarantinedEventsBefore(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteQuarantinedEventsBefore", reflect.TypeOf((*MockQuarantineRepository)(nil).DeleteQuarantinedEventsBefore), ctx, before)
+}
+
+// GetQuarantinedEvents mocks base method.
+func (m *MockQuarantineRepository) GetQuarantinedEvents(ctx context.Context, reason domain.QuarantineReason) ([]domain.QuarantinedEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQuarantinedEvents", ctx, reason)
+	ret0, _ := ret[0].([]domain.QuarantinedEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQuarantinedEvents indicates an expected call of GetQuarantinedEvents.
+func (mr *MockQuarantineRepositoryMockRecorder) GetQuarantinedEvents(ctx, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuarantinedEvents", reflect.TypeOf((*MockQuarantineRepository)(nil).GetQuarantinedEvents), ctx, reason)
+}
+
+// GetUnknownDevices mocks base method.
+func (m *MockQuarantineRepository) GetUnknownDevices(ctx context.Context, sampleSize int) ([]domain.UnknownDevice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnknownDevices", ctx, sampleSize)
+	ret0, _ := ret[0].([]domain.UnknownDevice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnknownDevices indicates an expected call of GetUnknownDevices.
+func (mr *MockQuarantineRepositoryMockRecorder) GetUnknownDevices(ctx, sampleSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnknownDevices", reflect.TypeOf((*MockQuarantineRepository)(nil).GetUnknownDevices), ctx, sampleSize)
+}
+
+// SaveQuarantinedEvent mocks base method.
+func (m *MockQuarantineRepository) SaveQuarantinedEvent(ctx context.Context, event *domain.QuarantinedEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveQuarantinedEvent", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveQuarantinedEvent indicates an expected call of SaveQuarantinedEvent.
+func (mr *MockQuarantineRepositoryMockRecorder) SaveQuarantinedEvent(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveQuarantinedEvent", reflect.TypeOf((*MockQuarantineRepository)(nil).SaveQuarantinedEvent), ctx, event)
+}
+
+// TakeQuarantinedEvents mocks base method.
+func (m *MockQuarantineRepository) TakeQuarantinedEvents(ctx context.Context, sn string, reason domain.QuarantineReason) ([]domain.QuarantinedEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeQuarantinedEvents", ctx, sn, reason)
+	ret0, _ := ret[0].([]domain.QuarantinedEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeQuarantinedEvents indicates an expected call of TakeQuarantinedEvents.
+func (mr *MockQuarantineRepositoryMockRecorder) TakeQuarantinedEvents(ctx, sn, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeQuarantinedEvents", reflect.TypeOf((*MockQuarantineRepository)(nil).TakeQuarantinedEvents), ctx, sn, reason)
+}
@@ -2,28 +2,117 @@ package usecase
 
 import (
 	"context"
+	"fmt"
 	"homework/internal/domain"
+	"homework/internal/tracing"
 )
 
 type User struct {
-	// TODO добавьте реализацию
+	ur  UserRepository
+	sor SensorOwnerRepository
+	sr  SensorRepository
 }
 
 func NewUser(ur UserRepository, sor SensorOwnerRepository, sr SensorRepository) *User {
-	return &User{}
+	return &User{
+		ur:  ur,
+		sor: sor,
+		sr:  sr,
+	}
 }
 
-func (u *User) RegisterUser(ctx context.Context, user *domain.User) (*domain.User, error) {
-	// TODO добавьте реализацию
-	return nil, nil
+func (u *User) RegisterUser(ctx context.Context, user *domain.User) (_ *domain.User, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "User.RegisterUser")
+	defer tracing.End(span, &err)
+
+	if user.Name == "" {
+		return nil, ErrInvalidUserName
+	}
+
+	if err := u.ur.SaveUser(ctx, user); err != nil {
+		return nil, fmt.Errorf("can't save user: %w", err)
+	}
+
+	return user, nil
 }
 
-func (u *User) AttachSensorToUser(ctx context.Context, userID, sensorID int64) error {
-	// TODO добавьте реализацию
+func (u *User) AttachSensorToUser(ctx context.Context, userID, sensorID int64) (err error) {
+	ctx, span := tracing.Start(ctx, tracer, "User.AttachSensorToUser")
+	defer tracing.End(span, &err)
+
+	if _, err := u.ur.GetUserByID(ctx, userID); err != nil {
+		return fmt.Errorf("can't get user by id: %w", err)
+	}
+
+	if _, err := u.sr.GetSensorByID(ctx, sensorID); err != nil {
+		return fmt.Errorf("can't get sensor by id: %w", err)
+	}
+
+	err = u.sor.SaveSensorOwner(ctx, domain.SensorOwner{
+		UserID:   userID,
+		SensorID: sensorID,
+	})
+	if err != nil {
+		return fmt.Errorf("can't save sensor owner: %w", err)
+	}
+
 	return nil
 }
 
-func (u *User) GetUserSensors(ctx context.Context, userID int64) ([]domain.Sensor, error) {
-	// TODO добавьте реализацию
-	return nil, nil
+func (u *User) GetUserSensors(ctx context.Context, userID int64) (_ []domain.Sensor, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "User.GetUserSensors")
+	defer tracing.End(span, &err)
+
+	if _, err := u.ur.GetUserByID(ctx, userID); err != nil {
+		return nil, fmt.Errorf("can't get user by id: %w", err)
+	}
+
+	owners, err := u.sor.GetSensorsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("can't get user sensors: %w", err)
+	}
+
+	sensors := make([]domain.Sensor, 0, len(owners))
+	for _, owner := range owners {
+		sensor, err := u.sr.GetSensorByID(ctx, owner.SensorID)
+		if err != nil {
+			return nil, fmt.Errorf("can't get sensor by id: %w", err)
+		}
+		sensors = append(sensors, *sensor)
+	}
+
+	return sensors, nil
+}
+
+// GetSensorOwners - функция получения владельцев всех датчиков по ID датчика,
+// владельцы каждого датчика идут по возрастанию ID. Датчики без владельцев в результат не попадают.
+func (u *User) GetSensorOwners(ctx context.Context) (_ map[int64][]domain.User, err error) {
+	ctx, span := tracing.Start(ctx, tracer, "User.GetSensorOwners")
+	defer tracing.End(span, &err)
+
+	users, err := u.ur.GetUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can't get users: %w", err)
+	}
+
+	owners, err := u.sor.GetSensorOwners(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can't get sensor owners: %w", err)
+	}
+
+	byID := make(map[int64]domain.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	result := make(map[int64][]domain.User)
+	for _, owner := range owners {
+		user, ok := byID[owner.UserID]
+		if !ok {
+			continue
+		}
+		result[owner.SensorID] = append(result[owner.SensorID], user)
+	}
+
+	return result, nil
 }
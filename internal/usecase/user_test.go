@@ -230,3 +230,41 @@ func Test_user_GetUserSensors(t *testing.T) {
 		assert.Len(t, sensors, 3)
 	})
 }
+
+func Test_user_GetSensorOwners(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("fail, users error", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		expectedError := errors.New("doh")
+		ur := NewMockUserRepository(ctrl)
+		ur.EXPECT().GetUsers(ctx).Times(1).Return(nil, expectedError)
+
+		_, err := NewUser(ur, nil, nil).GetSensorOwners(ctx)
+		assert.ErrorIs(t, err, expectedError)
+	})
+
+	t.Run("ok, owners grouped by sensor", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ur := NewMockUserRepository(ctrl)
+		ur.EXPECT().GetUsers(ctx).Times(1).Return([]domain.User{{ID: 1, Name: "Homer"}, {ID: 2, Name: "Marge"}}, nil)
+		sor := NewMockSensorOwnerRepository(ctrl)
+		sor.EXPECT().GetSensorOwners(ctx).Times(1).Return([]domain.SensorOwner{
+			{UserID: 1, SensorID: 10},
+			{UserID: 1, SensorID: 11},
+			{UserID: 2, SensorID: 10},
+		}, nil)
+
+		owners, err := NewUser(ur, sor, nil).GetSensorOwners(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, map[int64][]domain.User{
+			10: {{ID: 1, Name: "Homer"}, {ID: 2, Name: "Marge"}},
+			11: {{ID: 1, Name: "Homer"}},
+		}, owners)
+	})
+}
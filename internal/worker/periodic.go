@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+var ErrNotRunning = errors.New("worker is not running")
+
+// Periodic - фоновая задача, которая выполняется сразу после запуска и затем каждые interval
+type Periodic struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context) error
+	running  atomic.Bool
+}
+
+func NewPeriodic(name string, interval time.Duration, run func(ctx context.Context) error) *Periodic {
+	return &Periodic{
+		name:     name,
+		interval: interval,
+		run:      run,
+	}
+}
+
+// Run - выполняет задачу до отмены ctx, ошибки отдельных запусков логируются и не прерывают работу
+func (p *Periodic) Run(ctx context.Context) {
+	p.running.Store(true)
+	defer p.running.Store(false)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.run(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "periodic task failed", "task", p.name, "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check - проверка готовности: задача запущена и ещё не остановлена
+func (p *Periodic) Check() error {
+	if !p.running.Load() {
+		return fmt.Errorf("%w: %s", ErrNotRunning, p.name)
+	}
+
+	return nil
+}
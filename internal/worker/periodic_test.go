@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriodic_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var runs atomic.Int64
+	var p *Periodic
+	p = NewPeriodic("test", time.Millisecond, func(context.Context) error {
+		assert.NoError(t, p.Check())
+		if runs.Add(1) == 3 {
+			cancel()
+		}
+
+		return errors.New("some error")
+	})
+
+	assert.ErrorIs(t, p.Check(), ErrNotRunning)
+
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("periodic task was not stopped")
+	}
+	assert.Equal(t, int64(3), runs.Load())
+	assert.ErrorIs(t, p.Check(), ErrNotRunning)
+}
@@ -0,0 +1,2 @@
+alter table sensors
+    drop column calibration;
@@ -0,0 +1,7 @@
+create type sensor_type as enum ('cc', 'adc');
+
+alter table sensors
+    drop constraint sensors_type_not_empty;
+
+alter table sensors
+    alter column type type sensor_type using type::sensor_type;
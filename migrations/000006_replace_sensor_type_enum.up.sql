@@ -0,0 +1,7 @@
+alter table sensors
+    alter column type type text using type::text;
+
+alter table sensors
+    add constraint sensors_type_not_empty check (type <> '');
+
+drop type sensor_type;
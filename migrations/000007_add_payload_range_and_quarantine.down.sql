@@ -0,0 +1,6 @@
+drop table quarantined_events;
+
+alter table sensors
+    drop constraint sensors_payload_range_valid,
+    drop column payload_min,
+    drop column payload_max;
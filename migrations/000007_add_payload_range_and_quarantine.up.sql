@@ -0,0 +1,16 @@
+alter table sensors
+    add column payload_min bigint,
+    add column payload_max bigint,
+    add constraint sensors_payload_range_valid check (payload_min <= payload_max);
+
+create table quarantined_events
+(
+    timestamp               timestamp   not null,
+    sensor_serial_number    text        not null,
+    sensor_id               bigint      not null,
+    payload                 bigint      not null,
+    reason                  text        not null,
+    details                 text        not null
+);
+
+create index quarantined_events_reason_idx on quarantined_events (reason);
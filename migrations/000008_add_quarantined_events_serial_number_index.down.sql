@@ -0,0 +1 @@
+drop index quarantined_events_serial_number_idx;
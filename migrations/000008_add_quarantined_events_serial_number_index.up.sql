@@ -0,0 +1 @@
+create index quarantined_events_serial_number_idx on quarantined_events (sensor_serial_number, reason);
@@ -0,0 +1 @@
+drop index events_sensor_id_timestamp_idx;
@@ -0,0 +1 @@
+create index events_sensor_id_timestamp_idx on events (sensor_id, timestamp);
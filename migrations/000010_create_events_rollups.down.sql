@@ -0,0 +1,4 @@
+drop trigger events_rollup on events;
+drop function events_rollup();
+drop table events_daily;
+drop table events_hourly;
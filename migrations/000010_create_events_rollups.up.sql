@@ -0,0 +1,60 @@
+create table events_hourly
+(
+    sensor_id   bigint      not null,
+    bucket      timestamp   not null,
+    min         bigint      not null,
+    max         bigint      not null,
+    sum         bigint      not null,
+    count       bigint      not null,
+    primary key (sensor_id, bucket)
+);
+
+create table events_daily
+(
+    sensor_id   bigint      not null,
+    bucket      timestamp   not null,
+    min         bigint      not null,
+    max         bigint      not null,
+    sum         bigint      not null,
+    count       bigint      not null,
+    primary key (sensor_id, bucket)
+);
+
+insert into events_hourly (sensor_id, bucket, min, max, sum, count)
+select sensor_id, date_trunc('hour', timestamp), min(payload), max(payload), sum(payload), count(*)
+from events
+group by sensor_id, date_trunc('hour', timestamp);
+
+insert into events_daily (sensor_id, bucket, min, max, sum, count)
+select sensor_id, date_trunc('day', bucket), min(min), max(max), sum(sum), sum(count)
+from events_hourly
+group by sensor_id, date_trunc('day', bucket);
+
+-- свёртки ведутся при вставке событий и не меняются при их удалении
+create function events_rollup() returns trigger as
+$$
+begin
+    insert into events_hourly as r (sensor_id, bucket, min, max, sum, count)
+    values (new.sensor_id, date_trunc('hour', new.timestamp), new.payload, new.payload, new.payload, 1)
+    on conflict (sensor_id, bucket) do update
+        set min   = least(r.min, excluded.min),
+            max   = greatest(r.max, excluded.max),
+            sum   = r.sum + excluded.sum,
+            count = r.count + 1;
+
+    insert into events_daily as r (sensor_id, bucket, min, max, sum, count)
+    values (new.sensor_id, date_trunc('day', new.timestamp), new.payload, new.payload, new.payload, 1)
+    on conflict (sensor_id, bucket) do update
+        set min   = least(r.min, excluded.min),
+            max   = greatest(r.max, excluded.max),
+            sum   = r.sum + excluded.sum,
+            count = r.count + 1;
+
+    return null;
+end;
+$$ language plpgsql;
+
+create trigger events_rollup
+    after insert on events
+    for each row
+execute function events_rollup();
@@ -0,0 +1,24 @@
+drop trigger events_rollup on events;
+alter table events rename to events_partitioned;
+alter index events_sensor_id_timestamp_idx rename to events_partitioned_sensor_id_timestamp_idx;
+
+create table events
+(
+    timestamp               timestamp   not null,
+    sensor_serial_number    text        not null,
+    sensor_id               bigint      not null,
+    payload                 bigint      not null
+);
+
+insert into events (timestamp, sensor_serial_number, sensor_id, payload)
+select timestamp, sensor_serial_number, sensor_id, payload
+from events_partitioned;
+
+drop table events_partitioned;
+
+create index events_sensor_id_timestamp_idx on events (sensor_id, timestamp);
+
+create trigger events_rollup
+    after insert on events
+    for each row
+execute function events_rollup();
@@ -0,0 +1,44 @@
+alter table events rename to events_unpartitioned;
+alter index events_sensor_id_timestamp_idx rename to events_unpartitioned_sensor_id_timestamp_idx;
+drop trigger events_rollup on events_unpartitioned;
+
+create table events
+(
+    timestamp               timestamp   not null,
+    sensor_serial_number    text        not null,
+    sensor_id               bigint      not null,
+    payload                 bigint      not null
+) partition by range (timestamp);
+
+create index events_sensor_id_timestamp_idx on events (sensor_id, timestamp);
+
+-- месячные секции events_yYYYYmMM для имеющихся событий и на три месяца вперёд,
+-- дальше секции создаёт фоновая задача (см. postgres.PartitionManager)
+do
+$$
+    declare
+        partition_start timestamp := date_trunc('month', coalesce((select min(timestamp) from events_unpartitioned), now()::timestamp));
+        partition_last  timestamp := greatest(
+                date_trunc('month', coalesce((select max(timestamp) from events_unpartitioned), now()::timestamp)),
+                date_trunc('month', now()::timestamp) + interval '3 months');
+    begin
+        while partition_start <= partition_last
+            loop
+                execute format('create table %I partition of events for values from (%L) to (%L)',
+                               'events_y' || to_char(partition_start, 'YYYY') || 'm' || to_char(partition_start, 'MM'),
+                               partition_start, partition_start + interval '1 month');
+                partition_start := partition_start + interval '1 month';
+            end loop;
+    end
+$$;
+
+insert into events (timestamp, sensor_serial_number, sensor_id, payload)
+select timestamp, sensor_serial_number, sensor_id, payload
+from events_unpartitioned;
+
+drop table events_unpartitioned;
+
+create trigger events_rollup
+    after insert on events
+    for each row
+execute function events_rollup();
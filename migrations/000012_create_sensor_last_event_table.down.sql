@@ -0,0 +1 @@
+drop table sensor_last_event;
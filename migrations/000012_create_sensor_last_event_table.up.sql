@@ -0,0 +1,12 @@
+create table sensor_last_event
+(
+    sensor_id               bigint      primary key,
+    timestamp               timestamp   not null,
+    sensor_serial_number    text        not null,
+    payload                 bigint      not null
+);
+
+insert into sensor_last_event (sensor_id, timestamp, sensor_serial_number, payload)
+select distinct on (sensor_id) sensor_id, timestamp, sensor_serial_number, payload
+from events
+order by sensor_id, timestamp desc;
@@ -0,0 +1 @@
+drop index quarantined_events_timestamp_idx;
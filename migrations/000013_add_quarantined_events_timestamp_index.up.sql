@@ -0,0 +1 @@
+create index quarantined_events_timestamp_idx on quarantined_events (timestamp);
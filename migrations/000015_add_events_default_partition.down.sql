@@ -0,0 +1,26 @@
+alter table events detach partition events_default;
+
+-- события из секции по умолчанию возвращаются в месячные секции; секции заполняются до
+-- присоединения, чтобы не сработал триггер свёрток, которые уже учитывают эти события
+do
+$$
+    declare
+        partition_start timestamp;
+        partition_name  text;
+    begin
+        for partition_start in select distinct date_trunc('month', timestamp) from events_default
+            loop
+                partition_name := 'events_y' || to_char(partition_start, 'YYYY') || 'm' || to_char(partition_start, 'MM');
+                execute format('create table %I (like events)', partition_name);
+                execute format('insert into %I (timestamp, sensor_serial_number, sensor_id, payload)
+                                select timestamp, sensor_serial_number, sensor_id, payload
+                                from events_default
+                                where timestamp >= %L and timestamp < %L',
+                               partition_name, partition_start, partition_start + interval '1 month');
+                execute format('alter table events attach partition %I for values from (%L) to (%L)',
+                               partition_name, partition_start, partition_start + interval '1 month');
+            end loop;
+    end
+$$;
+
+drop table events_default;
@@ -0,0 +1,3 @@
+-- события вне месячных секций попадают в секцию по умолчанию, а не обрывают вставку;
+-- при создании месячной секции postgres.PartitionManager переносит в неё такие события
+create table events_default partition of events default;
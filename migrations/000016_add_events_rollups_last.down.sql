@@ -0,0 +1,30 @@
+create or replace function events_rollup() returns trigger as
+$$
+begin
+    insert into events_hourly as r (sensor_id, bucket, min, max, sum, count)
+    values (new.sensor_id, date_trunc('hour', new.timestamp), new.payload, new.payload, new.payload, 1)
+    on conflict (sensor_id, bucket) do update
+        set min   = least(r.min, excluded.min),
+            max   = greatest(r.max, excluded.max),
+            sum   = r.sum + excluded.sum,
+            count = r.count + 1;
+
+    insert into events_daily as r (sensor_id, bucket, min, max, sum, count)
+    values (new.sensor_id, date_trunc('day', new.timestamp), new.payload, new.payload, new.payload, 1)
+    on conflict (sensor_id, bucket) do update
+        set min   = least(r.min, excluded.min),
+            max   = greatest(r.max, excluded.max),
+            sum   = r.sum + excluded.sum,
+            count = r.count + 1;
+
+    return null;
+end;
+$$ language plpgsql;
+
+alter table events_daily
+    drop column last,
+    drop column last_at;
+
+alter table events_hourly
+    drop column last,
+    drop column last_at;
@@ -0,0 +1,56 @@
+-- последнее значение интервала для истории датчиков состояния; для интервалов, события которых
+-- уже удалены, последнее значение неизвестно и остаётся 0
+alter table events_hourly
+    add column last    bigint    not null default 0,
+    add column last_at timestamp;
+
+alter table events_daily
+    add column last    bigint    not null default 0,
+    add column last_at timestamp;
+
+update events_hourly r
+set last    = e.payload,
+    last_at = e.timestamp
+from (select distinct on (sensor_id, date_trunc('hour', timestamp)) sensor_id, date_trunc('hour', timestamp) as bucket, timestamp, payload
+      from events
+      order by sensor_id, date_trunc('hour', timestamp), timestamp desc) e
+where r.sensor_id = e.sensor_id
+  and r.bucket = e.bucket;
+
+update events_daily r
+set last    = h.last,
+    last_at = h.last_at
+from (select distinct on (sensor_id, date_trunc('day', bucket)) sensor_id, date_trunc('day', bucket) as bucket, last, last_at
+      from events_hourly
+      where last_at is not null
+      order by sensor_id, date_trunc('day', bucket), last_at desc) h
+where r.sensor_id = h.sensor_id
+  and r.bucket = h.bucket;
+
+-- последнее значение заменяется только более новым событием; greatest пропускает NULL
+create or replace function events_rollup() returns trigger as
+$$
+begin
+    insert into events_hourly as r (sensor_id, bucket, min, max, sum, count, last, last_at)
+    values (new.sensor_id, date_trunc('hour', new.timestamp), new.payload, new.payload, new.payload, 1, new.payload, new.timestamp)
+    on conflict (sensor_id, bucket) do update
+        set min     = least(r.min, excluded.min),
+            max     = greatest(r.max, excluded.max),
+            sum     = r.sum + excluded.sum,
+            count   = r.count + 1,
+            last    = case when r.last_at is null or r.last_at <= excluded.last_at then excluded.last else r.last end,
+            last_at = greatest(r.last_at, excluded.last_at);
+
+    insert into events_daily as r (sensor_id, bucket, min, max, sum, count, last, last_at)
+    values (new.sensor_id, date_trunc('day', new.timestamp), new.payload, new.payload, new.payload, 1, new.payload, new.timestamp)
+    on conflict (sensor_id, bucket) do update
+        set min     = least(r.min, excluded.min),
+            max     = greatest(r.max, excluded.max),
+            sum     = r.sum + excluded.sum,
+            count   = r.count + 1,
+            last    = case when r.last_at is null or r.last_at <= excluded.last_at then excluded.last else r.last end,
+            last_at = greatest(r.last_at, excluded.last_at);
+
+    return null;
+end;
+$$ language plpgsql;
@@ -0,0 +1,9 @@
+// Package migrations - SQL-миграции схемы postgres, встроенные в бинарный файл.
+package migrations
+
+import "embed"
+
+// FS - файлы миграций в формате golang-migrate
+//
+//go:embed *.sql
+var FS embed.FS
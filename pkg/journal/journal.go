@@ -0,0 +1,296 @@
+// Package journal - хранение состояния в локальных файлах: снимок состояния и журнал изменений после него.
+//
+// Каждое изменение дописывается в <name>.log отдельной строкой JSON и сбрасывается на диск до возврата из Append.
+// Snapshot записывает состояние целиком в <name>.snapshot и очищает журнал. При открытии состояние
+// восстанавливается из снимка, после чего к нему применяются записи журнала. Записи нумеруются,
+// поэтому записи, уже вошедшие в снимок, повторно не применяются, даже если журнал не успел очиститься.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DefaultSnapshotEvery - количество записей журнала, после которого NeedsSnapshot предлагает сделать снимок
+const DefaultSnapshotEvery = 10000
+
+var ErrCorrupted = errors.New("journal is corrupted")
+
+type Journal struct {
+	mu            sync.Mutex
+	logPath       string
+	snapshotPath  string
+	log           logFile
+	seq           uint64
+	records       int
+	snapshotEvery int
+}
+
+// logFile - открытый файл журнала, в тестах подменяется для имитации ошибок записи
+type logFile interface {
+	io.WriteSeeker
+	Sync() error
+	Truncate(size int64) error
+	Close() error
+}
+
+type record struct {
+	Seq  uint64          `json:"seq"`
+	Op   string          `json:"op"`
+	Data json.RawMessage `json:"data"`
+}
+
+type snapshot struct {
+	Seq  uint64          `json:"seq"`
+	Data json.RawMessage `json:"data"`
+}
+
+// WithSnapshotEvery - опция, задающая количество записей журнала между снимками
+func WithSnapshotEvery(n int) func(*Journal) {
+	return func(j *Journal) {
+		j.snapshotEvery = n
+	}
+}
+
+// Open - открывает журнал name в каталоге dir, создавая его при необходимости.
+// restore вызывается со снимком, если он есть, затем apply - для каждой записи журнала после снимка.
+// Недописанная последняя запись, например после аварийного завершения, отбрасывается.
+func Open(
+	dir, name string,
+	restore func(data json.RawMessage) error,
+	apply func(op string, data json.RawMessage) error,
+	options ...func(*Journal),
+) (*Journal, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("can't create journal directory: %w", err)
+	}
+
+	j := &Journal{
+		logPath:       filepath.Join(dir, name+".log"),
+		snapshotPath:  filepath.Join(dir, name+".snapshot"),
+		snapshotEvery: DefaultSnapshotEvery,
+	}
+	for _, option := range options {
+		option(j)
+	}
+
+	if err := j.restore(restore); err != nil {
+		return nil, err
+	}
+
+	log, err := os.OpenFile(j.logPath, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("can't open journal: %w", err)
+	}
+	if err := j.replay(log, apply); err != nil {
+		_ = log.Close()
+		return nil, err
+	}
+	j.log = log
+
+	return j, nil
+}
+
+// Append - дописывает запись в журнал и сбрасывает её на диск.
+// Если запись не удалась, журнал обрезается до её начала, чтобы следующая запись не продолжила оборванную строку.
+func (j *Journal) Append(op string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("can't marshal journal record: %w", err)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	line, err := json.Marshal(record{Seq: j.seq + 1, Op: op, Data: payload})
+	if err != nil {
+		return fmt.Errorf("can't marshal journal record: %w", err)
+	}
+
+	offset, err := j.log.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("can't seek journal: %w", err)
+	}
+	if _, err := j.log.Write(append(line, '\n')); err != nil {
+		return j.rollback(offset, fmt.Errorf("can't write journal record: %w", err))
+	}
+	if err := j.log.Sync(); err != nil {
+		return j.rollback(offset, fmt.Errorf("can't sync journal: %w", err))
+	}
+	j.seq++
+	j.records++
+
+	return nil
+}
+
+// NeedsSnapshot - набралось ли в журнале достаточно записей, чтобы заменить их снимком
+func (j *Journal) NeedsSnapshot() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.snapshotEvery > 0 && j.records >= j.snapshotEvery
+}
+
+// Snapshot - записывает состояние, включающее все записи журнала, и очищает журнал.
+// Вызывающий отвечает за то, чтобы между получением состояния и вызовом не было Append.
+func (j *Journal) Snapshot(data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("can't marshal snapshot: %w", err)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	content, err := json.Marshal(snapshot{Seq: j.seq, Data: payload})
+	if err != nil {
+		return fmt.Errorf("can't marshal snapshot: %w", err)
+	}
+	if err := WriteFileAtomic(j.snapshotPath, content); err != nil {
+		return err
+	}
+
+	// если процесс завершится до очистки, записи журнала будут пропущены по номеру
+	if err := j.log.Truncate(0); err != nil {
+		return fmt.Errorf("can't truncate journal: %w", err)
+	}
+	if _, err := j.log.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("can't truncate journal: %w", err)
+	}
+	if err := j.log.Sync(); err != nil {
+		return fmt.Errorf("can't sync journal: %w", err)
+	}
+	j.records = 0
+
+	return nil
+}
+
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.log.Close()
+}
+
+// rollback - отрезает от журнала всё, что записано после offset, и возвращает err вместе с ошибками отката
+func (j *Journal) rollback(offset int64, err error) error {
+	if truncateErr := j.log.Truncate(offset); truncateErr != nil {
+		return errors.Join(err, fmt.Errorf("can't truncate journal: %w", truncateErr))
+	}
+	if _, seekErr := j.log.Seek(offset, io.SeekStart); seekErr != nil {
+		return errors.Join(err, fmt.Errorf("can't seek journal: %w", seekErr))
+	}
+
+	return err
+}
+
+func (j *Journal) restore(restore func(data json.RawMessage) error) error {
+	content, err := os.ReadFile(j.snapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("can't read snapshot: %w", err)
+	}
+
+	var s snapshot
+	if err := json.Unmarshal(content, &s); err != nil {
+		return fmt.Errorf("%w: can't unmarshal snapshot %s: %w", ErrCorrupted, j.snapshotPath, err)
+	}
+	if err := restore(s.Data); err != nil {
+		return fmt.Errorf("can't restore snapshot %s: %w", j.snapshotPath, err)
+	}
+	j.seq = s.Seq
+
+	return nil
+}
+
+func (j *Journal) replay(log *os.File, apply func(op string, data json.RawMessage) error) error {
+	reader := bufio.NewReader(log)
+
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// строка без перевода строки - запись, прерванная на середине
+			if len(line) > 0 {
+				if err := log.Truncate(offset); err != nil {
+					return fmt.Errorf("can't truncate incomplete journal record: %w", err)
+				}
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("can't read journal: %w", err)
+		}
+
+		var r record
+		if err := json.Unmarshal(bytes.TrimSpace(line), &r); err != nil {
+			return fmt.Errorf("%w: can't unmarshal record at offset %d in %s: %w", ErrCorrupted, offset, j.logPath, err)
+		}
+		offset += int64(len(line))
+
+		if r.Seq <= j.seq {
+			continue
+		}
+		if r.Seq != j.seq+1 {
+			return fmt.Errorf("%w: record %d follows %d in %s", ErrCorrupted, r.Seq, j.seq, j.logPath)
+		}
+		if err := apply(r.Op, r.Data); err != nil {
+			return fmt.Errorf("can't apply journal record %d: %w", r.Seq, err)
+		}
+		j.seq = r.Seq
+		j.records++
+	}
+
+	if _, err := log.Seek(0, io.SeekEnd); err != nil {
+		return fmt.Errorf("can't seek journal: %w", err)
+	}
+
+	return nil
+}
+
+// WriteFileAtomic - записывает файл через временный и переименование, чтобы не оставить его недописанным
+func WriteFileAtomic(path string, content []byte) error {
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("can't create %s: %w", tmp, err)
+	}
+	if _, err := f.Write(content); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("can't write %s: %w", tmp, err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("can't sync %s: %w", tmp, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("can't close %s: %w", tmp, err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("can't rename %s: %w", tmp, err)
+	}
+
+	// переименование надёжно только после сброса каталога
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return fmt.Errorf("can't open directory: %w", err)
+	}
+	defer dir.Close()
+
+	if err := dir.Sync(); err != nil {
+		return fmt.Errorf("can't sync directory: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// counter - состояние для тестов: сумма значений из записей журнала
+type counter struct {
+	sum     int
+	applied int
+}
+
+func (c *counter) restore(data json.RawMessage) error {
+	return json.Unmarshal(data, &c.sum)
+}
+
+func (c *counter) apply(op string, data json.RawMessage) error {
+	var v int
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	c.sum += v
+	c.applied++
+
+	return nil
+}
+
+func open(t *testing.T, dir string, options ...func(*Journal)) (*Journal, *counter) {
+	t.Helper()
+
+	c := &counter{}
+	j, err := Open(dir, "test", c.restore, c.apply, options...)
+	require.NoError(t, err)
+
+	return j, c
+}
+
+func TestJournal_Replay(t *testing.T) {
+	dir := t.TempDir()
+
+	j, _ := open(t, dir)
+	for _, v := range []int{1, 2, 3} {
+		assert.NoError(t, j.Append("add", v))
+	}
+	assert.NoError(t, j.Close())
+
+	j, c := open(t, dir)
+	defer j.Close()
+
+	assert.Equal(t, 6, c.sum)
+	assert.Equal(t, 3, c.applied)
+
+	// новые записи продолжают нумерацию
+	assert.NoError(t, j.Append("add", 4))
+	assert.NoError(t, j.Close())
+
+	_, c = open(t, dir)
+	assert.Equal(t, 10, c.sum)
+}
+
+func TestJournal_Snapshot(t *testing.T) {
+	dir := t.TempDir()
+
+	j, _ := open(t, dir, WithSnapshotEvery(2))
+	assert.NoError(t, j.Append("add", 1))
+	assert.False(t, j.NeedsSnapshot())
+	assert.NoError(t, j.Append("add", 2))
+	assert.True(t, j.NeedsSnapshot())
+
+	assert.NoError(t, j.Snapshot(3))
+	assert.False(t, j.NeedsSnapshot())
+	assert.NoError(t, j.Append("add", 4))
+	assert.NoError(t, j.Close())
+
+	j, c := open(t, dir)
+	defer j.Close()
+
+	assert.Equal(t, 7, c.sum)
+	assert.Equal(t, 1, c.applied)
+}
+
+func TestJournal_SnapshotBeforeTruncate(t *testing.T) {
+	dir := t.TempDir()
+
+	j, _ := open(t, dir)
+	assert.NoError(t, j.Append("add", 1))
+	assert.NoError(t, j.Append("add", 2))
+	assert.NoError(t, j.Close())
+
+	// процесс завершился после записи снимка, но до очистки журнала
+	log, err := os.ReadFile(filepath.Join(dir, "test.log"))
+	require.NoError(t, err)
+
+	j, _ = open(t, dir)
+	assert.NoError(t, j.Snapshot(3))
+	assert.NoError(t, j.Close())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "test.log"), log, 0o644))
+
+	j, c := open(t, dir)
+	defer j.Close()
+
+	assert.Equal(t, 3, c.sum)
+	assert.Equal(t, 0, c.applied)
+}
+
+func TestJournal_IncompleteRecord(t *testing.T) {
+	dir := t.TempDir()
+
+	j, _ := open(t, dir)
+	assert.NoError(t, j.Append("add", 1))
+	assert.NoError(t, j.Close())
+
+	f, err := os.OpenFile(filepath.Join(dir, "test.log"), os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"seq":2,"op":"add","da`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	j, c := open(t, dir)
+	assert.Equal(t, 1, c.sum)
+	assert.NoError(t, j.Append("add", 2))
+	assert.NoError(t, j.Close())
+
+	_, c = open(t, dir)
+	assert.Equal(t, 3, c.sum)
+}
+
+// tornFile - файл журнала, запись в который обрывается на середине
+type tornFile struct {
+	logFile
+}
+
+func (f tornFile) Write(p []byte) (int, error) {
+	n, _ := f.logFile.Write(p[:len(p)/2])
+
+	return n, errors.New("no space left on device")
+}
+
+func TestJournal_TornAppend(t *testing.T) {
+	dir := t.TempDir()
+
+	j, _ := open(t, dir)
+	assert.NoError(t, j.Append("add", 1))
+
+	log := j.log
+	j.log = tornFile{log}
+	assert.Error(t, j.Append("add", 2))
+	j.log = log
+
+	// оборванная строка отрезана, следующая запись начинается с новой строки
+	assert.NoError(t, j.Append("add", 3))
+	assert.NoError(t, j.Close())
+
+	j, c := open(t, dir)
+	defer j.Close()
+
+	assert.Equal(t, 4, c.sum)
+	assert.Equal(t, 2, c.applied)
+}
+
+func TestJournal_Corrupted(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "test.log"), []byte("garbage\n"), 0o644))
+
+	_, err := Open(dir, "test", (&counter{}).restore, (&counter{}).apply)
+	assert.ErrorIs(t, err, ErrCorrupted)
+}
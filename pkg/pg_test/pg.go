@@ -6,17 +6,16 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"path/filepath"
-	"runtime"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	_ "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jackc/pgx/v5/pgxpool"
-	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
+
+	"homework/migrations"
 )
 
 const (
@@ -106,14 +105,13 @@ func createContainer(ctx context.Context) (testcontainers.Container, *pgxpool.Po
 }
 
 func migrateDb(dbAddr string) error {
-	_, path, _, ok := runtime.Caller(0)
-	if !ok {
-		return fmt.Errorf("failed to get path")
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return err
 	}
-	pathToMigrationFiles := filepath.Dir(path) + "/../../migrations"
 
-	databaseURL := fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=disable", DbUser, DbPass, dbAddr, DbName)
-	m, err := migrate.New(fmt.Sprintf("file:%s", pathToMigrationFiles), databaseURL)
+	databaseURL := fmt.Sprintf("pgx5://%s:%s@%s/%s?sslmode=disable", DbUser, DbPass, dbAddr, DbName)
+	m, err := migrate.NewWithSourceInstance("iofs", source, databaseURL)
 	if err != nil {
 		return err
 	}